@@ -0,0 +1,119 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+// BackfillCheckpoint 保存批量回填的进度，中断后从上次完成的位置继续
+type BackfillCheckpoint interface {
+	// Load 返回已完成的对话数，没有进度时返回 0
+	Load(ctx context.Context) (int, error)
+
+	// Save 记录已完成的对话数
+	Save(ctx context.Context, done int) error
+}
+
+// FileCheckpoint 以 JSON 文件保存回填进度，先写临时文件再重命名，进程中断也不会留下半份文件
+type FileCheckpoint struct {
+	path string
+}
+
+// NewFileCheckpoint 创建保存在 path 的回填进度
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+type fileCheckpointState struct {
+	Done int `json:"done"`
+}
+
+// Load 读取进度文件，文件不存在视为从头开始
+func (f *FileCheckpoint) Load(ctx context.Context) (int, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read checkpoint: %w", err)
+	}
+
+	var state fileCheckpointState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("decode checkpoint: %w", err)
+	}
+	return state.Done, nil
+}
+
+// Save 写入进度文件
+func (f *FileCheckpoint) Save(ctx context.Context, done int) error {
+	data, _ := json.Marshal(fileCheckpointState{Done: done})
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Backfill 依次添加历史对话，每完成一条保存进度；重启后跳过 checkpoint 中已完成的对话
+// ctx 取消或某条对话添加失败时停止并返回错误，已完成的进度保留，再次调用从失败的对话继续
+func (m *Memory) Backfill(ctx context.Context, reqs []*domain.AddRequest, checkpoint BackfillCheckpoint) (*domain.BackfillResponse, error) {
+	done, err := checkpoint.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: load checkpoint: %w", domain.ErrStoreUnavailable, err)
+	}
+	if done > len(reqs) {
+		return nil, fmt.Errorf("%w: checkpoint at %d exceeds %d conversations", domain.ErrInvalidInput, done, len(reqs))
+	}
+
+	m.logger.Info("backfill", "conversations", len(reqs), "resume_from", done)
+
+	resp := &domain.BackfillResponse{Skipped: done}
+	for i := done; i < len(reqs); i++ {
+		if err := ctx.Err(); err != nil {
+			return resp, err
+		}
+
+		addResp, err := m.Add(ctx, reqs[i])
+		if err != nil {
+			return resp, fmt.Errorf("backfill conversation %d: %w", i, err)
+		}
+		resp.Summaries += len(addResp.Summaries)
+		resp.Events += len(addResp.Events)
+		resp.Warnings = append(resp.Warnings, addResp.Warnings...)
+
+		if err := checkpoint.Save(ctx, i+1); err != nil {
+			return resp, fmt.Errorf("%w: save checkpoint: %w", domain.ErrStoreUnavailable, err)
+		}
+		resp.Processed++
+	}
+
+	m.logger.Info("backfill completed",
+		"processed", resp.Processed,
+		"skipped", resp.Skipped,
+		"summaries", resp.Summaries,
+		"events", resp.Events,
+	)
+
+	resp.Success = true
+	return resp, nil
+}
@@ -0,0 +1,83 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+func TestMemory_Backfill(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	// 记录被提取的对话（记忆与事件提取各调用一次，只记一次）
+	var seen []string
+	helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		for _, msg := range req.Messages {
+			for i := 0; i < 5; i++ {
+				text := fmt.Sprintf("第 %d 段对话", i)
+				if strings.Contains(msg.Text(), text) && (len(seen) == 0 || seen[len(seen)-1] != text) {
+					seen = append(seen, text)
+				}
+			}
+		}
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage(`{"memories":[],"events":[]}`)}, nil
+	})
+
+	reqs := make([]*domain.AddRequest, 5)
+	for i := range reqs {
+		reqs[i] = &domain.AddRequest{
+			AgentID:   "agent_1",
+			UserID:    "user_1",
+			SessionID: fmt.Sprintf("session_%d", i),
+			Messages:  []domain.Message{{Role: domain.RoleUser, Content: fmt.Sprintf("第 %d 段对话", i)}},
+		}
+	}
+	memory := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore())
+	checkpoint := NewFileCheckpoint(filepath.Join(t.TempDir(), "backfill.json"))
+
+	t.Run("interrupted backfill keeps progress", func(t *testing.T) {
+		interrupted := *reqs[2]
+		interrupted.Messages = nil // 第 3 段校验失败，模拟中途中断
+		_, err := memory.Backfill(ctx, []*domain.AddRequest{reqs[0], reqs[1], &interrupted, reqs[3], reqs[4]}, checkpoint)
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+
+		done, err := checkpoint.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, done)
+	})
+
+	t.Run("resumed backfill skips processed items", func(t *testing.T) {
+		seen = nil
+		resp, err := memory.Backfill(ctx, reqs, checkpoint)
+		require.NoError(t, err)
+
+		assert.True(t, resp.Success)
+		assert.Equal(t, 2, resp.Skipped)
+		assert.Equal(t, 3, resp.Processed)
+		assert.Equal(t, []string{"第 2 段对话", "第 3 段对话", "第 4 段对话"}, seen)
+
+		done, err := checkpoint.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 5, done)
+	})
+
+	t.Run("cancelled context stops before the next item", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		fresh := NewFileCheckpoint(filepath.Join(t.TempDir(), "backfill.json"))
+		resp, err := memory.Backfill(cancelled, reqs, fresh)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, resp.Processed)
+	})
+}
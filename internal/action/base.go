@@ -2,6 +2,7 @@ package action
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -31,14 +32,14 @@ type BaseAction struct {
 func NewBaseAction(name string) *BaseAction {
 	return &BaseAction{
 		name:   name,
-		logger: slog.Default().With("module", name),
+		logger: actionLogger(name),
 		g:      pkggenkit.Genkit(),
 	}
 }
 
-// GenEmbedding 生成文本的向量表示
+// GenEmbedding 生成文本的向量表示，配置了 truncate_embedding_dim 时截断到该维度
 func (b *BaseAction) GenEmbedding(ctx context.Context, embedderName, text string) ([]float32, error) {
-	resp, err := genkit.Embed(ctx, b.g, ai.WithEmbedderName(embedderName), ai.WithTextDocs(text))
+	resp, err := b.embed(ctx, embedderName, text)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +48,31 @@ func (b *BaseAction) GenEmbedding(ctx context.Context, embedderName, text string
 		return nil, fmt.Errorf("empty embedding response")
 	}
 
-	return resp.Embeddings[0].Embedding, nil
+	return truncateEmbedding(resp.Embeddings[0].Embedding, GetConfig().TruncateEmbeddingDim), nil
+}
+
+// truncateEmbedding 保留前 dim 维并重新做 L2 归一化，dim <= 0 或向量不超过 dim 时原样返回
+// 只适用于前若干维即可表达语义的 embedding 模型（如 Matryoshka 训练的模型）
+func truncateEmbedding(embedding []float32, dim int) []float32 {
+	if dim <= 0 || len(embedding) <= dim {
+		return embedding
+	}
+
+	truncated := make([]float32, dim)
+	copy(truncated, embedding)
+
+	var norm float64
+	for _, v := range truncated {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return truncated
+	}
+	norm = math.Sqrt(norm)
+	for i := range truncated {
+		truncated[i] = float32(float64(truncated[i]) / norm)
+	}
+	return truncated
 }
 
 // Generate 调用 LLM 生成内容
@@ -57,9 +82,9 @@ func (b *BaseAction) Generate(c *domain.AddContext, promptName string, input map
 		return fmt.Errorf("prompt not found: %s", promptName)
 	}
 
-	resp, err := prompt.Execute(c.Context, ai.WithInput(input))
+	resp, err := b.execute(c.Context, prompt, input)
 	if err != nil {
-		return fmt.Errorf("prompt execute failed: %w", err)
+		return err
 	}
 
 	if resp == nil {
@@ -90,9 +115,9 @@ func (b *BaseAction) GenerateWithContext(ctx context.Context, promptName string,
 		return fmt.Errorf("prompt not found: %s", promptName)
 	}
 
-	resp, err := prompt.Execute(ctx, ai.WithInput(input))
+	resp, err := b.execute(ctx, prompt, input)
 	if err != nil {
-		return fmt.Errorf("prompt execute failed: %w", err)
+		return err
 	}
 
 	if resp == nil {
@@ -106,6 +131,70 @@ func (b *BaseAction) GenerateWithContext(ctx context.Context, promptName string,
 	return nil
 }
 
+// embed 调用 embedder，主 embedder 失败或熔断时切换到备用 embedder
+func (b *BaseAction) embed(ctx context.Context, embedderName, text string) (*ai.EmbedResponse, error) {
+	limiter := LLMLimiter()
+	if err := limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer limiter.Release()
+
+	breaker := LLMBreaker()
+	err := breaker.Allow()
+	if err == nil {
+		var resp *ai.EmbedResponse
+		resp, err = genkit.Embed(ctx, b.g, ai.WithEmbedderName(embedderName), ai.WithTextDocs(text))
+		breaker.Record(err)
+		if err == nil {
+			return resp, nil
+		}
+	}
+
+	fallback := GetConfig().Fallback.Embedder
+	if fallback == "" || fallback == embedderName {
+		return nil, fmt.Errorf("%w: embed failed: %w", domain.ErrLLMUnavailable, err)
+	}
+
+	b.logger.Warn("primary embedder failed, using fallback", "embedder", embedderName, "fallback", fallback, "error", err)
+	resp, fallbackErr := genkit.Embed(ctx, b.g, ai.WithEmbedderName(fallback), ai.WithTextDocs(text))
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w: embed failed: %w", domain.ErrLLMUnavailable, errors.Join(err, fallbackErr))
+	}
+	return resp, nil
+}
+
+// execute 执行 prompt，主模型失败或熔断时用备用模型重试
+func (b *BaseAction) execute(ctx context.Context, prompt ai.Prompt, input map[string]any) (*ai.ModelResponse, error) {
+	limiter := LLMLimiter()
+	if err := limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer limiter.Release()
+
+	breaker := LLMBreaker()
+	err := breaker.Allow()
+	if err == nil {
+		var resp *ai.ModelResponse
+		resp, err = prompt.Execute(ctx, ai.WithInput(input))
+		breaker.Record(err)
+		if err == nil {
+			return resp, nil
+		}
+	}
+
+	fallback := GetConfig().Fallback.LLM
+	if fallback == "" {
+		return nil, fmt.Errorf("%w: prompt execute failed: %w", domain.ErrLLMUnavailable, err)
+	}
+
+	b.logger.Warn("primary model failed, using fallback", "prompt", prompt.Name(), "fallback", fallback, "error", err)
+	resp, fallbackErr := prompt.Execute(ctx, ai.WithInput(input), ai.WithModelName(fallback))
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w: prompt execute failed: %w", domain.ErrLLMUnavailable, errors.Join(err, fallbackErr))
+	}
+	return resp, nil
+}
+
 // CosineSimilarity 计算两个向量的余弦相似度
 func (b *BaseAction) CosineSimilarity(vec1, vec2 []float32) float64 {
 	if len(vec1) != len(vec2) || len(vec1) == 0 {
@@ -126,6 +215,61 @@ func (b *BaseAction) CosineSimilarity(vec1, vec2 []float32) float64 {
 	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
 }
 
+// NormalizeVector 返回单位化后的向量副本，归一化后的向量间余弦相似度即点积
+// 零向量或空向量返回 nil
+func NormalizeVector(vec []float32) []float32 {
+	norm := vectorNorm(vec)
+	if norm == 0 {
+		return nil
+	}
+
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = float32(float64(v) / norm)
+	}
+	return out
+}
+
+// DotProduct 计算两个向量的点积，长度不一致时返回 0
+func DotProduct(vec1, vec2 []float32) float64 {
+	if len(vec1) != len(vec2) {
+		return 0
+	}
+
+	var sum float64
+	for i := range vec1 {
+		sum += float64(vec1[i]) * float64(vec2[i])
+	}
+	return sum
+}
+
+// NormedVector 缓存了范数的向量，用于一个向量与多个候选循环比较，避免重复计算范数
+type NormedVector struct {
+	Vec  []float32
+	Norm float64
+}
+
+// NewNormedVector 计算并缓存向量范数
+func NewNormedVector(vec []float32) NormedVector {
+	return NormedVector{Vec: vec, Norm: vectorNorm(vec)}
+}
+
+// Similarity 计算与另一向量的余弦相似度，结果与 CosineSimilarity 一致
+func (v NormedVector) Similarity(other NormedVector) float64 {
+	if len(v.Vec) != len(other.Vec) || len(v.Vec) == 0 || v.Norm == 0 || other.Norm == 0 {
+		return 0
+	}
+	return DotProduct(v.Vec, other.Vec) / (v.Norm * other.Norm)
+}
+
+func vectorNorm(vec []float32) float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
 // DocToSummaryMemory 将 map 转换为 SummaryMemory
 func (b *BaseAction) DocToSummaryMemory(doc map[string]any) *domain.SummaryMemory {
 	var s domain.SummaryMemory
@@ -151,6 +295,31 @@ func (b *BaseAction) DocToSummaryMemory(doc map[string]any) *domain.SummaryMemor
 	return &s
 }
 
+// DocToConflictRecord 将 map 转换为 ConflictRecord
+func (b *BaseAction) DocToConflictRecord(doc map[string]any) domain.ConflictRecord {
+	var r domain.ConflictRecord
+
+	config := &mapstructure.DecoderConfig{
+		Result:           &r,
+		TagName:          "json",
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(b.timeHook, b.timePointerHook),
+	}
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		b.logger.Error("failed to create decoder", "error", err)
+		return domain.ConflictRecord{}
+	}
+
+	if err := decoder.Decode(doc); err != nil {
+		b.logger.Error("failed to decode doc to conflict record", "error", err)
+		return domain.ConflictRecord{}
+	}
+
+	return r
+}
+
 // DocToEventTriplet 将 map 转换为 EventTriplet
 func (b *BaseAction) DocToEventTriplet(doc map[string]any) *domain.EventTriplet {
 	var e domain.EventTriplet
@@ -159,7 +328,7 @@ func (b *BaseAction) DocToEventTriplet(doc map[string]any) *domain.EventTriplet
 		Result:           &e,
 		TagName:          "json",
 		WeaklyTypedInput: true,
-		DecodeHook:       mapstructure.ComposeDecodeHookFunc(b.float32SliceHook, b.timeHook),
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(b.float32SliceHook, b.timeHook, b.timePointerHook),
 	}
 
 	decoder, err := mapstructure.NewDecoder(config)
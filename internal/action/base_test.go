@@ -0,0 +1,219 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+func TestBaseAction_LogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	cfg := DefaultConfig()
+	cfg.LogLevels = map[string]string{"noisy": "warn"}
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Init(DefaultConfig()) })
+
+	t.Run("configured action drops logs below its level", func(t *testing.T) {
+		buf.Reset()
+		logger := NewBaseAction("noisy").logger
+		logger.Debug("noisy debug")
+		logger.Info("noisy info")
+		logger.With("stage", "search").Warn("noisy warn")
+
+		assert.NotContains(t, buf.String(), "noisy debug")
+		assert.NotContains(t, buf.String(), "noisy info")
+		assert.Contains(t, buf.String(), "noisy warn")
+		assert.Contains(t, buf.String(), "module=noisy")
+	})
+
+	t.Run("other actions keep the global level", func(t *testing.T) {
+		buf.Reset()
+		NewBaseAction("quiet").logger.Debug("quiet debug")
+		assert.Contains(t, buf.String(), "quiet debug")
+	})
+
+	t.Run("invalid level is rejected", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.LogLevels = map[string]string{"noisy": "verbose"}
+		assert.Error(t, cfg.Validate())
+	})
+}
+
+func TestBaseAction_TruncateEmbedding(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	full := make([]float32, 4096)
+	for i := range full {
+		full[i] = 1
+	}
+	helper.SetEmbedderVector(full)
+
+	cfg := DefaultConfig()
+	cfg.TruncateEmbeddingDim = 2560
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Init(DefaultConfig()) })
+
+	t.Run("truncated and renormalized", func(t *testing.T) {
+		embedding, err := NewBaseAction("test").GenEmbedding(ctx, EmbedderName, "用户住在北京")
+		require.NoError(t, err)
+		require.Len(t, embedding, 2560)
+
+		var norm float64
+		for _, v := range embedding {
+			norm += float64(v) * float64(v)
+		}
+		assert.InDelta(t, 1, math.Sqrt(norm), 1e-4)
+		assert.InDelta(t, 1/math.Sqrt(2560), embedding[0], 1e-6)
+	})
+
+	t.Run("shorter embeddings are kept", func(t *testing.T) {
+		assert.Equal(t, []float32{3, 4}, truncateEmbedding([]float32{3, 4}, 2560))
+		assert.Equal(t, []float32{0.6, 0.8}, truncateEmbedding([]float32{3, 4, 12}, 2))
+	})
+}
+
+func TestBaseAction_Fallback(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	cfg := DefaultConfig()
+	cfg.Fallback = FallbackConfig{LLM: "ark/doubao-lite", Embedder: "ark/doubao-embedding-event"}
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Init(DefaultConfig()) })
+
+	helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		return nil, errors.New("ark unavailable")
+	})
+	helper.MockPlugin.SetModelJSONResponse("doubao-lite", MemoryExtractResult{Memories: []ExtractedMemory{
+		{Content: "用户住在北京", Importance: 0.8, MemoryType: domain.MemoryTypeFact},
+	}})
+	helper.MockPlugin.SetEmbedderResponse("doubao-embedding-text-240715", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		return nil, errors.New("ark unavailable")
+	})
+	helper.MockPlugin.SetEmbedderVectorResponse("doubao-embedding-event", []float32{0.1, 0.2, 0.3})
+
+	t.Run("secondary handles failed generate and embed", func(t *testing.T) {
+		store := NewMockVectorStore()
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Messages = domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "我住在北京"}}
+
+		helper.NewSummaryMemoryAction().WithStore(store).Handle(c)
+
+		require.NoError(t, c.Error())
+		require.Len(t, c.Summaries, 1)
+		assert.Equal(t, "用户住在北京", c.Summaries[0].Content)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, c.Summaries[0].Embedding)
+	})
+
+	t.Run("secondary handles calls while circuit is open", func(t *testing.T) {
+		for i := 0; i < DefaultBreakerFailureThreshold; i++ {
+			LLMBreaker().Record(errors.New("ark unavailable"))
+		}
+		require.Equal(t, BreakerOpen, LLMBreaker().State())
+
+		embedding, err := NewBaseAction("test").GenEmbedding(ctx, EmbedderName, "用户住在北京")
+		require.NoError(t, err)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, embedding)
+	})
+
+	t.Run("both failing reports llm unavailable", func(t *testing.T) {
+		helper.MockPlugin.SetEmbedderResponse("doubao-embedding-event", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+			return nil, errors.New("fallback unavailable")
+		})
+
+		_, err := NewBaseAction("test").GenEmbedding(ctx, EmbedderName, "用户住在北京")
+		assert.ErrorIs(t, err, domain.ErrLLMUnavailable)
+		assert.ErrorContains(t, err, "fallback unavailable")
+	})
+}
+
+func TestNormalizedSimilarity(t *testing.T) {
+	base := NewBaseAction("test")
+	vecs := [][]float32{
+		{0.1, 0.2, 0.3},
+		{-0.4, 0.5, 0.05},
+		{3, 0, -2},
+		{0.7, 0.7, 0.7},
+	}
+
+	for _, a := range vecs {
+		na := NewNormedVector(a)
+		for _, b := range vecs {
+			want := base.CosineSimilarity(a, b)
+			assert.InDelta(t, want, DotProduct(NormalizeVector(a), NormalizeVector(b)), 1e-6)
+			assert.InDelta(t, want, na.Similarity(NewNormedVector(b)), 1e-9)
+		}
+	}
+
+	assert.Nil(t, NormalizeVector([]float32{0, 0, 0}))
+	assert.Zero(t, NewNormedVector([]float32{0, 0}).Similarity(NewNormedVector([]float32{1, 1})))
+	assert.Zero(t, NewNormedVector([]float32{1}).Similarity(NewNormedVector([]float32{1, 1})))
+}
+
+func BenchmarkSimilarity(b *testing.B) {
+	const dim, candidates = 1024, 50
+
+	newVec := func(seed int) []float32 {
+		vec := make([]float32, dim)
+		for i := range vec {
+			vec[i] = float32((i*31+seed*17)%97) / 97
+		}
+		return vec
+	}
+	query := newVec(0)
+	docs := make([][]float32, candidates)
+	for i := range docs {
+		docs[i] = newVec(i + 1)
+	}
+
+	b.Run("cosine", func(b *testing.B) {
+		base := NewBaseAction("bench")
+		for i := 0; i < b.N; i++ {
+			for _, doc := range docs {
+				base.CosineSimilarity(query, doc)
+			}
+		}
+	})
+
+	b.Run("cached norm", func(b *testing.B) {
+		q := NewNormedVector(query)
+		normed := make([]NormedVector, len(docs))
+		for i, doc := range docs {
+			normed[i] = NewNormedVector(doc)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, doc := range normed {
+				q.Similarity(doc)
+			}
+		}
+	})
+
+	b.Run("normalized dot", func(b *testing.B) {
+		q := NormalizeVector(query)
+		normed := make([][]float32, len(docs))
+		for i, doc := range docs {
+			normed[i] = NormalizeVector(doc)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, doc := range normed {
+				DotProduct(q, doc)
+			}
+		}
+	})
+}
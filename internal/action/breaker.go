@@ -0,0 +1,151 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开时直接返回，不再调用 LLM
+var ErrCircuitOpen = errors.New("llm circuit breaker open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // 正常放行
+	BreakerOpen                         // 熔断中，直接失败
+	BreakerHalfOpen                     // 冷却结束，放行一次试探调用
+)
+
+// CircuitBreaker LLM 调用熔断器
+// 连续失败达到阈值后打开，冷却期内直接失败；冷却结束后半开，试探成功则关闭，失败则重新打开
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool // 半开状态下是否已有试探调用在进行
+	now       func() time.Time
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// newCircuitBreakerFromConfig 按配置创建熔断器，未启用时返回 nil
+func newCircuitBreakerFromConfig(cfg BreakerConfig) *CircuitBreaker {
+	if !cfg.Enabled {
+		return nil
+	}
+	return NewCircuitBreaker(cfg.FailureThreshold, cfg.CooldownDuration())
+}
+
+// Allow 判断是否放行调用，熔断中返回 ErrCircuitOpen
+// nil 熔断器总是放行
+func (b *CircuitBreaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Record 记录调用结果，err 为 nil 表示成功
+// 调用方取消的请求不计入失败
+func (b *CircuitBreaker) Record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.state = BreakerClosed
+		b.failures = 0
+		b.probing = false
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		b.probing = false // 试探调用被取消，允许下一次试探
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.probing = false
+	}
+}
+
+// State 返回当前状态（打开且冷却结束时报告为半开）
+func (b *CircuitBreaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Reset 恢复为关闭状态
+func (b *CircuitBreaker) Reset() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// llmBreaker 所有 action 共享的 LLM 熔断器，随 Init 重建
+var llmBreaker atomic.Pointer[CircuitBreaker]
+
+func init() {
+	llmBreaker.Store(newCircuitBreakerFromConfig(DefaultConfig().Breaker))
+}
+
+// LLMBreaker 返回共享的 LLM 熔断器，未启用时返回 nil
+func LLMBreaker() *CircuitBreaker {
+	return llmBreaker.Load()
+}
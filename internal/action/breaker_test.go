@@ -0,0 +1,92 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(3, 30*time.Second)
+	b.now = func() time.Time { return now }
+
+	upstream := errors.New("upstream timeout")
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.NoError(t, b.Allow())
+			b.Record(upstream)
+		}
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	})
+
+	t.Run("half-opens after cooldown and allows one probe", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		assert.Equal(t, BreakerHalfOpen, b.State())
+
+		require.NoError(t, b.Allow())
+		assert.ErrorIs(t, b.Allow(), ErrCircuitOpen, "only one probe in flight")
+	})
+
+	t.Run("failed probe reopens", func(t *testing.T) {
+		b.Record(upstream)
+		assert.Equal(t, BreakerOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+	})
+
+	t.Run("successful probe closes", func(t *testing.T) {
+		now = now.Add(31 * time.Second)
+		require.NoError(t, b.Allow())
+		b.Record(nil)
+
+		assert.Equal(t, BreakerClosed, b.State())
+		assert.NoError(t, b.Allow())
+	})
+
+	t.Run("success resets failure count", func(t *testing.T) {
+		b.Record(upstream)
+		b.Record(upstream)
+		b.Record(nil)
+		b.Record(upstream)
+		assert.Equal(t, BreakerClosed, b.State())
+	})
+
+	t.Run("nil breaker always allows", func(t *testing.T) {
+		var nilBreaker *CircuitBreaker
+		assert.NoError(t, nilBreaker.Allow())
+		nilBreaker.Record(upstream)
+		assert.Equal(t, BreakerClosed, nilBreaker.State())
+	})
+}
+
+func TestBaseAction_BreakerShortCircuits(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	calls := 0
+	helper.MockPlugin.SetEmbedderResponse("doubao-embedding-text-240715", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		calls++
+		return nil, errors.New("upstream timeout")
+	})
+
+	base := NewBaseAction("test")
+	for i := 0; i < DefaultBreakerFailureThreshold+3; i++ {
+		_, err := base.GenEmbedding(ctx, EmbedderName, "用户喜欢咖啡")
+		assert.ErrorIs(t, err, domain.ErrLLMUnavailable)
+	}
+
+	assert.Equal(t, DefaultBreakerFailureThreshold, calls)
+	assert.Equal(t, BreakerOpen, LLMBreaker().State())
+
+	_, err := base.GenEmbedding(ctx, EmbedderName, "用户喜欢咖啡")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
@@ -0,0 +1,208 @@
+package action
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+// DefaultCacheMaxEntries 检索缓存默认最多保存的结果数
+const DefaultCacheMaxEntries = 10000
+
+// RetrievalCache 检索结果缓存（内存，按 agent/user 分区）
+// Agent 循环中常重复相同查询，TTL 内直接返回缓存结果，写入同一用户的记忆时失效
+// 条目数达到上限时先清理过期条目，仍然已满则淘汰最早过期的条目
+type RetrievalCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	size       int                              // 全部分区的条目总数
+	entries    map[string]map[string]cacheEntry // scope(agentID:userID) -> key -> entry
+	versions   map[string]uint64                // scope -> 版本号，每次失效递增
+	epoch      uint64                           // 全局版本，InvalidateAll 时递增
+	now        func() time.Time
+}
+
+type cacheEntry struct {
+	resp      *domain.RetrieveResponse
+	expiresAt time.Time
+}
+
+// NewRetrievalCache 创建检索缓存
+func NewRetrievalCache(ttl time.Duration) *RetrievalCache {
+	return &RetrievalCache{
+		ttl:        ttl,
+		maxEntries: DefaultCacheMaxEntries,
+		entries:    make(map[string]map[string]cacheEntry),
+		versions:   make(map[string]uint64),
+		now:        time.Now,
+	}
+}
+
+// WithMaxEntries 设置最多保存的结果数
+func (rc *RetrievalCache) WithMaxEntries(n int) *RetrievalCache {
+	if n > 0 {
+		rc.maxEntries = n
+	}
+	return rc
+}
+
+// cacheScope 缓存分区 key
+func cacheScope(agentID, userID string) string {
+	return agentID + ":" + userID
+}
+
+// cacheKey 由会话、查询和检索选项生成缓存 key
+func cacheKey(req *domain.RetrieveRequest) string {
+	data, _ := json.Marshal(struct {
+		SessionID string                 `json:"session_id"`
+		Query     string                 `json:"query"`
+		Limit     int                    `json:"limit"`
+		Options   domain.RetrieveOptions `json:"options"`
+	}{req.SessionID, req.Query, req.Limit, req.Options})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Get 获取未过期的缓存结果
+func (rc *RetrievalCache) Get(req *domain.RetrieveRequest) (*domain.RetrieveResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	scope := rc.entries[cacheScope(req.AgentID, req.UserID)]
+	key := cacheKey(req)
+	entry, ok := scope[key]
+	if !ok {
+		return nil, false
+	}
+	if rc.now().After(entry.expiresAt) {
+		rc.remove(cacheScope(req.AgentID, req.UserID), key)
+		return nil, false
+	}
+
+	return cloneRetrieveResponse(entry.resp), true
+}
+
+// Version 返回 agent/user 当前的缓存版本，检索开始前获取，传给 Set
+func (rc *RetrievalCache) Version(agentID, userID string) uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.versions[cacheScope(agentID, userID)] + rc.epoch
+}
+
+// Set 缓存检索结果
+// 检索期间发生过失效（版本已变化）时不缓存，避免写入前的旧结果覆盖失效
+func (rc *RetrievalCache) Set(req *domain.RetrieveRequest, resp *domain.RetrieveResponse, version uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	name := cacheScope(req.AgentID, req.UserID)
+	if rc.versions[name]+rc.epoch != version {
+		return
+	}
+	key := cacheKey(req)
+	if _, ok := rc.entries[name][key]; !ok {
+		if rc.size >= rc.maxEntries {
+			rc.evict()
+		}
+		rc.size++
+	}
+
+	scope, ok := rc.entries[name]
+	if !ok {
+		scope = make(map[string]cacheEntry)
+		rc.entries[name] = scope
+	}
+	scope[key] = cacheEntry{resp: cloneRetrieveResponse(resp), expiresAt: rc.now().Add(rc.ttl)}
+}
+
+// evict 清理全部过期条目，没有过期条目时淘汰最早过期的一条，调用方须持有锁
+func (rc *RetrievalCache) evict() {
+	now := rc.now()
+	var oldestScope, oldestKey string
+	var oldest time.Time
+
+	for name, scope := range rc.entries {
+		for key, entry := range scope {
+			if now.After(entry.expiresAt) {
+				rc.remove(name, key)
+				continue
+			}
+			if oldest.IsZero() || entry.expiresAt.Before(oldest) {
+				oldestScope, oldestKey, oldest = name, key, entry.expiresAt
+			}
+		}
+	}
+
+	if rc.size >= rc.maxEntries && !oldest.IsZero() {
+		rc.remove(oldestScope, oldestKey)
+	}
+}
+
+// remove 删除一个条目，分区为空时一并删除，调用方须持有锁
+func (rc *RetrievalCache) remove(name, key string) {
+	scope := rc.entries[name]
+	if _, ok := scope[key]; !ok {
+		return
+	}
+	delete(scope, key)
+	rc.size--
+	if len(scope) == 0 {
+		delete(rc.entries, name)
+	}
+}
+
+// cloneRetrieveResponse 复制检索结果及其结果切片，调用方增删或替换元素不会影响缓存
+// 元素内部的切片字段（如 Keywords）仍然共享，不应修改
+func cloneRetrieveResponse(resp *domain.RetrieveResponse) *domain.RetrieveResponse {
+	copied := *resp
+	copied.Facts = slices.Clone(resp.Facts)
+	copied.WorkingMem = slices.Clone(resp.WorkingMem)
+	copied.Events = slices.Clone(resp.Events)
+	copied.ShortTerm = slices.Clone(resp.ShortTerm)
+	copied.EventRelations = slices.Clone(resp.EventRelations)
+	copied.Debug = slices.Clone(resp.Debug)
+	copied.Warnings = slices.Clone(resp.Warnings)
+	if resp.Meta != nil {
+		meta := *resp.Meta
+		copied.Meta = &meta
+	}
+	if resp.Sessions != nil {
+		copied.Sessions = make([]domain.SessionGroup, len(resp.Sessions))
+		for i, g := range resp.Sessions {
+			g.Facts = slices.Clone(g.Facts)
+			g.WorkingMem = slices.Clone(g.WorkingMem)
+			g.Events = slices.Clone(g.Events)
+			copied.Sessions[i] = g
+		}
+	}
+	return &copied
+}
+
+// Invalidate 清除指定 agent/user 的全部缓存并递增版本
+func (rc *RetrievalCache) Invalidate(agentID, userID string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	name := cacheScope(agentID, userID)
+	rc.size -= len(rc.entries[name])
+	delete(rc.entries, name)
+	rc.versions[name]++
+}
+
+// InvalidateAll 清除全部缓存（如索引迁移后所有用户的向量都已变化）
+func (rc *RetrievalCache) InvalidateAll() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries = make(map[string]map[string]cacheEntry)
+	rc.size = 0
+	rc.epoch++
+}
@@ -0,0 +1,248 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestRetrievalCache(t *testing.T) {
+	req := &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里"}
+	resp := &domain.RetrieveResponse{Success: true, Total: 1}
+
+	t.Run("hit and expiry", func(t *testing.T) {
+		now := time.Now()
+		cache := NewRetrievalCache(time.Minute)
+		cache.now = func() time.Time { return now }
+
+		cache.Set(req, resp, 0)
+		cached, ok := cache.Get(req)
+		require.True(t, ok)
+		assert.Equal(t, 1, cached.Total)
+
+		now = now.Add(2 * time.Minute)
+		_, ok = cache.Get(req)
+		assert.False(t, ok)
+	})
+
+	t.Run("key includes query and options", func(t *testing.T) {
+		cache := NewRetrievalCache(time.Minute)
+		cache.Set(req, resp, 0)
+
+		other := *req
+		other.Query = "用户喜欢什么"
+		_, ok := cache.Get(&other)
+		assert.False(t, ok)
+
+		other = *req
+		other.Options.Explain = true
+		_, ok = cache.Get(&other)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalidate by user", func(t *testing.T) {
+		cache := NewRetrievalCache(time.Minute)
+		cache.Set(req, resp, 0)
+
+		otherUser := *req
+		otherUser.UserID = "user_2"
+		cache.Set(&otherUser, resp, 0)
+
+		cache.Invalidate("agent_1", "user_1")
+		_, ok := cache.Get(req)
+		assert.False(t, ok)
+		_, ok = cache.Get(&otherUser)
+		assert.True(t, ok)
+	})
+
+	t.Run("stale version not cached", func(t *testing.T) {
+		cache := NewRetrievalCache(time.Minute)
+
+		// 检索开始后发生写入
+		version := cache.Version("agent_1", "user_1")
+		cache.Invalidate("agent_1", "user_1")
+		cache.Set(req, resp, version)
+
+		_, ok := cache.Get(req)
+		assert.False(t, ok)
+
+		cache.Set(req, resp, cache.Version("agent_1", "user_1"))
+		_, ok = cache.Get(req)
+		assert.True(t, ok)
+	})
+
+	t.Run("max entries evicts expired then oldest", func(t *testing.T) {
+		now := time.Now()
+		cache := NewRetrievalCache(time.Minute).WithMaxEntries(2)
+		cache.now = func() time.Time { return now }
+
+		query := func(q string) *domain.RetrieveRequest {
+			other := *req
+			other.Query = q
+			return &other
+		}
+
+		cache.Set(query("q1"), resp, 0)
+		now = now.Add(2 * time.Minute) // q1 过期
+		cache.Set(query("q2"), resp, 0)
+		now = now.Add(time.Second)
+		cache.Set(query("q3"), resp, 0)
+		assert.Equal(t, 2, cache.size, "expired q1 swept")
+
+		now = now.Add(time.Second)
+		cache.Set(query("q4"), resp, 0)
+		assert.Equal(t, 2, cache.size)
+		_, ok := cache.Get(query("q2"))
+		assert.False(t, ok, "oldest entry evicted")
+		for _, q := range []string{"q3", "q4"} {
+			_, ok := cache.Get(query(q))
+			assert.True(t, ok, q)
+		}
+
+		cache.Invalidate("agent_1", "user_1")
+		assert.Zero(t, cache.size)
+	})
+
+	t.Run("returned slices are copies", func(t *testing.T) {
+		cache := NewRetrievalCache(time.Minute)
+		cache.Set(req, &domain.RetrieveResponse{Facts: []domain.SummaryMemory{{ID: "fact_1"}}}, 0)
+
+		cached, ok := cache.Get(req)
+		require.True(t, ok)
+		cached.Facts[0].ID = "changed"
+
+		cached, ok = cache.Get(req)
+		require.True(t, ok)
+		assert.Equal(t, "fact_1", cached.Facts[0].ID)
+	})
+}
+
+func TestMemory_RetrieveCache(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(map[string]any{"memories": []any{}, "events": []any{}})
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["memory_type"] != domain.MemoryTypeFact {
+			return nil, nil
+		}
+		return []map[string]any{
+			{"id": "fact_1", "content": "用户住在北京", "memory_type": domain.MemoryTypeFact, "_score": 0.9},
+		}, nil
+	}
+
+	memory := NewMemory().WithStores(store, NewMockRelationStore()).WithCache(NewRetrievalCache(time.Minute))
+	req := &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_cache", Query: "用户住在哪里"}
+
+	first, err := memory.Retrieve(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, first.Facts, 1)
+	searches := len(store.SearchCalls)
+	require.NotZero(t, searches)
+
+	// 重复查询命中缓存，不再访问存储
+	second, err := memory.Retrieve(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, first.Facts, second.Facts)
+	assert.Equal(t, searches, len(store.SearchCalls))
+
+	// 同一用户写入后缓存失效
+	_, err = memory.Add(ctx, &domain.AddRequest{
+		AgentID:  "agent_1",
+		UserID:   "user_cache",
+		Messages: []domain.Message{{Role: domain.RoleUser, Content: "我搬到上海了"}},
+	})
+	require.NoError(t, err)
+	searches = len(store.SearchCalls)
+
+	_, err = memory.Retrieve(ctx, req)
+	require.NoError(t, err)
+	assert.Greater(t, len(store.SearchCalls), searches)
+}
+
+func TestMemory_CacheInvalidationOnWrite(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(MemoryExtractResult{Memories: []ExtractedMemory{
+		{Content: "用户住在上海", Importance: 0.5, MemoryType: domain.MemoryTypeFact},
+	}})
+
+	// 有状态的 mock：Search 返回已写入的 fact
+	var facts []map[string]any
+	store := NewMockVectorStore()
+	store.StoreFunc = func(ctx context.Context, id string, doc map[string]any) error {
+		if doc["memory_type"] == domain.MemoryTypeFact {
+			hit := map[string]any{"_score": 0.9}
+			for k, v := range doc {
+				hit[k] = v
+			}
+			facts = append(facts, hit)
+		}
+		return nil
+	}
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["memory_type"] != domain.MemoryTypeFact {
+			return nil, nil
+		}
+		return facts, nil
+	}
+
+	memory := NewMemory().WithStores(store, NewMockRelationStore()).WithCache(NewRetrievalCache(time.Minute))
+	req := &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_invalidate", Query: "用户住在哪里"}
+
+	before, err := memory.Retrieve(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, before.Facts)
+
+	_, err = memory.Add(ctx, &domain.AddRequest{
+		AgentID:  "agent_1",
+		UserID:   "user_invalidate",
+		Messages: []domain.Message{{Role: domain.RoleUser, Content: "我住在上海"}},
+	})
+	require.NoError(t, err)
+
+	after, err := memory.Retrieve(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, after.Facts, 1)
+	assert.Equal(t, "用户住在上海", after.Facts[0].Content)
+
+	// 遗忘同样使缓存失效
+	cached, ok := memory.cache.Get(req)
+	require.True(t, ok)
+	assert.Len(t, cached.Facts, 1)
+
+	_, err = memory.Forget(ctx, &domain.ForgetRequest{AgentID: "agent_1", UserID: "user_invalidate"})
+	require.NoError(t, err)
+	_, ok = memory.cache.Get(req)
+	assert.False(t, ok)
+}
+
+func TestConsistency_OnExpire(t *testing.T) {
+	ctx := context.Background()
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		return []map[string]any{{"id": "fact_old", "content": "用户住在北京", "memory_type": domain.MemoryTypeFact}}, nil
+	}
+
+	var expired []string
+	action := NewConsistencyAction().WithStore(store).OnExpire(func(agentID, userID string) {
+		expired = append(expired, agentID+":"+userID)
+	})
+
+	action.detectConflicts(ctx, "agent_1", "user_1", []domain.SummaryMemory{
+		{ID: "fact_new", Content: "用户住在上海", MemoryType: domain.MemoryTypeFact, Embedding: []float32{0.1}},
+	})
+
+	require.Len(t, store.UpdateFieldsCalls, 1)
+	assert.Equal(t, "fact_old", store.UpdateFieldsCalls[0].ID)
+	assert.Equal(t, []string{"agent_1:user_1"}, expired)
+}
@@ -0,0 +1,254 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// CompactResult memory_compact prompt 输出
+type CompactResult struct {
+	Content    string   `json:"content"`
+	Importance float64  `json:"importance"`
+	Keywords   []string `json:"keywords"`
+}
+
+// CompactionAction 摘要记忆分层压缩
+// 长期用户的摘要记忆不断累积，超过阈值后按向量相似度聚成主题簇，
+// 为每个足够大的簇生成一条上层摘要并通过 child_ids 关联原记忆，宽泛的查询可直接命中上层摘要
+type CompactionAction struct {
+	*BaseAction
+
+	vectorStore vector.Store
+	embedder    string
+	maxLength   int
+	cfg         CompactionConfig
+}
+
+// NewCompactionAction 创建 CompactionAction
+func NewCompactionAction() *CompactionAction {
+	return &CompactionAction{
+		BaseAction:  NewBaseAction("compaction"),
+		vectorStore: vector.NewStore(),
+		embedder:    GetConfig().Summary.Embedder,
+		maxLength:   GetConfig().Summary.MaxLength,
+		cfg:         GetConfig().Compaction,
+	}
+}
+
+// WithStore 设置存储（用于测试注入 mock）
+func (a *CompactionAction) WithStore(store vector.Store) *CompactionAction {
+	a.vectorStore = store
+	return a
+}
+
+// WithConfig 设置分层压缩配置
+func (a *CompactionAction) WithConfig(cfg CompactionConfig) *CompactionAction {
+	a.cfg = cfg
+	return a
+}
+
+// Execute 压缩 agent+user 下尚未被压缩的摘要记忆
+// 只压缩一层：上层摘要与已有上层摘要的记忆不再参与；fact 与 working 分别聚类
+func (a *CompactionAction) Execute(ctx context.Context, agentID, userID string) (*domain.CompactResponse, error) {
+	a.logger.Info("executing compaction", "agent_id", agentID, "user_id", userID)
+
+	resp := &domain.CompactResponse{Success: true, MetaSummaries: []string{}}
+	if a.vectorStore == nil {
+		return resp, nil
+	}
+
+	summaries, err := a.loadSummaries(ctx, agentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp.Summaries = len(summaries)
+	if len(summaries) <= a.cfg.Threshold {
+		a.logger.Debug("compaction threshold not reached", "summaries", len(summaries), "threshold", a.cfg.Threshold)
+		return resp, nil
+	}
+
+	for _, cluster := range a.cluster(summaries) {
+		if len(cluster) < a.cfg.MinClusterSize {
+			continue
+		}
+
+		id, err := a.compactCluster(ctx, agentID, userID, cluster)
+		if err != nil {
+			return nil, err
+		}
+		resp.MetaSummaries = append(resp.MetaSummaries, id)
+		resp.Compacted += len(cluster)
+	}
+
+	a.logger.Info("compaction completed",
+		"summaries", resp.Summaries,
+		"meta_summaries", len(resp.MetaSummaries),
+		"compacted", resp.Compacted,
+	)
+
+	return resp, nil
+}
+
+// loadSummaries 按 id 分页（search_after）读取尚未被压缩的活跃摘要记忆
+// 归档与一致性检查过期的旧 fact 不参与压缩，避免被取代的记忆经上层摘要重新召回
+func (a *CompactionAction) loadSummaries(ctx context.Context, agentID, userID string) ([]*domain.SummaryMemory, error) {
+	scanner, ok := a.vectorStore.(vector.Scanner)
+	if !ok {
+		return nil, fmt.Errorf("%w: vector store does not support compaction", domain.ErrInvalidInput)
+	}
+
+	filters := map[string]any{
+		"type":     domain.DocTypeSummary,
+		"agent_id": agentID,
+		"user_id":  userID,
+	}
+
+	var summaries []*domain.SummaryMemory
+	after := ""
+	for {
+		docs, err := scanner.Scan(ctx, filters, after, ReindexBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: load summaries: %w", domain.ErrStoreUnavailable, err)
+		}
+		if len(docs) == 0 {
+			return summaries, nil
+		}
+
+		for _, doc := range docs {
+			if status, _ := doc["status"].(string); status != "" && status != vector.StatusActive {
+				continue
+			}
+			if doc["expired_at"] != nil {
+				continue
+			}
+			s := a.DocToSummaryMemory(doc)
+			if s.Level > 0 || s.ParentID != "" || len(s.Embedding) == 0 {
+				continue
+			}
+			summaries = append(summaries, s)
+		}
+		after, _ = docs[len(docs)-1]["id"].(string)
+	}
+}
+
+// cluster 贪心聚类：每条记忆归入第一个与其种子相似度达到阈值的同类型簇，否则自成新簇
+func (a *CompactionAction) cluster(summaries []*domain.SummaryMemory) [][]*domain.SummaryMemory {
+	type group struct {
+		memoryType string
+		seed       NormedVector
+		members    []*domain.SummaryMemory
+	}
+
+	var groups []*group
+	for _, s := range summaries {
+		vec := NewNormedVector(s.Embedding)
+
+		var target *group
+		for _, g := range groups {
+			if g.memoryType == s.MemoryType && g.seed.Similarity(vec) >= a.cfg.Similarity {
+				target = g
+				break
+			}
+		}
+		if target == nil {
+			target = &group{memoryType: s.MemoryType, seed: vec}
+			groups = append(groups, target)
+		}
+		target.members = append(target.members, s)
+	}
+
+	clusters := make([][]*domain.SummaryMemory, 0, len(groups))
+	for _, g := range groups {
+		clusters = append(clusters, g.members)
+	}
+	return clusters
+}
+
+// compactCluster 为一个簇生成上层摘要并写入，再把簇内记忆指向它
+func (a *CompactionAction) compactCluster(ctx context.Context, agentID, userID string, cluster []*domain.SummaryMemory) (string, error) {
+	// 按重要性排序，LLM 优先看到重要的记忆
+	sort.SliceStable(cluster, func(i, j int) bool {
+		return cluster[i].Importance > cluster[j].Importance
+	})
+
+	lines := make([]string, 0, len(cluster))
+	childIDs := make([]string, 0, len(cluster))
+	for _, s := range cluster {
+		lines = append(lines, "- "+s.Content)
+		childIDs = append(childIDs, s.ID)
+	}
+
+	var result CompactResult
+	if err := a.GenerateWithContext(ctx, "memory_compact", map[string]any{
+		"memories":   strings.Join(lines, "\n"),
+		"max_length": a.maxLength,
+	}, &result); err != nil {
+		return "", err
+	}
+	if result.Content == "" {
+		return "", fmt.Errorf("compaction returned empty content")
+	}
+	if top := cluster[0].Importance; result.Importance < top {
+		result.Importance = top
+	}
+
+	embedding, err := a.GenEmbedding(ctx, embedderOrDefault(a.embedder), result.Content)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	id := fmt.Sprintf("mem_%s", uuid.New().String()[:8])
+	doc := map[string]any{
+		"id":               id,
+		"type":             domain.DocTypeSummary,
+		"agent_id":         agentID,
+		"user_id":          userID,
+		"content":          result.Content,
+		"memory_type":      cluster[0].MemoryType,
+		"importance":       result.Importance,
+		"keywords":         result.Keywords,
+		"embedding":        embedding,
+		"is_protected":     result.Importance >= 0.9,
+		"level":            1,
+		"child_ids":        childIDs,
+		"access_count":     0,
+		"last_accessed_at": now,
+		"occurred_at":      latestOccurredAt(cluster),
+		"created_at":       now,
+		"updated_at":       now,
+	}
+	if err := a.vectorStore.Store(ctx, id, doc); err != nil {
+		return "", fmt.Errorf("%w: store meta summary: %w", domain.ErrStoreUnavailable, err)
+	}
+
+	for _, s := range cluster {
+		if err := a.vectorStore.UpdateFields(ctx, s.ID, map[string]any{
+			"parent_id":  id,
+			"updated_at": now,
+		}); err != nil {
+			return "", fmt.Errorf("%w: link summary %s: %w", domain.ErrStoreUnavailable, s.ID, err)
+		}
+	}
+
+	return id, nil
+}
+
+// latestOccurredAt 返回簇内最晚的对话发生时间
+func latestOccurredAt(cluster []*domain.SummaryMemory) time.Time {
+	var latest time.Time
+	for _, s := range cluster {
+		if s.OccurredAt.After(latest) {
+			latest = s.OccurredAt
+		}
+	}
+	return latest
+}
@@ -0,0 +1,120 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestCompactionAction_Execute(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.9, 0.1, 0})
+	helper.SetModelJSON(CompactResult{Content: "用户热爱咖啡，常去星巴克", Importance: 0.6, Keywords: []string{"咖啡"}})
+
+	// 4 条咖啡相关记忆彼此相近，另 1 条与之正交
+	newStore := func() *memoryVectorStore {
+		store := newMemoryVectorStore(3)
+		embeddings := [][]float32{{1, 0, 0}, {0.95, 0.05, 0}, {0.9, 0.1, 0}, {0.97, 0, 0.03}, {0, 0, 1}}
+		for i, embedding := range embeddings {
+			id := fmt.Sprintf("mem_%d", i+1)
+			store.docs[id] = map[string]any{
+				"id":          id,
+				"type":        domain.DocTypeSummary,
+				"agent_id":    "agent_1",
+				"user_id":     "user_1",
+				"memory_type": domain.MemoryTypeFact,
+				"content":     fmt.Sprintf("记忆 %d", i+1),
+				"importance":  0.5,
+				"embedding":   embedding,
+			}
+		}
+		return store
+	}
+	cfg := CompactionConfig{Threshold: 3, Similarity: 0.8, MinClusterSize: 3}
+
+	t.Run("clustered summaries produce a meta summary", func(t *testing.T) {
+		store := newStore()
+
+		resp, err := NewCompactionAction().WithStore(store).WithConfig(cfg).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, 5, resp.Summaries)
+		assert.Equal(t, 4, resp.Compacted)
+		require.Len(t, resp.MetaSummaries, 1)
+
+		meta := store.docs[resp.MetaSummaries[0]]
+		require.NotNil(t, meta)
+		assert.Equal(t, 1, meta["level"])
+		assert.Equal(t, "用户热爱咖啡，常去星巴克", meta["content"])
+		assert.Equal(t, domain.MemoryTypeFact, meta["memory_type"])
+		assert.ElementsMatch(t, []string{"mem_1", "mem_2", "mem_3", "mem_4"}, meta["child_ids"])
+
+		linked := map[string]any{}
+		for _, call := range store.UpdateFieldsCalls {
+			linked[call.ID] = call.Fields["parent_id"]
+		}
+		assert.Len(t, linked, 4)
+		for _, id := range []string{"mem_1", "mem_2", "mem_3", "mem_4"} {
+			assert.Equal(t, resp.MetaSummaries[0], linked[id])
+		}
+		assert.NotContains(t, linked, "mem_5")
+	})
+
+	t.Run("below threshold does nothing", func(t *testing.T) {
+		store := newStore()
+
+		resp, err := NewCompactionAction().WithStore(store).WithConfig(CompactionConfig{Threshold: 10, Similarity: 0.8, MinClusterSize: 3}).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+
+		assert.True(t, resp.Success)
+		assert.Empty(t, resp.MetaSummaries)
+		assert.Empty(t, store.StoreCalls)
+		assert.Empty(t, store.UpdateFieldsCalls)
+	})
+
+	t.Run("compacted summaries are skipped", func(t *testing.T) {
+		store := newStore()
+		for _, id := range []string{"mem_1", "mem_2"} {
+			store.docs[id]["parent_id"] = "mem_meta"
+		}
+
+		resp, err := NewCompactionAction().WithStore(store).WithConfig(cfg).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, resp.Summaries)
+		assert.Empty(t, resp.MetaSummaries)
+	})
+	t.Run("expired and archived summaries are skipped", func(t *testing.T) {
+		store := newStore()
+		store.docs["mem_1"]["expired_at"] = "2026-03-01T00:00:00Z"
+		store.docs["mem_2"]["status"] = vector.StatusArchived
+
+		resp, err := NewCompactionAction().WithStore(store).WithConfig(cfg).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, resp.Summaries)
+		assert.Empty(t, resp.MetaSummaries, "only two live coffee memories remain")
+	})
+
+	t.Run("pages past a single batch", func(t *testing.T) {
+		store := newMemoryVectorStore(3)
+		for i := range ReindexBatchSize + 5 {
+			id := fmt.Sprintf("mem_%03d", i)
+			store.docs[id] = map[string]any{
+				"id": id, "type": domain.DocTypeSummary, "agent_id": "agent_1", "user_id": "user_1",
+				"memory_type": domain.MemoryTypeFact, "content": id, "embedding": []float32{0, 0, 1},
+			}
+		}
+
+		resp, err := NewCompactionAction().WithStore(store).WithConfig(CompactionConfig{Threshold: 1000, Similarity: 0.8, MinClusterSize: 3}).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+		assert.Equal(t, ReindexBatchSize+5, resp.Summaries)
+	})
+}
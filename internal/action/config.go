@@ -0,0 +1,470 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+// 双写模式（OpenSearch 事件文档 + PostgreSQL 事件关系）
+const (
+	// DualWriteBestEffort 向量写入失败仅记录日志，事件仍保留并参与关系写入
+	DualWriteBestEffort = "best_effort"
+
+	// DualWriteStrict 向量写入失败则丢弃该事件，并跳过引用它的关系，保证两边一致
+	DualWriteStrict = "strict"
+)
+
+const (
+	// DefaultSummaryMaxLength 单条记忆默认最大字数
+	DefaultSummaryMaxLength = 200
+
+	// MinSummaryLength 按压缩比计算时的最小字数，避免短对话被压缩得无法成句
+	MinSummaryLength = 20
+
+	// DefaultMaxPerExtraction 单次提取默认最多保留的记忆/事件数
+	DefaultMaxPerExtraction = 20
+
+	// DefaultCacheTTL 检索缓存默认 TTL
+	DefaultCacheTTL = "30s"
+
+	// DefaultBreakerFailureThreshold 熔断器默认连续失败阈值
+	DefaultBreakerFailureThreshold = 5
+
+	// DefaultBreakerCooldown 熔断器默认冷却时间
+	DefaultBreakerCooldown = "30s"
+
+	// 一致性检查默认值
+	DefaultConsistencyScoreThreshold = 0.8 // 视为候选冲突的最低相似度
+	DefaultConsistencyLimit          = 5   // 每条新 fact 最多检查的候选数
+	DefaultConsistencyMinImportance  = 0.7 // 参与检查的新 fact 最低重要性
+
+	// 摘要记忆分层压缩默认值
+	DefaultCompactionThreshold      = 50  // 活跃摘要记忆数超过该值才压缩
+	DefaultCompactionSimilarity     = 0.8 // 归入同一主题簇的最低余弦相似度
+	DefaultCompactionMinClusterSize = 3   // 簇内至少多少条才生成上层摘要
+)
+
+// DefaultExclusiveTriggers 默认的互斥触发词：同一主语同一时间只会住在一处、就职于一处
+var DefaultExclusiveTriggers = []string{"住在", "居住在", "就职于", "工作于"}
+
+// Config 记忆处理配置
+type Config struct {
+	// IncludeSystemMessages 为 true 时 system 消息参与短期窗口与记忆/事件提取，默认跳过
+	IncludeSystemMessages bool `toml:"include_system_messages"`
+
+	// EmbedAttachmentCaptions 为 true 时将带描述的附件单独存为 working 记忆并向量化
+	EmbedAttachmentCaptions bool `toml:"embed_attachment_captions"`
+
+	// ContextualCaptionEmbedding 为 true 时附件描述连同发送者、所在消息与上一条消息一起向量化，记忆内容仍为描述本身
+	ContextualCaptionEmbedding bool `toml:"contextual_caption_embedding"`
+
+	// MinMessages / MinContentLength 消息数或内容总字数不足时跳过记忆与事件提取，短期窗口照常写入；0 表示不限制
+	MinMessages      int `toml:"min_messages"`
+	MinContentLength int `toml:"min_content_length"`
+
+	// MaxConcurrency 进程内同时进行的 LLM/embedding 调用上限（含备用模型），0 表示不限制
+	MaxConcurrency int `toml:"max_concurrency"`
+
+	// TruncateEmbeddingDim 将 embedder 返回的向量截断到该维度并重新归一化，须等于 storage.embedding_dim；0 表示不截断
+	TruncateEmbeddingDim int `toml:"truncate_embedding_dim"`
+
+	// LogLevels 按 action 名称单独设置最低日志级别（debug / info / warn / error），未配置的 action 沿用全局级别
+	// 只能在全局级别之上进一步过滤，e.g. 全局 info 时设为 debug 不会输出 debug 日志
+	LogLevels map[string]string `toml:"log_levels"`
+
+	Summary SummaryConfig `toml:"summary"`
+	Event   EventConfig   `toml:"event"`
+	Cache   CacheConfig   `toml:"cache"`
+	Breaker BreakerConfig `toml:"breaker"`
+
+	Consistency ConsistencyConfig `toml:"consistency"`
+
+	Compaction CompactionConfig `toml:"compaction"`
+
+	// Fallback 主模型失败或熔断时使用的备用模型，需在 [genkit.fallback] 中注册
+	Fallback FallbackConfig `toml:"fallback"`
+}
+
+// FallbackConfig 备用模型配置，为空表示不切换
+type FallbackConfig struct {
+	LLM string `toml:"llm"` // 备用 LLM，e.g. "fallback/gpt-4o-mini"
+	// 备用 embedder 必须与主 embedder 处于同一向量空间（如同一模型的另一部署），否则检索结果不可比
+	Embedder string `toml:"embedder"`
+}
+
+// ConsistencyConfig 写入阶段 fact 冲突检查配置
+type ConsistencyConfig struct {
+	ScoreThreshold float64 `toml:"score_threshold"` // 已有 fact 与新 fact 相似度达到该值视为冲突 (0, 1]
+	Limit          int     `toml:"limit"`           // 每条新 fact 最多检查的已有 fact 数
+	MinImportance  float64 `toml:"min_importance"`  // 重要性低于该值的新 fact 不做检查 (0, 1]，-1 表示检查所有 fact
+
+	// ExclusiveTriggers 同一主语同一时间只能有一个宾语的触发词（如"住在"），新事件会结束宾语不同的旧事件
+	ExclusiveTriggers []string `toml:"exclusive_triggers"`
+}
+
+// CompactionConfig 摘要记忆分层压缩配置
+type CompactionConfig struct {
+	Threshold      int     `toml:"threshold"`        // 活跃摘要记忆数超过该值才压缩
+	Similarity     float64 `toml:"similarity"`       // 归入同一主题簇的最低余弦相似度 (0, 1]
+	MinClusterSize int     `toml:"min_cluster_size"` // 簇内至少多少条才生成上层摘要
+}
+
+// BreakerConfig LLM 熔断器配置
+type BreakerConfig struct {
+	Enabled          bool   `toml:"enabled"`
+	FailureThreshold int    `toml:"failure_threshold"` // 连续失败多少次后打开
+	Cooldown         string `toml:"cooldown"`          // 打开后多久进入半开，e.g. "30s"
+}
+
+// CacheConfig 检索结果缓存配置
+type CacheConfig struct {
+	Enabled    bool   `toml:"enabled"`
+	TTL        string `toml:"ttl"`         // e.g. "30s"
+	MaxEntries int    `toml:"max_entries"` // 最多缓存的结果数，默认 DefaultCacheMaxEntries
+}
+
+// SummaryConfig 摘要记忆提取配置
+type SummaryConfig struct {
+	MaxLength        int     `toml:"max_length"`        // 单条记忆最大字数
+	CompressionRatio float64 `toml:"compression_ratio"` // 相对对话长度的压缩比 (0, 1]，0 表示不按比例限制
+	MaxMemories      int     `toml:"max_memories"`      // 单次提取最多保留的记忆数（按 importance）
+	Embedder         string  `toml:"embedder"`          // 摘要记忆使用的 embedder，e.g. "ark/doubao-embedding-text-240715"
+	Workers          int     `toml:"workers"`           // 并行向量化并存储的记忆数，0 或 1 表示逐条处理
+}
+
+// EventConfig 事件提取配置
+type EventConfig struct {
+	DualWriteMode string `toml:"dual_write_mode"` // best_effort / strict
+	MaxEvents     int    `toml:"max_events"`      // 单次提取最多保留的事件数（按 confidence）
+	Embedder      string `toml:"embedder"`        // 事件三元组使用的 embedder
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		Summary: SummaryConfig{
+			MaxLength:   DefaultSummaryMaxLength,
+			MaxMemories: DefaultMaxPerExtraction,
+			Embedder:    EmbedderName,
+		},
+		Event: EventConfig{
+			DualWriteMode: DualWriteBestEffort,
+			MaxEvents:     DefaultMaxPerExtraction,
+			Embedder:      EmbedderName,
+		},
+		Cache: CacheConfig{
+			TTL: DefaultCacheTTL,
+		},
+		Breaker: BreakerConfig{
+			Enabled:          true,
+			FailureThreshold: DefaultBreakerFailureThreshold,
+			Cooldown:         DefaultBreakerCooldown,
+		},
+		Consistency: ConsistencyConfig{
+			ScoreThreshold: DefaultConsistencyScoreThreshold,
+			Limit:          DefaultConsistencyLimit,
+			MinImportance:  DefaultConsistencyMinImportance,
+
+			ExclusiveTriggers: DefaultExclusiveTriggers,
+		},
+		Compaction: CompactionConfig{
+			Threshold:      DefaultCompactionThreshold,
+			Similarity:     DefaultCompactionSimilarity,
+			MinClusterSize: DefaultCompactionMinClusterSize,
+		},
+	}
+}
+
+// Validate 校验配置，并为未设置的字段填充默认值
+func (c *Config) Validate() error {
+	if c.MinMessages < 0 {
+		return fmt.Errorf("min_messages must not be negative")
+	}
+	if c.MinContentLength < 0 {
+		return fmt.Errorf("min_content_length must not be negative")
+	}
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("max_concurrency must not be negative")
+	}
+	if c.TruncateEmbeddingDim < 0 {
+		return fmt.Errorf("truncate_embedding_dim must not be negative")
+	}
+	for name, level := range c.LogLevels {
+		if _, err := parseLogLevel(level); err != nil {
+			return fmt.Errorf("log_levels.%s: %w", name, err)
+		}
+	}
+	if err := c.Summary.Validate(); err != nil {
+		return fmt.Errorf("summary: %w", err)
+	}
+	if err := c.Event.Validate(); err != nil {
+		return fmt.Errorf("event: %w", err)
+	}
+	if err := c.Cache.Validate(); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	if err := c.Breaker.Validate(); err != nil {
+		return fmt.Errorf("breaker: %w", err)
+	}
+	if err := c.Consistency.Validate(); err != nil {
+		return fmt.Errorf("consistency: %w", err)
+	}
+	if err := c.Compaction.Validate(); err != nil {
+		return fmt.Errorf("compaction: %w", err)
+	}
+	return nil
+}
+
+// BelowExtractionMinimum 判断对话是否过短、不值得调用 LLM 提取
+func (c *Config) BelowExtractionMinimum(messages domain.Messages) bool {
+	if c.MinMessages > 0 && len(messages) < c.MinMessages {
+		return true
+	}
+	if c.MinContentLength > 0 {
+		length := 0
+		for _, msg := range messages {
+			length += utf8.RuneCountInString(strings.TrimSpace(msg.Content))
+		}
+		if length < c.MinContentLength {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 校验摘要记忆提取配置
+func (c *SummaryConfig) Validate() error {
+	if c.MaxLength == 0 {
+		c.MaxLength = DefaultSummaryMaxLength
+	}
+	if c.MaxLength < 0 {
+		return fmt.Errorf("max_length must be positive")
+	}
+	if c.CompressionRatio < 0 || c.CompressionRatio > 1 {
+		return fmt.Errorf("compression_ratio must be between 0 and 1")
+	}
+	if c.MaxMemories == 0 {
+		c.MaxMemories = DefaultMaxPerExtraction
+	}
+	if c.MaxMemories < 0 {
+		return fmt.Errorf("max_memories must be positive")
+	}
+	if c.Embedder == "" {
+		c.Embedder = EmbedderName
+	}
+	if c.Workers < 0 {
+		return fmt.Errorf("workers must not be negative")
+	}
+	return nil
+}
+
+// Validate 校验一致性检查配置，未设置的字段使用默认值
+func (c *ConsistencyConfig) Validate() error {
+	if c.ScoreThreshold == 0 {
+		c.ScoreThreshold = DefaultConsistencyScoreThreshold
+	}
+	if c.Limit == 0 {
+		c.Limit = DefaultConsistencyLimit
+	}
+	if c.MinImportance == 0 {
+		c.MinImportance = DefaultConsistencyMinImportance
+	}
+	if c.ExclusiveTriggers == nil {
+		c.ExclusiveTriggers = DefaultExclusiveTriggers
+	}
+	if c.ScoreThreshold < 0 || c.ScoreThreshold > 1 {
+		return fmt.Errorf("score_threshold must be between 0 and 1")
+	}
+	if c.Limit < 0 {
+		return fmt.Errorf("limit must be positive")
+	}
+	if c.MinImportance != -1 && (c.MinImportance < 0 || c.MinImportance > 1) {
+		return fmt.Errorf("min_importance must be between 0 and 1, or -1 to check every fact")
+	}
+	return nil
+}
+
+// Validate 校验分层压缩配置，未设置的字段使用默认值
+func (c *CompactionConfig) Validate() error {
+	if c.Threshold == 0 {
+		c.Threshold = DefaultCompactionThreshold
+	}
+	if c.Similarity == 0 {
+		c.Similarity = DefaultCompactionSimilarity
+	}
+	if c.MinClusterSize == 0 {
+		c.MinClusterSize = DefaultCompactionMinClusterSize
+	}
+	if c.Threshold < 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	if c.Similarity < 0 || c.Similarity > 1 {
+		return fmt.Errorf("similarity must be between 0 and 1")
+	}
+	if c.MinClusterSize < 2 {
+		return fmt.Errorf("min_cluster_size must be at least 2")
+	}
+	return nil
+}
+
+// TargetLength 根据对话长度计算单条记忆的目标字数
+func (c *SummaryConfig) TargetLength(conversationLength int) int {
+	target := c.MaxLength
+	if target <= 0 {
+		target = DefaultSummaryMaxLength
+	}
+	if c.CompressionRatio > 0 {
+		if ratioLength := int(float64(conversationLength) * c.CompressionRatio); ratioLength < target {
+			target = max(ratioLength, MinSummaryLength)
+		}
+	}
+	return target
+}
+
+// Validate 校验检索缓存配置
+func (c *CacheConfig) Validate() error {
+	if c.TTL == "" {
+		c.TTL = DefaultCacheTTL
+	}
+
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil {
+		return fmt.Errorf("ttl is invalid: %w", err)
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive")
+	}
+	if c.MaxEntries < 0 {
+		return fmt.Errorf("max_entries must not be negative")
+	}
+	return nil
+}
+
+// TTLDuration 返回解析后的 TTL，校验前调用时回退到默认值
+func (c *CacheConfig) TTLDuration() time.Duration {
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil || ttl <= 0 {
+		ttl, _ = time.ParseDuration(DefaultCacheTTL)
+	}
+	return ttl
+}
+
+// embedderOrDefault 未配置 embedder 时回退到默认 embedder
+func embedderOrDefault(name string) string {
+	if name == "" {
+		return EmbedderName
+	}
+	return name
+}
+
+// Validate 校验熔断器配置
+func (c *BreakerConfig) Validate() error {
+	if c.FailureThreshold == 0 {
+		c.FailureThreshold = DefaultBreakerFailureThreshold
+	}
+	if c.FailureThreshold < 0 {
+		return fmt.Errorf("failure_threshold must be positive")
+	}
+	if c.Cooldown == "" {
+		c.Cooldown = DefaultBreakerCooldown
+	}
+
+	cooldown, err := time.ParseDuration(c.Cooldown)
+	if err != nil {
+		return fmt.Errorf("cooldown is invalid: %w", err)
+	}
+	if cooldown <= 0 {
+		return fmt.Errorf("cooldown must be positive")
+	}
+	return nil
+}
+
+// CooldownDuration 返回解析后的冷却时间，校验前调用时回退到默认值
+func (c *BreakerConfig) CooldownDuration() time.Duration {
+	cooldown, err := time.ParseDuration(c.Cooldown)
+	if err != nil || cooldown <= 0 {
+		cooldown, _ = time.ParseDuration(DefaultBreakerCooldown)
+	}
+	return cooldown
+}
+
+// Validate 校验事件提取配置
+func (c *EventConfig) Validate() error {
+	if c.DualWriteMode == "" {
+		c.DualWriteMode = DualWriteBestEffort
+	}
+	if c.MaxEvents == 0 {
+		c.MaxEvents = DefaultMaxPerExtraction
+	}
+	if c.MaxEvents < 0 {
+		return fmt.Errorf("max_events must be positive")
+	}
+	if c.Embedder == "" {
+		c.Embedder = EmbedderName
+	}
+
+	switch c.DualWriteMode {
+	case DualWriteBestEffort, DualWriteStrict:
+		return nil
+	default:
+		return fmt.Errorf("invalid dual_write_mode: %s, must be %s or %s", c.DualWriteMode, DualWriteBestEffort, DualWriteStrict)
+	}
+}
+
+// Package-level config
+var (
+	configMu sync.RWMutex
+	config   = DefaultConfig()
+)
+
+// Init 初始化 action 包配置
+func Init(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	configMu.Lock()
+	config = cfg
+	configMu.Unlock()
+
+	llmBreaker.Store(newCircuitBreakerFromConfig(cfg.Breaker))
+	llmLimiter.Store(NewLimiter(cfg.MaxConcurrency))
+	return nil
+}
+
+// Reload 热更新配置，只替换运行时可安全变更的字段
+// Cache 在创建 Memory 时已生效、Breaker 与并发限制持有运行状态，均保持不变；action 每次请求都会重新读取配置
+// Summary/Event 只更新阈值与数量限制：embedder 变更会使查询向量与已存储向量不在同一空间，
+// workers 与双写模式属于部署决策，均保持 Init 时的值
+func Reload(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	config.IncludeSystemMessages = cfg.IncludeSystemMessages
+	config.EmbedAttachmentCaptions = cfg.EmbedAttachmentCaptions
+	config.ContextualCaptionEmbedding = cfg.ContextualCaptionEmbedding
+	config.MinMessages = cfg.MinMessages
+	config.MinContentLength = cfg.MinContentLength
+	config.Summary.MaxLength = cfg.Summary.MaxLength
+	config.Summary.CompressionRatio = cfg.Summary.CompressionRatio
+	config.Summary.MaxMemories = cfg.Summary.MaxMemories
+	config.Event.MaxEvents = cfg.Event.MaxEvents
+	config.Consistency = cfg.Consistency
+	config.LogLevels = cfg.LogLevels
+	return nil
+}
+
+// GetConfig 返回当前配置
+func GetConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
@@ -2,8 +2,12 @@ package action
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/Zereker/memory/internal/domain"
 	"github.com/Zereker/memory/pkg/vector"
 )
@@ -14,50 +18,137 @@ var _ domain.AddAction = (*ConsistencyAction)(nil)
 // ConsistencyAction 认知一致性检查 Action
 // 写入阶段：新写入的 fact 记忆，按 keyword + embedding 搜索已有 fact
 // 发现冲突则 soft-disable 旧记忆（设 expired_at）
+// 新事件与已有事件主语、互斥触发词相同而宾语不同（"小明 住在 北京" / "小明 住在 上海"）时，旧事件设 invalid_at 结束
 type ConsistencyAction struct {
 	*BaseAction
-	store vector.Store
+	store    vector.Store
+	cfg      ConsistencyConfig
+	onExpire func(agentID, userID string) // 旧记忆被过期后回调（如使检索缓存失效）
 }
 
 // NewConsistencyAction 创建 ConsistencyAction
 func NewConsistencyAction() *ConsistencyAction {
 	return &ConsistencyAction{
-		BaseAction: NewBaseAction("consistency"),
+		BaseAction: NewBaseAction(ActionConsistency),
 		store:      vector.NewStore(),
+		cfg:        GetConfig().Consistency,
 	}
 }
 
+// WithConfig 设置一致性检查配置
+func (a *ConsistencyAction) WithConfig(cfg ConsistencyConfig) *ConsistencyAction {
+	a.cfg = cfg
+	return a
+}
+
 // WithStore 设置存储（用于测试注入 mock）
 func (a *ConsistencyAction) WithStore(store vector.Store) *ConsistencyAction {
 	a.store = store
 	return a
 }
 
+// OnExpire 设置旧记忆过期后的回调
+func (a *ConsistencyAction) OnExpire(fn func(agentID, userID string)) *ConsistencyAction {
+	a.onExpire = fn
+	return a
+}
+
 // Name 返回 action 名称
 func (a *ConsistencyAction) Name() string {
 	return "consistency"
 }
 
 // Handle 执行一致性检查
-// 仅处理高重要性 fact 记忆，异步执行不阻塞
+// 仅处理重要性达到 MinImportance 的 fact 记忆，异步执行不阻塞
 func (a *ConsistencyAction) Handle(c *domain.AddContext) {
 	// 筛选高重要性的 fact 记忆
 	var highImportanceFacts []domain.SummaryMemory
 	for _, s := range c.Summaries {
-		if s.MemoryType == domain.MemoryTypeFact && s.Importance >= 0.7 {
+		if s.MemoryType == domain.MemoryTypeFact && s.Importance >= a.cfg.MinImportance {
 			highImportanceFacts = append(highImportanceFacts, s)
 		}
 	}
 
-	if len(highImportanceFacts) == 0 {
-		c.Next()
+	// 异步执行冲突检测，不阻塞主链
+	if len(highImportanceFacts) > 0 {
+		go a.detectConflicts(c.Context, c.AgentID, c.UserID, highImportanceFacts)
+	}
+	if len(c.Events) > 0 {
+		go a.invalidateContradictedEvents(c.Context, c.AgentID, c.UserID, c.Events)
+	}
+
+	c.Next()
+}
+
+// invalidateContradictedEvents 结束与新事件矛盾的已有事件
+// 只检查互斥触发词且未给出结束时间的新事件；矛盾指 argument1 与 trigger_word 相同、argument2 不同且尚未结束，
+// 旧事件的 invalid_at 设为新事件的开始时间
+// 同一批提取的事件互不比较，已有事件开始时间晚于新事件时说明新事件描述的是更早的情况，不做处理
+func (a *ConsistencyAction) invalidateContradictedEvents(ctx context.Context, agentID, userID string, newEvents []domain.EventTriplet) {
+	if a.store == nil {
 		return
 	}
 
-	// 异步执行冲突检测，不阻塞主链
-	go a.detectConflicts(c.Context, c.AgentID, c.UserID, highImportanceFacts)
+	batch := make(map[string]bool, len(newEvents))
+	for _, e := range newEvents {
+		batch[e.ID] = true
+	}
 
-	c.Next()
+	base := NewBaseAction("consistency")
+	for _, newEvent := range newEvents {
+		if newEvent.InvalidAt != nil || !slices.Contains(a.cfg.ExclusiveTriggers, newEvent.TriggerWord) {
+			continue
+		}
+
+		start := newEvent.OccurredAt
+		if newEvent.ValidAt != nil {
+			start = *newEvent.ValidAt
+		}
+
+		docs, err := a.store.Search(ctx, vector.SearchQuery{
+			Filters: map[string]any{
+				"type":         domain.DocTypeEvent,
+				"agent_id":     agentID,
+				"user_id":      userID,
+				"argument1":    newEvent.Argument1,
+				"trigger_word": newEvent.TriggerWord,
+			},
+			MissingFields: []string{"invalid_at"},
+			Limit:         a.cfg.Limit,
+		})
+		if err != nil {
+			a.logger.Warn("event conflict search failed", "error", err)
+			continue
+		}
+
+		for _, doc := range docs {
+			existing := base.DocToEventTriplet(doc)
+			if batch[existing.ID] || existing.Argument2 == newEvent.Argument2 {
+				continue
+			}
+			if existing.ValidAt != nil && existing.ValidAt.After(start) {
+				continue
+			}
+
+			a.logger.Info("contradictory event detected",
+				"new_id", newEvent.ID,
+				"old_id", existing.ID,
+				"new_argument2", newEvent.Argument2,
+				"old_argument2", existing.Argument2,
+			)
+
+			if err := a.store.UpdateFields(ctx, existing.ID, map[string]any{
+				"invalid_at": start,
+			}); err != nil {
+				a.logger.Warn("failed to invalidate old event", "id", existing.ID, "error", err)
+				continue
+			}
+
+			if a.onExpire != nil {
+				a.onExpire(agentID, userID)
+			}
+		}
+	}
 }
 
 // detectConflicts 检测并处理冲突的 fact 记忆
@@ -80,8 +171,8 @@ func (a *ConsistencyAction) detectConflicts(ctx context.Context, agentID, userID
 				"agent_id":    agentID,
 				"user_id":     userID,
 			},
-			ScoreThreshold: 0.8, // 高相似度阈值
-			Limit:          5,
+			ScoreThreshold: a.cfg.ScoreThreshold,
+			Limit:          a.cfg.Limit,
 		})
 		if err != nil {
 			a.logger.Warn("conflict search failed", "error", err)
@@ -112,18 +203,103 @@ func (a *ConsistencyAction) detectConflicts(ctx context.Context, agentID, userID
 				"old_content", existing.Content,
 			)
 
-			// 通过类型断言使用 UpdateFields
-			type fieldUpdater interface {
-				UpdateFields(ctx context.Context, id string, fields map[string]any) error
+			if err := a.store.UpdateFields(ctx, existing.ID, map[string]any{
+				"expired_at": now,
+			}); err != nil {
+				a.logger.Warn("failed to expire old fact", "id", existing.ID, "error", err)
+				continue
+			}
+
+			score, _ := doc["_score"].(float64)
+			a.recordConflict(ctx, domain.ConflictRecord{
+				ID:         fmt.Sprintf("cfl_%s", uuid.New().String()[:8]),
+				AgentID:    agentID,
+				UserID:     userID,
+				OldID:      existing.ID,
+				NewID:      newFact.ID,
+				OldContent: existing.Content,
+				NewContent: newFact.Content,
+				Score:      score,
+				CreatedAt:  now,
+			})
+
+			if a.onExpire != nil {
+				a.onExpire(agentID, userID)
 			}
+		}
+	}
+}
 
-			if updater, ok := a.store.(fieldUpdater); ok {
-				if err := updater.UpdateFields(ctx, existing.ID, map[string]any{
-					"expired_at": now,
-				}); err != nil {
-					a.logger.Warn("failed to expire old fact", "id", existing.ID, "error", err)
-				}
+// recordConflict 写入冲突审计记录，失败只记录日志，不影响已完成的过期
+func (a *ConsistencyAction) recordConflict(ctx context.Context, r domain.ConflictRecord) {
+	doc := map[string]any{
+		"id":          r.ID,
+		"type":        domain.DocTypeConflict,
+		"agent_id":    r.AgentID,
+		"user_id":     r.UserID,
+		"old_id":      r.OldID,
+		"new_id":      r.NewID,
+		"old_content": r.OldContent,
+		"new_content": r.NewContent,
+		"score":       r.Score,
+		"created_at":  r.CreatedAt,
+	}
+
+	if err := a.store.Store(ctx, r.ID, doc); err != nil {
+		a.logger.Warn("failed to record conflict", "old_id", r.OldID, "new_id", r.NewID, "error", err)
+	}
+}
+
+// Restore 撤销一次冲突判定：清除旧 fact 的 expired_at，并把对应审计记录标记为已恢复
+// expireSuperseding 为 true 时，审计记录中取代它的新 fact 改为过期
+// 只有该 agent/user 下存在未恢复的审计记录时才会修改 id，否则返回 ErrNotFound，避免按 id 改动其他租户或未被取代的文档
+func (a *ConsistencyAction) Restore(ctx context.Context, agentID, userID, id string, expireSuperseding bool) (*domain.RestoreFactResponse, error) {
+	docs, err := a.store.Search(ctx, vector.SearchQuery{
+		Filters: map[string]any{
+			"type":     domain.DocTypeConflict,
+			"agent_id": agentID,
+			"user_id":  userID,
+			"old_id":   id,
+		},
+		MissingFields: []string{"restored_at"},
+		Limit:         ConflictsLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: search conflicts: %w", domain.ErrStoreUnavailable, err)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%w: no unrestored conflict for %s", domain.ErrNotFound, id)
+	}
+
+	now := time.Now()
+	if err := a.store.UpdateFields(ctx, id, map[string]any{
+		"expired_at": nil,
+		"updated_at": now,
+	}); err != nil {
+		return nil, fmt.Errorf("%w: restore fact %s: %w", domain.ErrStoreUnavailable, id, err)
+	}
+
+	resp := &domain.RestoreFactResponse{Restored: id, Expired: []string{}}
+	for _, doc := range docs {
+		record := a.DocToConflictRecord(doc)
+
+		if expireSuperseding && record.NewID != "" {
+			if err := a.store.UpdateFields(ctx, record.NewID, map[string]any{
+				"expired_at": now,
+				"updated_at": now,
+			}); err != nil {
+				return nil, fmt.Errorf("%w: expire fact %s: %w", domain.ErrStoreUnavailable, record.NewID, err)
 			}
+			resp.Expired = append(resp.Expired, record.NewID)
+		}
+
+		if err := a.store.UpdateFields(ctx, record.ID, map[string]any{"restored_at": now}); err != nil {
+			a.logger.Warn("failed to mark conflict restored", "id", record.ID, "error", err)
 		}
 	}
+
+	a.logger.Info("fact restored", "id", id, "conflicts", len(docs), "expired", len(resp.Expired))
+
+	resp.Success = true
+	return resp, nil
 }
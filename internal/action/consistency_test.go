@@ -0,0 +1,245 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestConsistency_Config(t *testing.T) {
+	ctx := context.Background()
+
+	// 已有 fact 按相似度返回，遵守查询的 ScoreThreshold 与 Limit
+	newStore := func() *MockVectorStore {
+		store := NewMockVectorStore()
+		store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+			var found []map[string]any
+			for _, doc := range []map[string]any{
+				{"id": "fact_1", "content": "用户住在北京", "_score": 0.92},
+				{"id": "fact_2", "content": "用户住在北京朝阳区", "_score": 0.75},
+				{"id": "fact_3", "content": "用户在北京工作", "_score": 0.65},
+			} {
+				if doc["_score"].(float64) >= query.ScoreThreshold && len(found) < query.Limit {
+					found = append(found, doc)
+				}
+			}
+			return found, nil
+		}
+		return store
+	}
+	newFact := domain.SummaryMemory{ID: "fact_new", Content: "用户住在上海", MemoryType: domain.MemoryTypeFact, Importance: 0.5, Embedding: []float32{0.1}}
+
+	expiredIDs := func(store *MockVectorStore) []string {
+		var ids []string
+		for _, call := range store.UpdateFieldsCalls {
+			ids = append(ids, call.ID)
+		}
+		return ids
+	}
+
+	t.Run("lower threshold catches more candidates", func(t *testing.T) {
+		strict, loose := newStore(), newStore()
+
+		NewConsistencyAction().WithStore(strict).WithConfig(ConsistencyConfig{ScoreThreshold: 0.9, Limit: 5}).
+			detectConflicts(ctx, "agent_1", "user_1", []domain.SummaryMemory{newFact})
+		NewConsistencyAction().WithStore(loose).WithConfig(ConsistencyConfig{ScoreThreshold: 0.7, Limit: 5}).
+			detectConflicts(ctx, "agent_1", "user_1", []domain.SummaryMemory{newFact})
+
+		assert.Equal(t, []string{"fact_1"}, expiredIDs(strict))
+		assert.Equal(t, []string{"fact_1", "fact_2"}, expiredIDs(loose))
+	})
+
+	t.Run("limit caps candidates", func(t *testing.T) {
+		store := newStore()
+
+		NewConsistencyAction().WithStore(store).WithConfig(ConsistencyConfig{ScoreThreshold: 0.6, Limit: 2}).
+			detectConflicts(ctx, "agent_1", "user_1", []domain.SummaryMemory{newFact})
+
+		assert.Equal(t, []string{"fact_1", "fact_2"}, expiredIDs(store))
+	})
+
+	t.Run("importance gate", func(t *testing.T) {
+		gated := NewConsistencyAction().WithStore(newStore()).WithConfig(ConsistencyConfig{MinImportance: 0.7})
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Summaries = []domain.SummaryMemory{newFact}
+		gated.Handle(c)
+		assert.Empty(t, gated.store.(*MockVectorStore).SearchCalls)
+
+		cfg := ConsistencyConfig{MinImportance: -1}
+		assert.NoError(t, cfg.Validate())
+		assert.Equal(t, DefaultConsistencyScoreThreshold, cfg.ScoreThreshold)
+
+		invalid := ConsistencyConfig{MinImportance: 1.5}
+		assert.Error(t, invalid.Validate())
+	})
+}
+
+func TestConsistency_ConflictAudit(t *testing.T) {
+	ctx := context.Background()
+
+	store := newMemoryVectorStore(3)
+	store.docs["fact_old"] = map[string]any{
+		"id":          "fact_old",
+		"type":        domain.DocTypeSummary,
+		"memory_type": domain.MemoryTypeFact,
+		"agent_id":    "agent_1",
+		"user_id":     "user_1",
+		"content":     "用户住在北京",
+	}
+
+	NewConsistencyAction().WithStore(store).detectConflicts(ctx, "agent_1", "user_1", []domain.SummaryMemory{
+		{ID: "fact_new", Content: "用户住在上海", MemoryType: domain.MemoryTypeFact, Importance: 0.9, Embedding: []float32{0.1, 0.2, 0.3}},
+	})
+
+	resp, err := NewMemory().WithStores(store, NewMockRelationStore()).Conflicts(ctx, "agent_1", "user_1")
+	require.NoError(t, err)
+	require.Len(t, resp.Conflicts, 1)
+
+	record := resp.Conflicts[0]
+	assert.Equal(t, "fact_old", record.OldID)
+	assert.Equal(t, "fact_new", record.NewID)
+	assert.Equal(t, "用户住在北京", record.OldContent)
+	assert.Equal(t, "用户住在上海", record.NewContent)
+	assert.Equal(t, 0.9, record.Score)
+	assert.False(t, record.CreatedAt.IsZero())
+
+	t.Run("other users see no conflicts", func(t *testing.T) {
+		resp, err := NewMemory().WithStores(store, NewMockRelationStore()).Conflicts(ctx, "agent_1", "user_2")
+		require.NoError(t, err)
+		assert.Empty(t, resp.Conflicts)
+	})
+
+	t.Run("no store", func(t *testing.T) {
+		_, err := NewMemory().WithStores(nil, NewMockRelationStore()).Conflicts(ctx, "agent_1", "user_1")
+		assert.ErrorIs(t, err, domain.ErrStoreUnavailable)
+	})
+}
+
+func TestMemory_RestoreFact(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	store := newMemoryVectorStore(3)
+	for id, content := range map[string]string{"fact_old": "用户住在北京", "fact_new": "用户住在上海"} {
+		store.docs[id] = map[string]any{
+			"id":          id,
+			"type":        domain.DocTypeSummary,
+			"memory_type": domain.MemoryTypeFact,
+			"agent_id":    "agent_1",
+			"user_id":     "user_1",
+			"content":     content,
+			"embedding":   []float32{0.1, 0.2, 0.3},
+		}
+	}
+	memory := NewMemory().WithStores(store, NewMockRelationStore())
+
+	retrievedFacts := func() []string {
+		resp, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里"})
+		require.NoError(t, err)
+		return summaryIDs(resp.Facts)
+	}
+
+	NewConsistencyAction().WithStore(store).detectConflicts(ctx, "agent_1", "user_1", []domain.SummaryMemory{
+		{ID: "fact_new", Content: "用户住在上海", MemoryType: domain.MemoryTypeFact, Importance: 0.9, Embedding: []float32{0.1, 0.2, 0.3}},
+	})
+	require.Equal(t, []string{"fact_new"}, retrievedFacts())
+
+	resp, err := memory.RestoreFact(ctx, &domain.RestoreFactRequest{AgentID: "agent_1", UserID: "user_1", ID: "fact_old", ExpireSuperseding: true})
+	require.NoError(t, err)
+	assert.Equal(t, "fact_old", resp.Restored)
+	assert.Equal(t, []string{"fact_new"}, resp.Expired)
+
+	t.Run("restored fact reappears in search", func(t *testing.T) {
+		assert.Equal(t, []string{"fact_old"}, retrievedFacts())
+	})
+
+	t.Run("audit entry is marked restored", func(t *testing.T) {
+		conflicts, err := memory.Conflicts(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+		require.Len(t, conflicts.Conflicts, 1)
+		assert.NotNil(t, conflicts.Conflicts[0].RestoredAt)
+	})
+
+	t.Run("id is required", func(t *testing.T) {
+		_, err := memory.RestoreFact(ctx, &domain.RestoreFactRequest{AgentID: "agent_1", UserID: "user_1"})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("id without conflict record stays expired", func(t *testing.T) {
+		_, err := memory.RestoreFact(ctx, &domain.RestoreFactRequest{AgentID: "agent_1", UserID: "user_1", ID: "fact_new"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NotNil(t, store.docs["fact_new"]["expired_at"])
+	})
+
+	t.Run("other tenant cannot restore", func(t *testing.T) {
+		require.NoError(t, store.UpdateFields(ctx, "fact_old", map[string]any{"expired_at": time.Now()}))
+
+		_, err := memory.RestoreFact(ctx, &domain.RestoreFactRequest{AgentID: "agent_2", UserID: "user_1", ID: "fact_old"})
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		assert.NotNil(t, store.docs["fact_old"]["expired_at"])
+	})
+}
+
+func TestConsistency_ContradictoryEvents(t *testing.T) {
+	ctx := context.Background()
+
+	movedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	store := vector.NewMemoryStore(3)
+	for _, doc := range []map[string]any{
+		{"id": "evt_beijing", "argument1": "小明", "trigger_word": "住在", "argument2": "北京", "valid_at": time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"id": "evt_coffee", "argument1": "小明", "trigger_word": "喜欢", "argument2": "咖啡"},
+		{"id": "evt_other_user", "user_id": "user_2", "argument1": "小明", "trigger_word": "住在", "argument2": "广州"},
+	} {
+		doc["type"] = domain.DocTypeEvent
+		doc["agent_id"] = "agent_1"
+		if doc["user_id"] == nil {
+			doc["user_id"] = "user_1"
+		}
+		require.NoError(t, store.Store(ctx, doc["id"].(string), doc))
+	}
+
+	invalidAt := func(id string) any {
+		docs, err := store.MGet(ctx, []string{id})
+		require.NoError(t, err)
+		return docs[id]["invalid_at"]
+	}
+
+	var expired int
+	action := NewConsistencyAction().WithStore(store).OnExpire(func(agentID, userID string) { expired++ })
+	action.invalidateContradictedEvents(ctx, "agent_1", "user_1", []domain.EventTriplet{
+		{ID: "evt_shanghai", Argument1: "小明", TriggerWord: "住在", Argument2: "上海", ValidAt: &movedAt},
+		{ID: "evt_tea", Argument1: "小明", TriggerWord: "喜欢", Argument2: "茶", OccurredAt: movedAt},
+	})
+
+	t.Run("older contradictory event ends when the new one starts", func(t *testing.T) {
+		assert.Equal(t, movedAt, invalidAt("evt_beijing"))
+		assert.Equal(t, 1, expired)
+	})
+
+	t.Run("non-exclusive triggers may hold several objects", func(t *testing.T) {
+		assert.Nil(t, invalidAt("evt_coffee"))
+	})
+
+	t.Run("other users are untouched", func(t *testing.T) {
+		assert.Nil(t, invalidAt("evt_other_user"))
+	})
+
+	t.Run("new event describing an earlier period is ignored", func(t *testing.T) {
+		require.NoError(t, store.Store(ctx, "evt_hangzhou", map[string]any{
+			"id": "evt_hangzhou", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1",
+			"argument1": "小红", "trigger_word": "住在", "argument2": "杭州", "valid_at": movedAt,
+		}))
+		earlier := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+		action.invalidateContradictedEvents(ctx, "agent_1", "user_1", []domain.EventTriplet{
+			{ID: "evt_nanjing", Argument1: "小红", TriggerWord: "住在", Argument2: "南京", ValidAt: &earlier},
+		})
+		assert.Nil(t, invalidAt("evt_hangzhou"))
+	})
+}
@@ -0,0 +1,143 @@
+package action
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// DeleteUserBatchSize 删除用户数据时每批扫描的文档数
+const DeleteUserBatchSize = 100
+
+// DeleteUserAction 删除 agent+user 下的全部数据（被遗忘权）
+// 与遗忘不同，归档和软删除的文档也会被物理删除
+type DeleteUserAction struct {
+	*BaseAction
+
+	vectorStore    vector.Store
+	relationStore  relation.Store
+	shortTermStore *ShortTermStore
+	batchSize      int
+}
+
+// NewDeleteUserAction 创建 DeleteUserAction
+func NewDeleteUserAction() *DeleteUserAction {
+	return &DeleteUserAction{
+		BaseAction:     NewBaseAction("delete_user"),
+		vectorStore:    vector.NewStore(),
+		relationStore:  relation.NewStore(),
+		shortTermStore: GetShortTermStore(),
+		batchSize:      DeleteUserBatchSize,
+	}
+}
+
+// WithStores 设置存储（用于测试注入 mock）
+func (a *DeleteUserAction) WithStores(v vector.Store, r relation.Store) *DeleteUserAction {
+	a.vectorStore = v
+	a.relationStore = r
+	return a
+}
+
+// Execute 依次删除事件关系、OpenSearch 文档和短期记忆窗口
+// 关系须在事件文档删除前处理，否则无法再找到事件 ID；任一存储失败即中止，可重试
+func (a *DeleteUserAction) Execute(ctx context.Context, agentID, userID string) (*domain.DeleteUserResponse, error) {
+	a.logger.Info("executing delete user", "agent_id", agentID, "user_id", userID)
+
+	scanner, ok := a.vectorStore.(vector.Scanner)
+	if !ok {
+		return nil, fmt.Errorf("%w: vector store does not support user deletion", domain.ErrInvalidInput)
+	}
+
+	resp := &domain.DeleteUserResponse{}
+	filters := map[string]any{"agent_id": agentID, "user_id": userID}
+
+	// 1. 事件关系
+	if a.relationStore != nil {
+		relations, err := a.deleteRelations(ctx, scanner, agentID, userID)
+		resp.Relations = relations
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. 活跃文档批量删除
+	deleted, err := a.vectorStore.DeleteByQuery(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: delete documents: %w", domain.ErrStoreUnavailable, err)
+	}
+	resp.Documents = deleted
+
+	// 3. DeleteByQuery 只匹配活跃文档，剩余的归档/软删除文档逐个删除
+	after := ""
+	for {
+		docs, err := scanner.Scan(ctx, filters, after, a.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: scan documents: %w", domain.ErrStoreUnavailable, err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		for _, doc := range docs {
+			if doc["status"] == vector.StatusActive {
+				continue // 已被 DeleteByQuery 删除，刷新前仍可能被扫描到
+			}
+			id, _ := doc["id"].(string)
+			if err := a.vectorStore.Delete(ctx, id); err != nil {
+				return nil, fmt.Errorf("%w: delete document %s: %w", domain.ErrStoreUnavailable, id, err)
+			}
+			resp.Documents++
+		}
+		after, _ = docs[len(docs)-1]["id"].(string)
+	}
+
+	// 4. 短期记忆窗口
+	resp.ShortTermWindows = a.shortTermStore.ClearUser(agentID, userID)
+
+	a.logger.Info("delete user completed",
+		"documents", resp.Documents,
+		"relations", resp.Relations,
+		"short_term_windows", resp.ShortTermWindows,
+	)
+
+	resp.Success = true
+	return resp, nil
+}
+
+// deleteRelations 删除 agent+user 下所有事件的关系，返回删除的关系数
+func (a *DeleteUserAction) deleteRelations(ctx context.Context, scanner vector.Scanner, agentID, userID string) (int, error) {
+	filters := map[string]any{"type": domain.DocTypeEvent, "agent_id": agentID, "user_id": userID}
+	seen := make(map[string]bool)
+
+	after := ""
+	for {
+		docs, err := scanner.Scan(ctx, filters, after, a.batchSize)
+		if err != nil {
+			return len(seen), fmt.Errorf("%w: scan events: %w", domain.ErrStoreUnavailable, err)
+		}
+		if len(docs) == 0 {
+			return len(seen), nil
+		}
+
+		for _, doc := range docs {
+			id, _ := doc["id"].(string)
+			rels, err := a.relationStore.FindByEventID(ctx, id)
+			if err != nil {
+				return len(seen), fmt.Errorf("%w: find relations of %s: %w", domain.ErrStoreUnavailable, id, err)
+			}
+			if len(rels) == 0 {
+				continue
+			}
+			if err := a.relationStore.DeleteByEventID(ctx, id); err != nil {
+				return len(seen), fmt.Errorf("%w: delete relations of %s: %w", domain.ErrStoreUnavailable, id, err)
+			}
+			for _, rel := range rels {
+				seen[rel.ID] = true
+			}
+		}
+		after, _ = docs[len(docs)-1]["id"].(string)
+	}
+}
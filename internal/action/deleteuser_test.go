@@ -0,0 +1,103 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestMemory_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+
+	// 向量存储：DeleteByQuery 只删除活跃文档，与 OpenSearch 一致
+	store := newMemoryVectorStore(3)
+	store.DeleteByQueryFunc = func(ctx context.Context, filters map[string]any) (int, error) {
+		deleted := 0
+		for _, doc := range store.sorted(filters, "") {
+			if doc["status"] == vector.StatusActive {
+				delete(store.docs, doc["id"].(string))
+				deleted++
+			}
+		}
+		return deleted, nil
+	}
+	store.DeleteFunc = func(ctx context.Context, id string) error {
+		delete(store.docs, id)
+		return nil
+	}
+	for _, doc := range []map[string]any{
+		{"id": "mem_1", "type": domain.DocTypeSummary, "user_id": "user_1", "status": vector.StatusActive},
+		{"id": "mem_2", "type": domain.DocTypeSummary, "user_id": "user_1", "status": vector.StatusArchived},
+		{"id": "evt_1", "type": domain.DocTypeEvent, "user_id": "user_1", "status": vector.StatusActive},
+		{"id": "evt_2", "type": domain.DocTypeEvent, "user_id": "user_1", "status": vector.StatusDeleted},
+		{"id": "mem_3", "type": domain.DocTypeSummary, "user_id": "user_2", "status": vector.StatusActive},
+	} {
+		doc["agent_id"] = "agent_delete"
+		store.docs[doc["id"].(string)] = doc
+	}
+
+	// 关系存储：evt_1 与 evt_2 之间一条关系
+	rels := map[string]relation.Relation{
+		"rel_1": {ID: "rel_1", FromEventID: "evt_1", ToEventID: "evt_2", RelationType: domain.RelationCausal},
+	}
+	relationStore := NewMockRelationStore()
+	relationStore.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+		var found []relation.Relation
+		for _, rel := range rels {
+			if rel.FromEventID == eventID || rel.ToEventID == eventID {
+				found = append(found, rel)
+			}
+		}
+		return found, nil
+	}
+	relationStore.DeleteByEventIDFunc = func(ctx context.Context, eventID string) error {
+		for id, rel := range rels {
+			if rel.FromEventID == eventID || rel.ToEventID == eventID {
+				delete(rels, id)
+			}
+		}
+		return nil
+	}
+
+	shortTerm := GetShortTermStore()
+	shortTerm.AppendMessages("agent_delete", "user_1", "session_1", domain.Messages{{Role: domain.RoleUser, Content: "你好"}})
+	shortTerm.AppendMessages("agent_delete", "user_1", "session_2", domain.Messages{{Role: domain.RoleUser, Content: "在吗"}})
+	shortTerm.AppendMessages("agent_delete", "user_2", "session_1", domain.Messages{{Role: domain.RoleUser, Content: "你好"}})
+
+	t.Run("clears every store", func(t *testing.T) {
+		resp, err := NewMemory().WithStores(store, relationStore).DeleteUser(ctx, "agent_delete", "user_1")
+		require.NoError(t, err)
+
+		assert.True(t, resp.Success)
+		assert.Equal(t, 4, resp.Documents)
+		assert.Equal(t, 1, resp.Relations)
+		assert.Equal(t, 2, resp.ShortTermWindows)
+
+		assert.Empty(t, store.sorted(map[string]any{"agent_id": "agent_delete", "user_id": "user_1"}, ""))
+		assert.Empty(t, rels)
+		assert.Nil(t, shortTerm.GetWindow("agent_delete", "user_1", "session_1"))
+		assert.Nil(t, shortTerm.GetWindow("agent_delete", "user_1", "session_2"))
+	})
+
+	t.Run("other users are untouched", func(t *testing.T) {
+		assert.Contains(t, store.docs, "mem_3")
+		assert.NotNil(t, shortTerm.GetWindow("agent_delete", "user_2", "session_1"))
+	})
+
+	t.Run("store failure reports store unavailable", func(t *testing.T) {
+		failing := newMemoryVectorStore(3)
+		failing.DeleteByQueryFunc = func(ctx context.Context, filters map[string]any) (int, error) {
+			return 0, errors.New("opensearch unavailable")
+		}
+
+		_, err := NewMemory().WithStores(failing, NewMockRelationStore()).DeleteUser(ctx, "agent_delete", "user_1")
+		assert.ErrorIs(t, err, domain.ErrStoreUnavailable)
+	})
+}
@@ -153,6 +153,7 @@
 //   - Generate: 调用 LLM 生成内容，自动记录 token 使用量
 //   - GenEmbedding: 生成文本向量表示
 //   - CosineSimilarity: 计算向量余弦相似度
+//   - NormalizeVector / DotProduct / NormedVector: 归一化向量与缓存范数，用于循环比较
 //   - DocToEpisode: 将存储文档转换为 Episode 结构
 //
 // # LLM 输出类型
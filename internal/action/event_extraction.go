@@ -1,7 +1,10 @@
 package action
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,14 +23,17 @@ type EventExtractionAction struct {
 
 	vectorStore   vector.Store
 	relationStore relation.Store
+
+	cfg EventConfig
 }
 
 // NewEventExtractionAction 创建 EventExtractionAction
 func NewEventExtractionAction() *EventExtractionAction {
 	return &EventExtractionAction{
-		BaseAction:    NewBaseAction("event_extraction"),
+		BaseAction:    NewBaseAction(ActionEventExtraction),
 		vectorStore:   vector.NewStore(),
 		relationStore: relation.NewStore(),
+		cfg:           GetConfig().Event,
 	}
 }
 
@@ -38,6 +44,12 @@ func (a *EventExtractionAction) WithStores(vectorStore vector.Store, relationSto
 	return a
 }
 
+// WithConfig 设置事件提取配置
+func (a *EventExtractionAction) WithConfig(cfg EventConfig) *EventExtractionAction {
+	a.cfg = cfg
+	return a
+}
+
 // Name 返回 action 名称
 func (a *EventExtractionAction) Name() string {
 	return "event_extraction"
@@ -51,9 +63,12 @@ type EventExtractResult struct {
 
 // ExtractedEvent 单条提取的事件三元组
 type ExtractedEvent struct {
-	TriggerWord string `json:"trigger_word"`
-	Argument1   string `json:"argument1"`
-	Argument2   string `json:"argument2"`
+	TriggerWord string  `json:"trigger_word"`
+	Argument1   string  `json:"argument1"`
+	Argument2   string  `json:"argument2"`
+	Confidence  float64 `json:"confidence"` // 置信度 0-1，用于超出上限时的取舍
+	ValidAt     string  `json:"valid_at"`   // 事件开始时间，YYYY / YYYY-MM / YYYY-MM-DD，未提及为空
+	InvalidAt   string  `json:"invalid_at"` // 事件结束时间，格式同 valid_at
 }
 
 // ExtractedRelation 事件间的关系
@@ -72,14 +87,26 @@ func (a *EventExtractionAction) Handle(c *domain.AddContext) {
 		return
 	}
 
+	if cfg := GetConfig(); cfg.BelowExtractionMinimum(c.Messages) {
+		a.logger.Debug("conversation below extraction minimum, skipping", "message_count", len(c.Messages))
+		c.Next()
+		return
+	}
+
 	// 调用 LLM 提取事件
 	conversation := c.Messages.Format()
 	var result EventExtractResult
 	if err := a.Generate(c, "event_extract", map[string]any{
-		"conversation": conversation,
-		"language":     c.LanguageName(),
+		"conversation":   conversation,
+		"language":       c.LanguageName(),
+		"reference_date": c.OccurredAtOr(time.Now()).Format(time.DateOnly),
 	}, &result); err != nil {
 		a.logger.Error("event extraction failed", "error", err)
+		// LLM 不可用时终止链，其余错误（如输出解析失败）跳过本步骤
+		if errors.Is(err, domain.ErrLLMUnavailable) {
+			c.SetError(err)
+			return
+		}
 		c.Next()
 		return
 	}
@@ -90,7 +117,14 @@ func (a *EventExtractionAction) Handle(c *domain.AddContext) {
 		return
 	}
 
+	// 超出上限时按置信度保留 top-N
+	if a.cfg.MaxEvents > 0 && len(result.Events) > a.cfg.MaxEvents {
+		a.logger.Info("dropping low-confidence events", "extracted", len(result.Events), "max_events", a.cfg.MaxEvents)
+		result = capEvents(result, a.cfg.MaxEvents)
+	}
+
 	now := time.Now()
+	occurredAt := c.OccurredAtOr(now)
 	eventIDs := make([]string, len(result.Events))
 
 	// 存储事件三元组
@@ -100,54 +134,82 @@ func (a *EventExtractionAction) Handle(c *domain.AddContext) {
 
 		// 生成触发词向量
 		triggerText := ev.Argument1 + " " + ev.TriggerWord + " " + ev.Argument2
-		embedding, err := a.GenEmbedding(c.Context, EmbedderName, triggerText)
+		embedding, err := a.GenEmbedding(c.Context, embedderOrDefault(a.cfg.Embedder), triggerText)
 		if err != nil {
 			a.logger.Warn("failed to generate trigger embedding", "error", err)
+			c.AddWarning("%s: failed to embed event %s: %v", a.Name(), eventID, err)
 		}
 
 		triplet := domain.EventTriplet{
 			ID:               eventID,
 			AgentID:          c.AgentID,
 			UserID:           c.UserID,
+			SessionID:        c.SessionID,
 			TriggerWord:      ev.TriggerWord,
 			Argument1:        ev.Argument1,
 			Argument2:        ev.Argument2,
 			TriggerEmbedding: embedding,
 			AccessCount:      0,
 			LastAccessedAt:   now,
+			OccurredAt:       occurredAt,
+			ValidAt:          parseEventTime(ev.ValidAt),
+			InvalidAt:        parseEventTime(ev.InvalidAt),
 			CreatedAt:        now,
 		}
 
 		// 存储到 OpenSearch（向量检索用）
 		if err := a.storeEventToVector(c, triplet); err != nil {
 			a.logger.Warn("failed to store event to vector", "id", eventID, "error", err)
+			c.AddWarning("%s: failed to store event %s: %v", a.Name(), eventID, err)
+
+			// strict 模式：丢弃该事件，引用它的关系也不再写入 PostgreSQL
+			if a.cfg.DualWriteMode == DualWriteStrict {
+				eventIDs[i] = ""
+				continue
+			}
 		}
 
 		c.AddEvents(triplet)
 	}
 
-	// 存储事件关系
+	// 存储事件关系，同一批内 (from, to, type) 相同的关系只保留第一条：关系存储按该键去重，重复项不会被持久化
+	var relations []domain.EventRelation
+	seen := make(map[[3]string]bool, len(result.Relations))
 	for _, rel := range result.Relations {
 		if rel.FromIndex < 0 || rel.FromIndex >= len(eventIDs) ||
 			rel.ToIndex < 0 || rel.ToIndex >= len(eventIDs) {
 			continue
 		}
 
-		eventRelation := domain.EventRelation{
+		// 端点事件已被丢弃（strict 模式下向量写入失败）
+		if eventIDs[rel.FromIndex] == "" || eventIDs[rel.ToIndex] == "" {
+			a.logger.Debug("skip relation with dropped event", "from_index", rel.FromIndex, "to_index", rel.ToIndex)
+			continue
+		}
+
+		key := [3]string{eventIDs[rel.FromIndex], eventIDs[rel.ToIndex], rel.RelationType}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		relations = append(relations, domain.EventRelation{
 			ID:           fmt.Sprintf("rel_%s", uuid.New().String()[:8]),
 			RelationType: rel.RelationType,
 			FromEventID:  eventIDs[rel.FromIndex],
 			ToEventID:    eventIDs[rel.ToIndex],
 			CreatedAt:    now,
-		}
+		})
+	}
 
-		// 存储关系到 PostgreSQL
-		if err := a.storeRelation(c, eventRelation); err != nil {
-			a.logger.Warn("failed to store relation", "error", err)
-			continue
+	// 批量存储关系到 PostgreSQL
+	if len(relations) > 0 {
+		if err := a.storeRelations(c, relations); err != nil {
+			a.logger.Warn("failed to store relations", "count", len(relations), "error", err)
+			c.AddWarning("%s: failed to store %d relations: %v", a.Name(), len(relations), err)
+		} else {
+			c.AddEventRelations(relations...)
 		}
-
-		c.AddEventRelations(eventRelation)
 	}
 
 	a.logger.Info("event extraction completed",
@@ -158,6 +220,43 @@ func (a *EventExtractionAction) Handle(c *domain.AddContext) {
 	c.Next()
 }
 
+// capEvents 按 confidence 保留前 n 个事件（保持原有顺序），并重映射关系索引，丢弃引用被删事件的关系
+func capEvents(result EventExtractResult, n int) EventExtractResult {
+	order := make([]int, len(result.Events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return result.Events[order[i]].Confidence > result.Events[order[j]].Confidence
+	})
+
+	keep := make([]bool, len(result.Events))
+	for _, idx := range order[:n] {
+		keep[idx] = true
+	}
+
+	capped := EventExtractResult{Events: make([]ExtractedEvent, 0, n)}
+	remap := make(map[int]int, n)
+	for i, ev := range result.Events {
+		if keep[i] {
+			remap[i] = len(capped.Events)
+			capped.Events = append(capped.Events, ev)
+		}
+	}
+
+	for _, rel := range result.Relations {
+		from, okFrom := remap[rel.FromIndex]
+		to, okTo := remap[rel.ToIndex]
+		if !okFrom || !okTo {
+			continue
+		}
+		rel.FromIndex, rel.ToIndex = from, to
+		capped.Relations = append(capped.Relations, rel)
+	}
+
+	return capped
+}
+
 // storeEventToVector 存储事件到 OpenSearch（向量检索）
 func (a *EventExtractionAction) storeEventToVector(c *domain.AddContext, e domain.EventTriplet) error {
 	if a.vectorStore == nil {
@@ -169,29 +268,59 @@ func (a *EventExtractionAction) storeEventToVector(c *domain.AddContext, e domai
 		"type":             domain.DocTypeEvent,
 		"agent_id":         e.AgentID,
 		"user_id":          e.UserID,
+		"session_id":       e.SessionID,
 		"trigger_word":     e.TriggerWord,
 		"argument1":        e.Argument1,
 		"argument2":        e.Argument2,
 		"embedding":        e.TriggerEmbedding, // 使用 embedding 字段与 k-NN 查询一致
 		"access_count":     e.AccessCount,
 		"last_accessed_at": e.LastAccessedAt,
+		"occurred_at":      e.OccurredAt,
 		"created_at":       e.CreatedAt,
 	}
+	if e.ValidAt != nil {
+		doc["valid_at"] = *e.ValidAt
+	}
+	if e.InvalidAt != nil {
+		doc["invalid_at"] = *e.InvalidAt
+	}
 
 	return a.vectorStore.Store(c.Context, e.ID, doc)
 }
 
-// storeRelation 存储事件关系到 PostgreSQL
-func (a *EventExtractionAction) storeRelation(c *domain.AddContext, rel domain.EventRelation) error {
+// eventTimeLayouts LLM 输出的事件时间格式，精度从高到低
+var eventTimeLayouts = []string{time.RFC3339, time.DateOnly, "2006-01", "2006"}
+
+// parseEventTime 解析 LLM 输出的事件时间，只给出年份或年月时取该区间的起点；无法解析返回 nil
+func parseEventTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// storeRelations 批量存储事件关系到 PostgreSQL
+func (a *EventExtractionAction) storeRelations(c *domain.AddContext, rels []domain.EventRelation) error {
 	if a.relationStore == nil {
 		return nil
 	}
 
-	return a.relationStore.CreateRelation(c.Context, relation.Relation{
-		ID:           rel.ID,
-		FromEventID:  rel.FromEventID,
-		ToEventID:    rel.ToEventID,
-		RelationType: rel.RelationType,
-		CreatedAt:    rel.CreatedAt,
-	})
+	records := make([]relation.Relation, len(rels))
+	for i, rel := range rels {
+		records[i] = relation.Relation{
+			ID:           rel.ID,
+			FromEventID:  rel.FromEventID,
+			ToEventID:    rel.ToEventID,
+			RelationType: rel.RelationType,
+			CreatedAt:    rel.CreatedAt,
+		}
+	}
+
+	return a.relationStore.CreateRelations(c.Context, records)
 }
@@ -0,0 +1,197 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+)
+
+func newEventAddContext(ctx context.Context) *domain.AddContext {
+	c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+	c.Messages = domain.Messages{
+		{Role: domain.RoleUser, Name: "小明", Content: "我今天先去了星巴克，然后喝了咖啡"},
+	}
+	return c
+}
+
+func TestEventExtraction_DualWriteMode(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(EventExtractResult{
+		Events: []ExtractedEvent{
+			{TriggerWord: "去了", Argument1: "小明", Argument2: "星巴克"},
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡"},
+		},
+		Relations: []ExtractedRelation{
+			{FromIndex: 0, ToIndex: 1, RelationType: domain.RelationTemporal},
+		},
+	})
+
+	// 第二个事件写入 OpenSearch 失败
+	newStores := func() (*MockVectorStore, *MockRelationStore) {
+		vectorStore := NewMockVectorStore()
+		vectorStore.StoreFunc = func(ctx context.Context, id string, doc map[string]any) error {
+			if doc["trigger_word"] == "喝" {
+				return errors.New("opensearch unavailable")
+			}
+			return nil
+		}
+		return vectorStore, NewMockRelationStore()
+	}
+
+	t.Run("best effort keeps event and relation", func(t *testing.T) {
+		vectorStore, relationStore := newStores()
+		c := newEventAddContext(ctx)
+
+		helper.NewEventExtractionAction().
+			WithStores(vectorStore, relationStore).
+			WithConfig(EventConfig{DualWriteMode: DualWriteBestEffort}).
+			Handle(c)
+
+		assert.Len(t, c.Events, 2)
+		assert.Len(t, c.EventRelations, 1)
+		assert.Len(t, relationStore.CreateRelationsCalls, 1)
+	})
+
+	t.Run("strict drops failed event and its relations", func(t *testing.T) {
+		vectorStore, relationStore := newStores()
+		c := newEventAddContext(ctx)
+
+		helper.NewEventExtractionAction().
+			WithStores(vectorStore, relationStore).
+			WithConfig(EventConfig{DualWriteMode: DualWriteStrict}).
+			Handle(c)
+
+		require.Len(t, c.Events, 1)
+		assert.Equal(t, "星巴克", c.Events[0].Argument2)
+		assert.Empty(t, c.EventRelations)
+		assert.Empty(t, relationStore.CreateRelationsCalls)
+	})
+}
+
+func TestEventExtraction_ValidAt(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(EventExtractResult{
+		Events: []ExtractedEvent{
+			{TriggerWord: "工作", Argument1: "小明", Argument2: "这家公司", ValidAt: "2020"},
+			{TriggerWord: "住在", Argument1: "小明", Argument2: "杭州", ValidAt: "2021-03", InvalidAt: "2024-06-30"},
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡", ValidAt: "不久前"},
+		},
+	})
+
+	vectorStore := NewMockVectorStore()
+	c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+	c.Messages = domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "我从 2020 年开始在这家公司工作"}}
+
+	helper.NewEventExtractionAction().WithStores(vectorStore, NewMockRelationStore()).Handle(c)
+	require.Len(t, c.Events, 3)
+
+	t.Run("since 2020 sets valid_at", func(t *testing.T) {
+		require.NotNil(t, c.Events[0].ValidAt)
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), *c.Events[0].ValidAt)
+		assert.Nil(t, c.Events[0].InvalidAt)
+		assert.Equal(t, *c.Events[0].ValidAt, vectorStore.StoreCalls[0].Doc["valid_at"])
+	})
+
+	t.Run("month and day precision", func(t *testing.T) {
+		assert.Equal(t, time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), *c.Events[1].ValidAt)
+		assert.Equal(t, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), *c.Events[1].InvalidAt)
+	})
+
+	t.Run("unparseable time is dropped", func(t *testing.T) {
+		assert.Nil(t, c.Events[2].ValidAt)
+		assert.NotContains(t, vectorStore.StoreCalls[2].Doc, "valid_at")
+	})
+}
+
+func TestEventExtraction_BulkRelations(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(EventExtractResult{
+		Events: []ExtractedEvent{
+			{TriggerWord: "失眠了", Argument1: "小明", Argument2: "昨晚"},
+			{TriggerWord: "去了", Argument1: "小明", Argument2: "星巴克"},
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡"},
+		},
+		Relations: []ExtractedRelation{
+			{FromIndex: 0, ToIndex: 2, RelationType: domain.RelationCausal},
+			{FromIndex: 1, ToIndex: 2, RelationType: domain.RelationTemporal},
+			{FromIndex: 1, ToIndex: 5, RelationType: domain.RelationTemporal}, // 越界，忽略
+			{FromIndex: 0, ToIndex: 2, RelationType: domain.RelationCausal},   // 批内重复，只保留一条
+		},
+	})
+
+	t.Run("single batch for all relations", func(t *testing.T) {
+		relationStore := NewMockRelationStore()
+		c := newEventAddContext(ctx)
+
+		helper.NewEventExtractionAction().WithStores(NewMockVectorStore(), relationStore).Handle(c)
+
+		assert.Empty(t, relationStore.CreateRelationCalls)
+		require.Len(t, relationStore.CreateRelationsCalls, 1)
+		require.Len(t, relationStore.CreateRelationsCalls[0], 2)
+		require.Len(t, c.EventRelations, 2)
+		for i, rel := range relationStore.CreateRelationsCalls[0] {
+			assert.Equal(t, rel.ID, c.EventRelations[i].ID, "response lists only persisted relations")
+		}
+	})
+
+	t.Run("batch failure keeps events", func(t *testing.T) {
+		relationStore := NewMockRelationStore()
+		relationStore.CreateRelationsFunc = func(ctx context.Context, rels []relation.Relation) error {
+			return errors.New("postgres unavailable")
+		}
+		c := newEventAddContext(ctx)
+
+		helper.NewEventExtractionAction().WithStores(NewMockVectorStore(), relationStore).Handle(c)
+
+		assert.Len(t, c.Events, 3)
+		assert.Empty(t, c.EventRelations)
+	})
+}
+
+func TestEventExtraction_MaxEvents(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(EventExtractResult{
+		Events: []ExtractedEvent{
+			{TriggerWord: "失眠了", Argument1: "小明", Argument2: "昨晚", Confidence: 0.4},
+			{TriggerWord: "去了", Argument1: "小明", Argument2: "星巴克", Confidence: 0.9},
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡", Confidence: 0.8},
+			{TriggerWord: "看了", Argument1: "小明", Argument2: "手机", Confidence: 0.2},
+		},
+		Relations: []ExtractedRelation{
+			{FromIndex: 0, ToIndex: 2, RelationType: domain.RelationCausal}, // 引用被丢弃的事件
+			{FromIndex: 1, ToIndex: 2, RelationType: domain.RelationTemporal},
+		},
+	})
+
+	vectorStore, relationStore := NewMockVectorStore(), NewMockRelationStore()
+	c := newEventAddContext(ctx)
+
+	helper.NewEventExtractionAction().
+		WithStores(vectorStore, relationStore).
+		WithConfig(EventConfig{DualWriteMode: DualWriteBestEffort, MaxEvents: 2}).
+		Handle(c)
+
+	require.Len(t, c.Events, 2)
+	assert.Equal(t, "星巴克", c.Events[0].Argument2)
+	assert.Equal(t, "咖啡", c.Events[1].Argument2)
+	assert.Len(t, vectorStore.StoreCalls, 2)
+
+	require.Len(t, c.EventRelations, 1)
+	assert.Equal(t, c.Events[0].ID, c.EventRelations[0].FromEventID)
+	assert.Equal(t, c.Events[1].ID, c.EventRelations[0].ToEventID)
+}
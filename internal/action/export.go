@@ -0,0 +1,120 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// ExportBatchSize 导出时每批读取的文档数
+const ExportBatchSize = 100
+
+// ExportAction 以 NDJSON 流式导出 agent+user 下的全部数据，用于数据可携带与备份
+// 文档含向量与归档状态，可原样导入恢复
+type ExportAction struct {
+	*BaseAction
+
+	vectorStore   vector.Store
+	relationStore relation.Store
+	batchSize     int
+}
+
+// NewExportAction 创建 ExportAction
+func NewExportAction() *ExportAction {
+	return &ExportAction{
+		BaseAction:    NewBaseAction("export"),
+		vectorStore:   vector.NewStore(),
+		relationStore: relation.NewStore(),
+		batchSize:     ExportBatchSize,
+	}
+}
+
+// WithStores 设置存储（用于测试注入 mock）
+func (a *ExportAction) WithStores(v vector.Store, r relation.Store) *ExportAction {
+	a.vectorStore = v
+	a.relationStore = r
+	return a
+}
+
+// WithBatchSize 设置每批读取的文档数
+func (a *ExportAction) WithBatchSize(size int) *ExportAction {
+	if size > 0 {
+		a.batchSize = size
+	}
+	return a
+}
+
+// Execute 按 id 分页（search_after）读取全部文档逐行写出，每个事件之后写出其尚未导出的关系
+// 任一存储读取失败即中止，已写出的行不回滚
+func (a *ExportAction) Execute(ctx context.Context, agentID, userID string, w io.Writer) (*domain.ExportResponse, error) {
+	a.logger.Info("executing export", "agent_id", agentID, "user_id", userID)
+
+	scanner, ok := a.vectorStore.(vector.Scanner)
+	if !ok {
+		return nil, fmt.Errorf("%w: vector store does not support export", domain.ErrInvalidInput)
+	}
+
+	resp := &domain.ExportResponse{}
+	enc := json.NewEncoder(w)
+	filters := map[string]any{"agent_id": agentID, "user_id": userID}
+	seenRelations := make(map[string]bool)
+
+	after := ""
+	for {
+		docs, err := scanner.Scan(ctx, filters, after, a.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: export documents: %w", domain.ErrStoreUnavailable, err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		for _, doc := range docs {
+			if err := enc.Encode(domain.ExportRecord{Kind: domain.ExportKindDocument, Document: doc}); err != nil {
+				return nil, err
+			}
+			resp.Documents++
+
+			if doc["type"] != domain.DocTypeEvent || a.relationStore == nil {
+				continue
+			}
+
+			id, _ := doc["id"].(string)
+			rels, err := a.relationStore.FindByEventID(ctx, id)
+			if err != nil {
+				return nil, fmt.Errorf("%w: export relations of %s: %w", domain.ErrStoreUnavailable, id, err)
+			}
+			for _, rel := range rels {
+				if seenRelations[rel.ID] {
+					continue
+				}
+				seenRelations[rel.ID] = true
+
+				record := domain.ExportRecord{
+					Kind: domain.ExportKindRelation,
+					Relation: &domain.EventRelation{
+						ID:           rel.ID,
+						RelationType: rel.RelationType,
+						FromEventID:  rel.FromEventID,
+						ToEventID:    rel.ToEventID,
+						CreatedAt:    rel.CreatedAt,
+					},
+				}
+				if err := enc.Encode(record); err != nil {
+					return nil, err
+				}
+				resp.Relations++
+			}
+		}
+		after, _ = docs[len(docs)-1]["id"].(string)
+	}
+
+	a.logger.Info("export completed", "documents", resp.Documents, "relations", resp.Relations)
+
+	return resp, nil
+}
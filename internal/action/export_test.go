@@ -0,0 +1,116 @@
+package action
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// newExportStores 返回按 id 分页扫描 docs 的向量存储，以及按事件 id 返回 rels 的关系存储
+func newExportStores(docs []map[string]any, rels []relation.Relation) (*MockVectorStore, *MockRelationStore) {
+	vectorStore := NewMockVectorStore()
+	vectorStore.ScanFunc = func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+		var page []map[string]any
+		for _, doc := range docs {
+			if doc["agent_id"] == filters["agent_id"] && doc["user_id"] == filters["user_id"] &&
+				doc["id"].(string) > after && len(page) < size {
+				page = append(page, doc)
+			}
+		}
+		return page, nil
+	}
+
+	relationStore := NewMockRelationStore()
+	relationStore.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+		var found []relation.Relation
+		for _, rel := range rels {
+			if rel.FromEventID == eventID || rel.ToEventID == eventID {
+				found = append(found, rel)
+			}
+		}
+		return found, nil
+	}
+
+	return vectorStore, relationStore
+}
+
+// readExport 解析 NDJSON 导出
+func readExport(t *testing.T, data []byte) []domain.ExportRecord {
+	t.Helper()
+
+	var records []domain.ExportRecord
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record domain.ExportRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestMemory_Export(t *testing.T) {
+	ctx := context.Background()
+
+	docs := []map[string]any{
+		{"id": "evt_1", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1", "trigger_word": "去了", "embedding": []float32{0.1}},
+		{"id": "evt_2", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1", "trigger_word": "喝", "embedding": []float32{0.2}},
+		{"id": "mem_1", "type": domain.DocTypeSummary, "agent_id": "agent_1", "user_id": "user_1", "content": "用户住在北京", "status": vector.StatusArchived},
+		{"id": "mem_2", "type": domain.DocTypeSummary, "agent_id": "agent_1", "user_id": "user_2", "content": "其他用户"},
+	}
+	rels := []relation.Relation{
+		{ID: "rel_1", FromEventID: "evt_1", ToEventID: "evt_2", RelationType: domain.RelationTemporal},
+	}
+
+	t.Run("includes documents and relations from each store", func(t *testing.T) {
+		vectorStore, relationStore := newExportStores(docs, rels)
+
+		var buf bytes.Buffer
+		resp, err := NewExportAction().WithStores(vectorStore, relationStore).WithBatchSize(2).Execute(ctx, "agent_1", "user_1", &buf)
+		require.NoError(t, err)
+		assert.Equal(t, 3, resp.Documents)
+		assert.Equal(t, 1, resp.Relations) // 两端事件共享的关系只导出一次
+
+		records := readExport(t, buf.Bytes())
+		require.Len(t, records, 4)
+
+		var ids []string
+		for _, record := range records {
+			switch record.Kind {
+			case domain.ExportKindDocument:
+				ids = append(ids, record.Document["id"].(string))
+			case domain.ExportKindRelation:
+				assert.Equal(t, "rel_1", record.Relation.ID)
+				assert.Equal(t, domain.RelationTemporal, record.Relation.RelationType)
+			}
+		}
+		assert.Equal(t, []string{"evt_1", "evt_2", "mem_1"}, ids)
+		assert.Equal(t, []any{0.1}, records[0].Document["embedding"])
+	})
+
+	t.Run("store failure reports store unavailable", func(t *testing.T) {
+		vectorStore, _ := newExportStores(docs, rels)
+		relationStore := NewMockRelationStore()
+		relationStore.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+			return nil, errors.New("postgres unavailable")
+		}
+
+		var buf bytes.Buffer
+		_, err := NewMemory().WithStores(vectorStore, relationStore).Export(ctx, "agent_1", "user_1", &buf)
+		assert.ErrorIs(t, err, domain.ErrStoreUnavailable)
+	})
+
+	t.Run("requires agent and user", func(t *testing.T) {
+		_, err := NewMemory().Export(ctx, "agent_1", "", &bytes.Buffer{})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
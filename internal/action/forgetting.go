@@ -2,6 +2,8 @@ package action
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math"
 	"time"
@@ -52,26 +54,31 @@ func (a *ForgettingAction) Execute(ctx context.Context, agentID, userID string)
 	resp := &domain.ForgetResponse{Success: true}
 
 	// 1. 遗忘工作记忆
-	workingForgot, err := a.forgetWorkingMemories(ctx, agentID, userID)
-	if err != nil {
-		a.logger.Warn("failed to forget working memories", "error", err)
+	workingForgot, workingErr := a.forgetWorkingMemories(ctx, agentID, userID)
+	if workingErr != nil {
+		a.logger.Warn("failed to forget working memories", "error", workingErr)
 	}
 	resp.WorkingForgot = workingForgot
 
 	// 2. 遗忘事件图谱
-	eventsForgot, err := a.forgetEvents(ctx, agentID, userID)
-	if err != nil {
-		a.logger.Warn("failed to forget events", "error", err)
+	eventsForgot, eventsErr := a.forgetEvents(ctx, agentID, userID)
+	if eventsErr != nil {
+		a.logger.Warn("failed to forget events", "error", eventsErr)
 	}
 	resp.EventsForgot = eventsForgot
 
 	// 3. 过期事实记忆（3 个月 ILM）
-	factsExpired, err := a.expireFactMemories(ctx, agentID, userID)
-	if err != nil {
-		a.logger.Warn("failed to expire fact memories", "error", err)
+	factsExpired, factsErr := a.expireFactMemories(ctx, agentID, userID)
+	if factsErr != nil {
+		a.logger.Warn("failed to expire fact memories", "error", factsErr)
 	}
 	resp.FactsExpired = factsExpired
 
+	// 三个步骤均失败，视为存储不可用
+	if workingErr != nil && eventsErr != nil && factsErr != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrStoreUnavailable, errors.Join(workingErr, eventsErr, factsErr))
+	}
+
 	a.logger.Info("forgetting completed",
 		"working_forgot", workingForgot,
 		"events_forgot", eventsForgot,
@@ -106,12 +113,6 @@ func (a *ForgettingAction) forgetWorkingMemories(ctx context.Context, agentID, u
 	forgot := 0
 	now := time.Now()
 
-	// 需要类型断言来使用 Delete 方法
-	type deleter interface {
-		Delete(ctx context.Context, id string) error
-	}
-	del, canDelete := a.vectorStore.(deleter)
-
 	for _, doc := range docs {
 		s := base.DocToSummaryMemory(doc)
 
@@ -122,11 +123,9 @@ func (a *ForgettingAction) forgetWorkingMemories(ctx context.Context, agentID, u
 
 		score := a.calcWorkingForgetScore(s, now)
 		if score > ForgetThreshold {
-			if canDelete {
-				if err := del.Delete(ctx, s.ID); err != nil {
-					a.logger.Warn("failed to delete working memory", "id", s.ID, "error", err)
-					continue
-				}
+			if err := a.vectorStore.Delete(ctx, s.ID); err != nil {
+				a.logger.Warn("failed to delete working memory", "id", s.ID, "error", err)
+				continue
 			}
 			forgot++
 		}
@@ -177,21 +176,14 @@ func (a *ForgettingAction) forgetEvents(ctx context.Context, agentID, userID str
 	forgot := 0
 	now := time.Now()
 
-	type deleter interface {
-		Delete(ctx context.Context, id string) error
-	}
-	del, canDelete := a.vectorStore.(deleter)
-
 	for _, doc := range docs {
 		e := base.DocToEventTriplet(doc)
 
 		score := a.calcEventForgetScore(e, now)
 		if score > ForgetThreshold {
 			// 从 OpenSearch 删除
-			if canDelete {
-				if err := del.Delete(ctx, e.ID); err != nil {
-					a.logger.Warn("failed to delete event from vector", "id", e.ID, "error", err)
-				}
+			if err := a.vectorStore.Delete(ctx, e.ID); err != nil {
+				a.logger.Warn("failed to delete event from vector", "id", e.ID, "error", err)
 			}
 
 			// 从 PostgreSQL 删除关联的关系
@@ -248,11 +240,6 @@ func (a *ForgettingAction) expireFactMemories(ctx context.Context, agentID, user
 	base := NewBaseAction("forgetting")
 	expired := 0
 
-	type deleter interface {
-		Delete(ctx context.Context, id string) error
-	}
-	del, canDelete := a.vectorStore.(deleter)
-
 	for _, doc := range docs {
 		s := base.DocToSummaryMemory(doc)
 
@@ -261,11 +248,9 @@ func (a *ForgettingAction) expireFactMemories(ctx context.Context, agentID, user
 			continue
 		}
 
-		if canDelete {
-			if err := del.Delete(ctx, s.ID); err != nil {
-				a.logger.Warn("failed to delete expired fact", "id", s.ID, "error", err)
-				continue
-			}
+		if err := a.vectorStore.Delete(ctx, s.ID); err != nil {
+			a.logger.Warn("failed to delete expired fact", "id", s.ID, "error", err)
+			continue
 		}
 		expired++
 	}
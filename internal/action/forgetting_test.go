@@ -0,0 +1,48 @@
+package action
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestForgetting_DeletesThroughStore(t *testing.T) {
+	ctx := context.Background()
+	stale := time.Now().AddDate(0, 0, -60).Format(time.RFC3339)
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		switch {
+		case query.Filters["type"] == domain.DocTypeEvent:
+			return []map[string]any{
+				{"id": "evt_stale", "trigger_word": "去了", "last_accessed_at": stale},
+			}, nil
+		case query.Filters["memory_type"] == domain.MemoryTypeWorking:
+			return []map[string]any{
+				{"id": "working_stale", "importance": 0.1, "last_accessed_at": stale},
+				{"id": "working_protected", "importance": 0.1, "last_accessed_at": stale, "is_protected": true},
+			}, nil
+		case query.Filters["memory_type"] == domain.MemoryTypeFact:
+			return []map[string]any{
+				{"id": "fact_expired", "created_at": stale},
+			}, nil
+		}
+		return nil, nil
+	}
+	relationStore := NewMockRelationStore()
+
+	resp, err := NewForgettingAction().WithStores(store, relationStore).Execute(ctx, "agent_1", "user_1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, resp.WorkingForgot)
+	assert.Equal(t, 1, resp.EventsForgot)
+	assert.Equal(t, 1, resp.FactsExpired)
+	assert.ElementsMatch(t, []string{"working_stale", "evt_stale", "fact_expired"}, store.DeleteCalls)
+	assert.Equal(t, []string{"evt_stale"}, relationStore.DeleteByEventIDCalls)
+}
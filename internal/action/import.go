@@ -0,0 +1,210 @@
+package action
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// ImportMaxLineBytes 导入时单行记录的上限（含向量的文档）
+const ImportMaxLineBytes = 16 << 20
+
+// embeddingDimensioner 由能报告索引向量维度的存储实现
+type embeddingDimensioner interface {
+	EmbeddingDim() int
+}
+
+// ImportAction 导入 ExportAction 产生的 NDJSON，按原 ID 与向量直接写入存储，不经过 LLM 提取
+type ImportAction struct {
+	*BaseAction
+
+	vectorStore   vector.Store
+	relationStore relation.Store
+	embeddingDim  int // 0 表示不校验
+}
+
+// NewImportAction 创建 ImportAction
+func NewImportAction() *ImportAction {
+	a := &ImportAction{BaseAction: NewBaseAction("import")}
+	return a.WithStores(vector.NewStore(), relation.NewStore())
+}
+
+// WithStores 设置存储（用于测试注入 mock），向量维度取自存储
+func (a *ImportAction) WithStores(v vector.Store, r relation.Store) *ImportAction {
+	a.vectorStore = v
+	a.relationStore = r
+	if d, ok := v.(embeddingDimensioner); ok {
+		a.embeddingDim = d.EmbeddingDim()
+	}
+	return a
+}
+
+// Execute 先解析并校验全部记录，全部通过后再写入，避免维度不符时留下半份数据
+// 文档的 agent_id/user_id 改写为目标作用域；关系两端须为本次导入的事件；已被其他 agent/user 占用的 ID 拒绝导入
+func (a *ImportAction) Execute(ctx context.Context, agentID, userID string, r io.Reader) (*domain.ImportResponse, error) {
+	a.logger.Info("executing import", "agent_id", agentID, "user_id", userID)
+
+	docs, rels, err := a.parse(r, agentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &domain.ImportResponse{}
+	if a.vectorStore != nil {
+		if err := a.checkOwnership(ctx, docs, agentID, userID); err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if err := a.vectorStore.Store(ctx, doc["id"].(string), doc); err != nil {
+				return nil, fmt.Errorf("%w: import document %v: %w", domain.ErrStoreUnavailable, doc["id"], err)
+			}
+			resp.Documents++
+		}
+	}
+
+	if a.relationStore != nil && len(rels) > 0 {
+		if err := a.relationStore.CreateRelations(ctx, rels); err != nil {
+			return nil, fmt.Errorf("%w: import relations: %w", domain.ErrStoreUnavailable, err)
+		}
+		resp.Relations = len(rels)
+	}
+
+	a.logger.Info("import completed", "documents", resp.Documents, "relations", resp.Relations)
+
+	resp.Success = true
+	return resp, nil
+}
+
+// checkOwnership 文档按原 ID 写入未分区的存储，ID 已属于其他 agent/user 时拒绝，避免覆盖其他租户的记忆
+// 存储不支持批量读取时无法校验，同样拒绝
+func (a *ImportAction) checkOwnership(ctx context.Context, docs []map[string]any, agentID, userID string) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	getter, ok := a.vectorStore.(vector.MultiGetter)
+	if !ok {
+		return fmt.Errorf("%w: vector store does not support import", domain.ErrInvalidInput)
+	}
+
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc["id"].(string))
+	}
+	existing, err := getter.MGet(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("%w: check existing documents: %w", domain.ErrStoreUnavailable, err)
+	}
+
+	for id, doc := range existing {
+		if doc["agent_id"] != agentID || doc["user_id"] != userID {
+			return fmt.Errorf("%w: document %s belongs to another agent or user", domain.ErrInvalidInput, id)
+		}
+	}
+	return nil
+}
+
+// parse 读取 NDJSON 记录，任一行非法返回 ErrInvalidInput
+func (a *ImportAction) parse(r io.Reader, agentID, userID string) ([]map[string]any, []relation.Relation, error) {
+	var docs []map[string]any
+	var rels []relation.Relation
+	events := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), ImportMaxLineBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record domain.ExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, nil, fmt.Errorf("%w: line %d: %w", domain.ErrInvalidInput, line, err)
+		}
+
+		switch record.Kind {
+		case domain.ExportKindDocument:
+			doc, err := a.parseDocument(record.Document, agentID, userID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: line %d: %w", domain.ErrInvalidInput, line, err)
+			}
+			if doc["type"] == domain.DocTypeEvent {
+				events[doc["id"].(string)] = true
+			}
+			docs = append(docs, doc)
+		case domain.ExportKindRelation:
+			if record.Relation == nil || record.Relation.ID == "" {
+				return nil, nil, fmt.Errorf("%w: line %d: relation has no id", domain.ErrInvalidInput, line)
+			}
+			rels = append(rels, relation.Relation{
+				ID:           record.Relation.ID,
+				FromEventID:  record.Relation.FromEventID,
+				ToEventID:    record.Relation.ToEventID,
+				RelationType: record.Relation.RelationType,
+				CreatedAt:    record.Relation.CreatedAt,
+			})
+		default:
+			return nil, nil, fmt.Errorf("%w: line %d: unknown record kind %q", domain.ErrInvalidInput, line, record.Kind)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", domain.ErrInvalidInput, err)
+	}
+
+	for i, rel := range rels {
+		if !events[rel.FromEventID] || !events[rel.ToEventID] {
+			return nil, nil, fmt.Errorf("%w: relation %s references an event outside the import", domain.ErrInvalidInput, rel.ID)
+		}
+		if rel.CreatedAt.IsZero() {
+			rels[i].CreatedAt = time.Now()
+		}
+	}
+
+	return docs, rels, nil
+}
+
+// parseDocument 校验文档并把 JSON 数组形式的向量字段转换为 []float32
+func (a *ImportAction) parseDocument(doc map[string]any, agentID, userID string) (map[string]any, error) {
+	if doc == nil {
+		return nil, fmt.Errorf("document is empty")
+	}
+	id, _ := doc["id"].(string)
+	if id == "" {
+		return nil, fmt.Errorf("document has no id")
+	}
+	if doc["type"] != domain.DocTypeSummary && doc["type"] != domain.DocTypeEvent && doc["type"] != domain.DocTypeConflict {
+		return nil, fmt.Errorf("document %s has unknown type %v", id, doc["type"])
+	}
+
+	doc["agent_id"] = agentID
+	doc["user_id"] = userID
+
+	for _, field := range []string{"embedding", "topic_embedding"} {
+		raw, ok := doc[field].([]any)
+		if !ok {
+			delete(doc, field)
+			continue
+		}
+
+		embedding := make([]float32, len(raw))
+		for i, v := range raw {
+			f, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("document %s has a non-numeric %s", id, field)
+			}
+			embedding[i] = float32(f)
+		}
+		if a.embeddingDim > 0 && len(embedding) != a.embeddingDim {
+			return nil, fmt.Errorf("document %s %s has %d dimensions, index expects %d", id, field, len(embedding), a.embeddingDim)
+		}
+		doc[field] = embedding
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,209 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// memoryVectorStore 保存写入文档的内存向量存储，搜索按等值与缺失字段过滤返回，字段更新直接修改文档
+type memoryVectorStore struct {
+	*MockVectorStore
+
+	mu   sync.Mutex
+	docs map[string]map[string]any
+	dim  int
+}
+
+func newMemoryVectorStore(dim int) *memoryVectorStore {
+	s := &memoryVectorStore{MockVectorStore: NewMockVectorStore(), docs: map[string]map[string]any{}, dim: dim}
+	s.StoreFunc = func(ctx context.Context, id string, doc map[string]any) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.docs[id] = copyDoc(doc)
+		return nil
+	}
+	s.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		var found []map[string]any
+	docs:
+		for _, doc := range s.sorted(query.Filters, "") {
+			if ids, ok := query.TermsFilters["id"]; ok && !containsString(ids, doc["id"].(string)) {
+				continue
+			}
+			for _, field := range query.MissingFields {
+				if doc[field] != nil {
+					continue docs
+				}
+			}
+			hit := map[string]any{"_score": 0.9}
+			for k, v := range doc {
+				hit[k] = v
+			}
+			found = append(found, hit)
+		}
+		return found, nil
+	}
+	s.UpdateFieldsFunc = func(ctx context.Context, id string, fields map[string]any) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if doc, ok := s.docs[id]; ok {
+			for k, v := range fields {
+				doc[k] = v
+			}
+		}
+		return nil
+	}
+	s.ScanFunc = func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+		page := s.sorted(filters, after)
+		return page[:min(size, len(page))], nil
+	}
+	return s
+}
+
+func (s *memoryVectorStore) EmbeddingDim() int { return s.dim }
+
+func (s *memoryVectorStore) MGet(ctx context.Context, ids []string) (map[string]map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := make(map[string]map[string]any, len(ids))
+	for _, id := range ids {
+		if doc, ok := s.docs[id]; ok {
+			found[id] = copyDoc(doc)
+		}
+	}
+	return found, nil
+}
+
+// sorted 返回匹配 filters 且 id 大于 after 的文档副本，按 id 排序；副本在持锁时复制，避免与异步字段更新竞争
+func (s *memoryVectorStore) sorted(filters map[string]any, after string) []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var docs []map[string]any
+	for id, doc := range s.docs {
+		if id <= after {
+			continue
+		}
+		match := true
+		for k, v := range filters {
+			if doc[k] != v {
+				match = false
+			}
+		}
+		if match {
+			docs = append(docs, copyDoc(doc))
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i]["id"].(string) < docs[j]["id"].(string) })
+	return docs
+}
+
+func copyDoc(doc map[string]any) map[string]any {
+	c := make(map[string]any, len(doc))
+	for k, v := range doc {
+		c[k] = v
+	}
+	return c
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMemory_ImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	source := newMemoryVectorStore(3)
+	for _, doc := range []map[string]any{
+		{"id": "mem_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "content": "用户住在北京", "embedding": []float32{0.1, 0.2, 0.3}},
+		{"id": "evt_1", "type": domain.DocTypeEvent, "trigger_word": "去了", "argument1": "小明", "argument2": "星巴克", "embedding": []float32{0.3, 0.2, 0.1}},
+		{"id": "evt_2", "type": domain.DocTypeEvent, "trigger_word": "喝", "argument1": "小明", "argument2": "咖啡", "embedding": []float32{0.2, 0.2, 0.2}},
+	} {
+		doc["agent_id"], doc["user_id"] = "agent_1", "user_1"
+		require.NoError(t, source.Store(ctx, doc["id"].(string), doc))
+	}
+	sourceRelations := NewMockRelationStore()
+	sourceRelations.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+		return []relation.Relation{{ID: "rel_1", FromEventID: "evt_1", ToEventID: "evt_2", RelationType: domain.RelationTemporal}}, nil
+	}
+
+	var exported bytes.Buffer
+	_, err := NewMemory().WithStores(source, sourceRelations).Export(ctx, "agent_1", "user_1", &exported)
+	require.NoError(t, err)
+
+	t.Run("restored data is retrievable", func(t *testing.T) {
+		target, targetRelations := newMemoryVectorStore(3), NewMockRelationStore()
+		memory := NewMemory().WithStores(target, targetRelations)
+
+		resp, err := memory.Import(ctx, "agent_1", "user_1", bytes.NewReader(exported.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 3, resp.Documents)
+		assert.Equal(t, 1, resp.Relations)
+
+		require.Len(t, targetRelations.CreateRelationsCalls, 1)
+		assert.Equal(t, "rel_1", targetRelations.CreateRelationsCalls[0][0].ID)
+		assert.Equal(t, []float32{0.1, 0.2, 0.3}, target.docs["mem_1"]["embedding"])
+
+		retrieved, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里"})
+		require.NoError(t, err)
+		require.Len(t, retrieved.Facts, 1)
+		assert.Equal(t, "mem_1", retrieved.Facts[0].ID)
+		assert.Equal(t, "用户住在北京", retrieved.Facts[0].Content)
+		assert.NotEmpty(t, retrieved.Events)
+	})
+
+	t.Run("dimension mismatch writes nothing", func(t *testing.T) {
+		target := newMemoryVectorStore(4)
+
+		_, err := NewMemory().WithStores(target, NewMockRelationStore()).Import(ctx, "agent_1", "user_1", bytes.NewReader(exported.Bytes()))
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		assert.Contains(t, err.Error(), "index expects 4")
+		assert.Empty(t, target.StoreCalls)
+	})
+
+	t.Run("id owned by another tenant is rejected", func(t *testing.T) {
+		target := newMemoryVectorStore(3)
+		target.docs["mem_1"] = map[string]any{"id": "mem_1", "agent_id": "agent_2", "user_id": "user_9", "content": "其他租户的记忆"}
+
+		_, err := NewMemory().WithStores(target, NewMockRelationStore()).Import(ctx, "agent_1", "user_1", bytes.NewReader(exported.Bytes()))
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		assert.Empty(t, target.StoreCalls)
+		assert.Equal(t, "其他租户的记忆", target.docs["mem_1"]["content"])
+	})
+
+	t.Run("reimport into the same scope overwrites", func(t *testing.T) {
+		target := newMemoryVectorStore(3)
+		memory := NewMemory().WithStores(target, NewMockRelationStore())
+
+		_, err := memory.Import(ctx, "agent_1", "user_1", bytes.NewReader(exported.Bytes()))
+		require.NoError(t, err)
+		resp, err := memory.Import(ctx, "agent_1", "user_1", bytes.NewReader(exported.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 3, resp.Documents)
+	})
+
+	t.Run("relation outside the import is rejected", func(t *testing.T) {
+		input := `{"kind":"relation","relation":{"id":"rel_9","from_event_id":"evt_8","to_event_id":"evt_9"}}`
+
+		_, err := NewMemory().WithStores(newMemoryVectorStore(3), NewMockRelationStore()).Import(ctx, "agent_1", "user_1", strings.NewReader(input))
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
@@ -0,0 +1,53 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+// Limiter 限制进程内同时进行的 LLM/embedding 调用数，避免突发请求触发供应商限流
+type Limiter struct {
+	slots chan struct{}
+}
+
+// NewLimiter 创建并发上限为 n 的 Limiter，n <= 0 返回 nil（不限制）
+func NewLimiter(n int) *Limiter {
+	if n <= 0 {
+		return nil
+	}
+	return &Limiter{slots: make(chan struct{}, n)}
+}
+
+// Acquire 等待一个空闲名额，ctx 取消时返回 ErrLLMUnavailable
+// nil Limiter 总是立即放行
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: waiting for llm slot: %w", domain.ErrLLMUnavailable, ctx.Err())
+	}
+}
+
+// Release 归还 Acquire 取得的名额
+func (l *Limiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// llmLimiter 所有 action 共享的 LLM/embedding 并发限制，随 Init 重建
+var llmLimiter atomic.Pointer[Limiter]
+
+// LLMLimiter 返回共享的并发限制，未配置时返回 nil
+func LLMLimiter() *Limiter {
+	return llmLimiter.Load()
+}
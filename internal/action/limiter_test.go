@@ -0,0 +1,75 @@
+package action
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("blocks beyond the limit until release", func(t *testing.T) {
+		l := NewLimiter(1)
+		require.NoError(t, l.Acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, l.Acquire(ctx), domain.ErrLLMUnavailable)
+
+		l.Release()
+		assert.NoError(t, l.Acquire(context.Background()))
+	})
+
+	t.Run("nil limiter always allows", func(t *testing.T) {
+		var l *Limiter
+		assert.Nil(t, NewLimiter(0))
+		assert.NoError(t, l.Acquire(context.Background()))
+		l.Release()
+	})
+}
+
+func TestBaseAction_ConcurrencyLimit(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	cfg := DefaultConfig()
+	cfg.MaxConcurrency = 3
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Init(DefaultConfig()) })
+
+	var inFlight, peak atomic.Int32
+	helper.MockPlugin.SetEmbedderResponse("doubao-embedding-text-240715", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &ai.EmbedResponse{Embeddings: []*ai.Embedding{{Embedding: []float32{0.1, 0.2, 0.3}}}}, nil
+	})
+
+	base := NewBaseAction("test")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := base.GenEmbedding(ctx, EmbedderName, "用户喜欢咖啡")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Equal(t, int32(3), peak.Load())
+}
@@ -0,0 +1,50 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// levelHandler 在全局 handler 之上按 action 单独设置最低日志级别
+type levelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+// Enabled 低于 action 级别的日志直接丢弃，其余交给全局 handler 判断
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// parseLogLevel 解析 debug / info / warn / error（不区分大小写）
+func parseLogLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q, must be debug, info, warn or error", s)
+	}
+	return level, nil
+}
+
+// actionLogger 返回带 module 字段的 logger，配置了 log_levels 的 action 使用单独的最低级别
+func actionLogger(name string) *slog.Logger {
+	logger := slog.Default().With("module", name)
+
+	raw, ok := GetConfig().LogLevels[name]
+	if !ok {
+		return logger
+	}
+	level, err := parseLogLevel(raw)
+	if err != nil {
+		return logger
+	}
+	return slog.New(&levelHandler{Handler: logger.Handler(), level: level})
+}
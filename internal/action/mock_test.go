@@ -2,6 +2,7 @@ package action
 
 import (
 	"context"
+	"sync"
 
 	"github.com/Zereker/memory/pkg/relation"
 	"github.com/Zereker/memory/pkg/vector"
@@ -10,11 +11,22 @@ import (
 // MockVectorStore 用于测试的向量存储 mock
 // 实现 vector.Store 接口
 type MockVectorStore struct {
-	StoreFunc  func(ctx context.Context, id string, doc map[string]any) error
-	SearchFunc func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error)
+	StoreFunc         func(ctx context.Context, id string, doc map[string]any) error
+	SearchFunc        func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error)
+	DeleteFunc        func(ctx context.Context, id string) error
+	UpdateFieldsFunc  func(ctx context.Context, id string, fields map[string]any) error
+	CountFunc         func(ctx context.Context, filters map[string]any) (int, error)
+	DeleteByQueryFunc func(ctx context.Context, filters map[string]any) (int, error)
+	ScanFunc          func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error)
 
-	StoreCalls  []struct{ ID string; Doc map[string]any }
-	SearchCalls []vector.SearchQuery
+	// mu 保护 Calls 记录（检索后的访问统计在 goroutine 中异步更新）
+	mu                 sync.Mutex
+	StoreCalls         []struct{ ID string; Doc map[string]any }
+	SearchCalls        []vector.SearchQuery
+	DeleteCalls        []string
+	UpdateFieldsCalls  []struct{ ID string; Fields map[string]any }
+	CountCalls         []map[string]any
+	DeleteByQueryCalls []map[string]any
 }
 
 func NewMockVectorStore() *MockVectorStore {
@@ -25,27 +37,86 @@ func NewMockVectorStore() *MockVectorStore {
 		SearchFunc: func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
 			return nil, nil
 		},
+		DeleteFunc: func(ctx context.Context, id string) error {
+			return nil
+		},
+		UpdateFieldsFunc: func(ctx context.Context, id string, fields map[string]any) error {
+			return nil
+		},
+		CountFunc: func(ctx context.Context, filters map[string]any) (int, error) {
+			return 0, nil
+		},
+		DeleteByQueryFunc: func(ctx context.Context, filters map[string]any) (int, error) {
+			return 0, nil
+		},
+		ScanFunc: func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+			return nil, nil
+		},
 	}
 }
 
 func (m *MockVectorStore) Store(ctx context.Context, id string, doc map[string]any) error {
+	m.mu.Lock()
 	m.StoreCalls = append(m.StoreCalls, struct{ ID string; Doc map[string]any }{id, doc})
+	m.mu.Unlock()
 	return m.StoreFunc(ctx, id, doc)
 }
 
 func (m *MockVectorStore) Search(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+	m.mu.Lock()
 	m.SearchCalls = append(m.SearchCalls, query)
+	m.mu.Unlock()
 	return m.SearchFunc(ctx, query)
 }
 
+func (m *MockVectorStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	m.DeleteCalls = append(m.DeleteCalls, id)
+	m.mu.Unlock()
+	return m.DeleteFunc(ctx, id)
+}
+
+func (m *MockVectorStore) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+	m.mu.Lock()
+	m.UpdateFieldsCalls = append(m.UpdateFieldsCalls, struct{ ID string; Fields map[string]any }{id, fields})
+	m.mu.Unlock()
+	return m.UpdateFieldsFunc(ctx, id, fields)
+}
+
+func (m *MockVectorStore) Count(ctx context.Context, filters map[string]any) (int, error) {
+	m.mu.Lock()
+	m.CountCalls = append(m.CountCalls, filters)
+	m.mu.Unlock()
+	return m.CountFunc(ctx, filters)
+}
+
+func (m *MockVectorStore) DeleteByQuery(ctx context.Context, filters map[string]any) (int, error) {
+	m.mu.Lock()
+	m.DeleteByQueryCalls = append(m.DeleteByQueryCalls, filters)
+	m.mu.Unlock()
+	return m.DeleteByQueryFunc(ctx, filters)
+}
+
+func (m *MockVectorStore) Scan(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+	return m.ScanFunc(ctx, filters, after, size)
+}
+
+// Compile-time check
+var _ vector.Store = (*MockVectorStore)(nil)
+var _ vector.Scanner = (*MockVectorStore)(nil)
+
 // MockRelationStore 用于测试的关系存储 mock
 // 实现 relation.Store 接口
 type MockRelationStore struct {
 	CreateRelationFunc  func(ctx context.Context, rel relation.Relation) error
+	CreateRelationsFunc func(ctx context.Context, rels []relation.Relation) error
 	DeleteByEventIDFunc func(ctx context.Context, eventID string) error
+	FindByEventIDFunc   func(ctx context.Context, eventID string) ([]relation.Relation, error)
 
 	CreateRelationCalls  []relation.Relation
+	CreateRelationsCalls [][]relation.Relation
 	DeleteByEventIDCalls []string
+	FindByEventIDCalls   []string
 }
 
 func NewMockRelationStore() *MockRelationStore {
@@ -53,9 +124,15 @@ func NewMockRelationStore() *MockRelationStore {
 		CreateRelationFunc: func(ctx context.Context, rel relation.Relation) error {
 			return nil
 		},
+		CreateRelationsFunc: func(ctx context.Context, rels []relation.Relation) error {
+			return nil
+		},
 		DeleteByEventIDFunc: func(ctx context.Context, eventID string) error {
 			return nil
 		},
+		FindByEventIDFunc: func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+			return nil, nil
+		},
 	}
 }
 
@@ -64,11 +141,21 @@ func (m *MockRelationStore) CreateRelation(ctx context.Context, rel relation.Rel
 	return m.CreateRelationFunc(ctx, rel)
 }
 
+func (m *MockRelationStore) CreateRelations(ctx context.Context, rels []relation.Relation) error {
+	m.CreateRelationsCalls = append(m.CreateRelationsCalls, rels)
+	return m.CreateRelationsFunc(ctx, rels)
+}
+
 func (m *MockRelationStore) DeleteByEventID(ctx context.Context, eventID string) error {
 	m.DeleteByEventIDCalls = append(m.DeleteByEventIDCalls, eventID)
 	return m.DeleteByEventIDFunc(ctx, eventID)
 }
 
+func (m *MockRelationStore) FindByEventID(ctx context.Context, eventID string) ([]relation.Relation, error) {
+	m.FindByEventIDCalls = append(m.FindByEventIDCalls, eventID)
+	return m.FindByEventIDFunc(ctx, eventID)
+}
+
 func (m *MockRelationStore) Close(_ context.Context) error {
 	return nil
 }
@@ -2,28 +2,169 @@ package action
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"sync"
 
 	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
 )
 
+// DefaultActivityInterval 活动时间线的默认分桶区间
+const DefaultActivityInterval = "day"
+
+// ConflictsLimit 冲突审计接口返回的最近记录数
+const ConflictsLimit = 100
+
+// 可按 agent 启用的 action 名称
+const (
+	ActionShortTerm          = "short_term"
+	ActionSummaryMemory      = "summary_memory"
+	ActionEventExtraction    = "event_extraction"
+	ActionConsistency        = "consistency"
+	ActionShortTermRecall    = "short_term_recall"
+	ActionCognitiveRetrieval = "cognitive_retrieval"
+)
+
+var knownActions = map[string]bool{
+	ActionShortTerm:          true,
+	ActionSummaryMemory:      true,
+	ActionEventExtraction:    true,
+	ActionConsistency:        true,
+	ActionShortTermRecall:    true,
+	ActionCognitiveRetrieval: true,
+}
+
+// IsKnownAction 判断 action 名称是否可配置
+func IsKnownAction(name string) bool {
+	return knownActions[name]
+}
+
+// AgentProfile agent 级配置
+type AgentProfile struct {
+	Actions []string // 启用的 action，链顺序固定，未列出的 action 被跳过
+
+	// 默认检索选项，请求中已设置的字段优先
+	RetrieveDefaults domain.RetrieveOptions
+}
+
 // Memory 统一的记忆操作入口
 type Memory struct {
-	logger     *slog.Logger
-	forgetting *ForgettingAction
+	logger        *slog.Logger
+	forgetting    *ForgettingAction
+	vectorStore   vector.Store
+	relationStore relation.Store
+	cache         *RetrievalCache // nil 表示不缓存
+
+	agentsMu sync.RWMutex
+	agents   map[string]AgentProfile // 未配置的 agent 运行完整链，配置热更新时整体替换
 }
 
 // NewMemory 创建 Memory 实例
 func NewMemory() *Memory {
 	return &Memory{
-		logger:     slog.Default().With("module", "memory"),
-		forgetting: NewForgettingAction(),
+		logger:        slog.Default().With("module", "memory"),
+		forgetting:    NewForgettingAction(),
+		vectorStore:   vector.NewStore(),
+		relationStore: relation.NewStore(),
+		cache:         newRetrievalCacheFromConfig(GetConfig().Cache),
 	}
 }
 
+// newRetrievalCacheFromConfig 按配置创建检索缓存，未启用时返回 nil
+func newRetrievalCacheFromConfig(cfg CacheConfig) *RetrievalCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	return NewRetrievalCache(cfg.TTLDuration()).WithMaxEntries(cfg.MaxEntries)
+}
+
+// WithCache 设置检索缓存，传入 nil 关闭缓存
+func (m *Memory) WithCache(cache *RetrievalCache) *Memory {
+	m.cache = cache
+	return m
+}
+
+// WithAgents 设置 agent 级配置，key 为 agent_id
+// 运行中调用会整体替换全部 agent 配置（配置热更新），之后的请求使用新配置
+func (m *Memory) WithAgents(agents map[string]AgentProfile) *Memory {
+	m.agentsMu.Lock()
+	defer m.agentsMu.Unlock()
+	m.agents = agents
+	return m
+}
+
+// Agent 返回 agent 级配置，未配置该 agent 时 ok 为 false
+func (m *Memory) Agent(agentID string) (AgentProfile, bool) {
+	m.agentsMu.RLock()
+	defer m.agentsMu.RUnlock()
+	profile, ok := m.agents[agentID]
+	return profile, ok
+}
+
+// actionEnabled 判断 agent 是否启用了指定 action
+func (m *Memory) actionEnabled(agentID, name string) bool {
+	profile, ok := m.Agent(agentID)
+	if !ok {
+		return true
+	}
+	for _, action := range profile.Actions {
+		if action == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAgentDefaults 将 agent 的默认检索选项合并进请求，返回副本，不修改调用方的请求
+func (m *Memory) applyAgentDefaults(req *domain.RetrieveRequest) *domain.RetrieveRequest {
+	profile, ok := m.Agent(req.AgentID)
+	if !ok {
+		return req
+	}
+
+	merged := *req
+	merged.Options = req.Options.WithDefaults(profile.RetrieveDefaults)
+	return &merged
+}
+
+// defaultLimiter 由能报告未指定 limit 时默认检索条数的存储实现
+type defaultLimiter interface {
+	DefaultLimit() int
+}
+
+// applyDefaultLimit 请求未指定 limit 时使用存储配置的默认条数（[storage] default_limit），返回副本不修改调用方的请求
+func (m *Memory) applyDefaultLimit(req *domain.RetrieveRequest) *domain.RetrieveRequest {
+	if req.Limit > 0 {
+		return req
+	}
+	limiter, ok := m.vectorStore.(defaultLimiter)
+	if !ok {
+		return req
+	}
+
+	merged := *req
+	merged.Limit = limiter.DefaultLimit()
+	return &merged
+}
+
+// WithStores 设置存储（用于测试注入 mock），作用于链中所有 action
+func (m *Memory) WithStores(v vector.Store, r relation.Store) *Memory {
+	m.vectorStore = v
+	m.relationStore = r
+	m.forgetting.WithStores(v, r)
+	return m
+}
+
 // Add 从对话中添加记忆
 // Chain: ShortTermAction → SummaryMemoryAction → EventExtractionAction → ConsistencyAction
 func (m *Memory) Add(ctx context.Context, req *domain.AddRequest) (*domain.AddResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
 	userID, agentID := inferUserAndAgent(req)
 
 	m.logger.Info("add",
@@ -34,31 +175,43 @@ func (m *Memory) Add(ctx context.Context, req *domain.AddRequest) (*domain.AddRe
 	)
 
 	// 创建 action chain
-	chain := domain.NewActionChain()
-	chain.Use(NewShortTermAction())         // 1. 短期记忆窗口
-	chain.Use(NewSummaryMemoryAction())     // 2. 摘要记忆提取
-	chain.Use(NewEventExtractionAction())   // 3. 事件三元组提取
-	chain.Use(NewConsistencyAction())       // 4. 认知一致性检查
+	chain := m.newAddChain(agentID, userID)
 
 	// 创建 context
 	addCtx := domain.NewAddContext(ctx, agentID, userID, req.SessionID)
 	addCtx.Messages = domain.Messages(req.Messages)
+	addCtx.OccurredAt = req.OccurredAt()
+
+	// system 消息通常是 prompt 而非对话内容，默认不进入记忆
+	if !GetConfig().IncludeSystemMessages {
+		addCtx.Messages = addCtx.Messages.WithoutSystem()
+	}
 
 	// 执行 chain
-	chain.Run(addCtx)
+	if len(addCtx.Messages) == 0 {
+		addCtx.AbortWithReason("no non-system messages")
+	} else {
+		chain.Run(addCtx)
+	}
 
-	// 构建响应
-	resp := &domain.AddResponse{
-		Success:        true,
-		Summaries:      addCtx.Summaries,
-		Events:         addCtx.Events,
-		EventRelations: addCtx.EventRelations,
+	// 新记忆写入后，该用户的缓存检索结果失效
+	m.invalidateCache(agentID, userID)
+
+	// chain 中途出错时已写入的部分不回滚，但不能报告成功
+	if err := addCtx.Error(); err != nil {
+		m.logger.Error("add failed", "error", err)
+		return &domain.AddResponse{Success: false, Warnings: addCtx.Warnings}, err
 	}
 
+	// 构建响应
+	resp := newAddResponse(addCtx)
+
 	m.logger.Info("add completed",
 		"summaries", len(resp.Summaries),
 		"events", len(resp.Events),
 		"relations", len(resp.EventRelations),
+		"warnings", len(resp.Warnings),
+		"abort_reason", resp.AbortReason,
 	)
 
 	return resp, nil
@@ -67,16 +220,30 @@ func (m *Memory) Add(ctx context.Context, req *domain.AddRequest) (*domain.AddRe
 // Retrieve 检索相关记忆
 // Chain: ShortTermRecallAction → CognitiveRetrievalAction
 func (m *Memory) Retrieve(ctx context.Context, req *domain.RetrieveRequest) (*domain.RetrieveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
 	m.logger.Info("retrieve",
 		"agent_id", req.AgentID,
 		"user_id", req.UserID,
 		"query", req.Query,
 	)
 
+	req = m.applyDefaultLimit(req)
+	req = m.applyAgentDefaults(req)
+
+	var cacheVersion uint64
+	if m.cache != nil {
+		if resp, ok := m.cache.Get(req); ok {
+			m.logger.Info("retrieve cache hit", "agent_id", req.AgentID, "user_id", req.UserID)
+			return resp, nil
+		}
+		cacheVersion = m.cache.Version(req.AgentID, req.UserID)
+	}
+
 	// 创建 recall chain
-	chain := domain.NewRecallChain()
-	chain.Use(NewShortTermRecallAction())     // 1. 短期记忆召回
-	chain.Use(NewCognitiveRetrievalAction())  // 2. 认知检索
+	chain := m.newRecallChain(req.AgentID, req.UserID)
 
 	// 创建 context
 	recallCtx := domain.NewRecallContext(ctx, req)
@@ -84,18 +251,18 @@ func (m *Memory) Retrieve(ctx context.Context, req *domain.RetrieveRequest) (*do
 	// 执行 chain
 	chain.Run(recallCtx)
 
-	// 构建响应
-	resp := &domain.RetrieveResponse{
-		Success:    true,
-		Facts:      recallCtx.Facts,
-		WorkingMem: recallCtx.WorkingMem,
-		Events:     recallCtx.Events,
-		ShortTerm:  recallCtx.ShortTerm,
-		Total:      recallCtx.TotalResults(),
+	if err := recallCtx.Error(); err != nil {
+		m.logger.Error("retrieve failed", "error", err)
+		return nil, err
 	}
 
-	// 格式化记忆上下文
-	resp.MemoryContext = FormatMemoryContext(recallCtx)
+	// 构建响应
+	resp := newRetrieveResponse(recallCtx)
+
+	// 降级结果不缓存，关系存储恢复后即可拿到完整结果
+	if m.cache != nil && !resp.Degraded {
+		m.cache.Set(req, resp, cacheVersion)
+	}
 
 	m.logger.Info("retrieve completed",
 		"facts", len(resp.Facts),
@@ -108,21 +275,380 @@ func (m *Memory) Retrieve(ctx context.Context, req *domain.RetrieveRequest) (*do
 	return resp, nil
 }
 
+// scopedVectorStore 返回限定在 agent/user 下的向量存储，所有查询强制带租户过滤
+// 未配置向量存储时返回 nil，保持各 action 的降级判断
+func (m *Memory) scopedVectorStore(agentID, userID string) vector.Store {
+	if m.vectorStore == nil {
+		return nil
+	}
+	return vector.NewScopedStore(m.vectorStore, agentID, userID)
+}
+
+// scopedScanner 返回限定在 agent/user 下、供按 id 分页扫描的向量存储
+// ScopedStore 总是实现 Scan，底层存储不支持时返回 nil，由各 action 报告不支持该操作
+func (m *Memory) scopedScanner(agentID, userID string) vector.Store {
+	if _, ok := m.vectorStore.(vector.Scanner); !ok {
+		return nil
+	}
+	return m.scopedVectorStore(agentID, userID)
+}
+
+// newAddChain 按 agent 启用的 action 构建写入链，向量存储按请求的 agent/user 限定范围
+func (m *Memory) newAddChain(agentID, userID string) *domain.ActionChain {
+	store := m.scopedVectorStore(agentID, userID)
+	chain := domain.NewActionChain()
+	if m.actionEnabled(agentID, ActionShortTerm) {
+		chain.Use(NewShortTermAction()) // 1. 短期记忆窗口
+	}
+	if m.actionEnabled(agentID, ActionSummaryMemory) {
+		chain.Use(NewSummaryMemoryAction().WithStore(store)) // 2. 摘要记忆提取
+	}
+	if m.actionEnabled(agentID, ActionEventExtraction) {
+		chain.Use(NewEventExtractionAction().WithStores(store, m.relationStore)) // 3. 事件三元组提取
+	}
+	if m.actionEnabled(agentID, ActionConsistency) {
+		chain.Use(NewConsistencyAction().WithStore(store).OnExpire(m.invalidateCache)) // 4. 认知一致性检查
+	}
+	return chain
+}
+
+// newRecallChain 按 agent 启用的 action 构建检索链，向量存储按请求的 agent/user 限定范围
+func (m *Memory) newRecallChain(agentID, userID string) *domain.RecallChain {
+	store := m.scopedVectorStore(agentID, userID)
+	chain := domain.NewRecallChain()
+	if m.actionEnabled(agentID, ActionShortTermRecall) {
+		chain.Use(NewShortTermRecallAction()) // 1. 短期记忆召回
+	}
+	if m.actionEnabled(agentID, ActionCognitiveRetrieval) {
+		chain.Use(NewCognitiveRetrievalAction().WithStores(store, m.relationStore)) // 2. 认知检索
+	}
+	return chain
+}
+
+// newAddResponse 从执行完的 AddContext 构建响应
+func newAddResponse(c *domain.AddContext) *domain.AddResponse {
+	return &domain.AddResponse{
+		Success:        !c.IsAborted(),
+		Summaries:      c.Summaries,
+		Events:         c.Events,
+		EventRelations: c.EventRelations,
+		Warnings:       c.Warnings,
+		AbortReason:    abortReason(c.IsAborted(), c.AbortReason()),
+	}
+}
+
+// unspecifiedAbortReason 通过 Abort 终止而未给出原因时响应中的 abort_reason
+const unspecifiedAbortReason = "aborted"
+
+// abortReason 返回链提前终止的原因，保证终止的链总带有非空原因
+func abortReason(aborted bool, reason string) string {
+	if aborted && reason == "" {
+		return unspecifiedAbortReason
+	}
+	return reason
+}
+
+// newRetrieveResponse 从执行完的 RecallContext 构建响应
+func newRetrieveResponse(c *domain.RecallContext) *domain.RetrieveResponse {
+	resp := &domain.RetrieveResponse{
+		Success:    !c.IsAborted(),
+		Facts:      c.Facts,
+		WorkingMem: c.WorkingMem,
+		Events:     c.Events,
+		ShortTerm:  c.ShortTerm,
+		Total:      c.TotalResults(),
+
+		EventRelations: c.EventRelations,
+
+		Debug:       c.Debug,
+		Meta:        c.Meta,
+		AbortReason: abortReason(c.IsAborted(), c.AbortReason()),
+
+		Degraded: c.Degraded,
+		Warnings: c.Warnings,
+	}
+
+	if c.Options.GroupBySession {
+		resp.Sessions = groupBySession(c)
+	}
+
+	// 格式化记忆上下文（程序化调用方只需要结构化结果时跳过）
+	if !c.Options.SkipFormatting {
+		resp.MemoryContext = FormatMemoryContext(c)
+	}
+	return resp
+}
+
+// groupBySession 按 session_id 分组 Fact / Working / 事件结果，分组按会话首次出现的顺序排列，组内保持原有顺序
+func groupBySession(c *domain.RecallContext) []domain.SessionGroup {
+	var groups []domain.SessionGroup
+	index := make(map[string]int)
+	group := func(sessionID string) *domain.SessionGroup {
+		i, ok := index[sessionID]
+		if !ok {
+			i = len(groups)
+			index[sessionID] = i
+			groups = append(groups, domain.SessionGroup{SessionID: sessionID})
+		}
+		return &groups[i]
+	}
+
+	for _, s := range c.Facts {
+		g := group(s.SessionID)
+		g.Facts = append(g.Facts, s)
+	}
+	for _, s := range c.WorkingMem {
+		g := group(s.SessionID)
+		g.WorkingMem = append(g.WorkingMem, s)
+	}
+	for _, e := range c.Events {
+		g := group(e.SessionID)
+		g.Events = append(g.Events, e)
+	}
+	return groups
+}
+
 // Forget 执行记忆遗忘
 func (m *Memory) Forget(ctx context.Context, req *domain.ForgetRequest) (*domain.ForgetResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
 	m.logger.Info("forget",
 		"agent_id", req.AgentID,
 		"user_id", req.UserID,
 	)
 
-	return m.forgetting.Execute(ctx, req.AgentID, req.UserID)
+	resp, err := m.forgetting.Execute(ctx, req.AgentID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 遗忘删除了记忆，缓存的检索结果失效
+	m.invalidateCache(req.AgentID, req.UserID)
+
+	return resp, nil
+}
+
+// Compact 将 agent+user 下同一主题的摘要记忆压缩为上层摘要
+func (m *Memory) Compact(ctx context.Context, req *domain.CompactRequest) (*domain.CompactResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("compact",
+		"agent_id", req.AgentID,
+		"user_id", req.UserID,
+	)
+
+	resp, err := NewCompactionAction().WithStore(m.scopedScanner(req.AgentID, req.UserID)).Execute(ctx, req.AgentID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新增了上层摘要，缓存的检索结果失效
+	m.invalidateCache(req.AgentID, req.UserID)
+
+	return resp, nil
+}
+
+// Reindex 用当前配置的 embedder 重建 agent+user 下所有文档的向量
+// 用于切换 embedding 模型（维度不变）后修复召回
+func (m *Memory) Reindex(ctx context.Context, req *domain.ReindexRequest) (*domain.ReindexResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("reindex",
+		"agent_id", req.AgentID,
+		"user_id", req.UserID,
+	)
+
+	resp, err := NewReindexAction().WithStore(m.vectorStore).Execute(ctx, req.AgentID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 向量变化后缓存的检索结果失效
+	m.invalidateCache(req.AgentID, req.UserID)
+
+	return resp, nil
+}
+
+// MigrateIndex 迁移到新的向量维度，完成后别名指向新索引，全部检索缓存失效
+// 须先将摘要记忆与事件的 embedder 配置为新维度的模型
+func (m *Memory) MigrateIndex(ctx context.Context, req *domain.MigrateIndexRequest) (*domain.MigrateIndexResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("migrate index", "embedding_dim", req.EmbeddingDim)
+
+	resp, err := NewReindexAction().WithStore(m.vectorStore).Migrate(ctx, req.EmbeddingDim)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.cache != nil {
+		m.cache.InvalidateAll()
+	}
+
+	return resp, nil
+}
+
+// Export 将 agent+user 下的全部文档与事件关系以 NDJSON 写入 w
+func (m *Memory) Export(ctx context.Context, agentID, userID string, w io.Writer) (*domain.ExportResponse, error) {
+	if agentID == "" || userID == "" {
+		return nil, fmt.Errorf("%w: agent_id and user_id are required", domain.ErrInvalidInput)
+	}
+
+	m.logger.Info("export",
+		"agent_id", agentID,
+		"user_id", userID,
+	)
+
+	return NewExportAction().WithStores(m.scopedScanner(agentID, userID), m.relationStore).Execute(ctx, agentID, userID, w)
+}
+
+// Import 将 Export 产生的 NDJSON 按原 ID 与向量写回 agent+user 作用域
+func (m *Memory) Import(ctx context.Context, agentID, userID string, r io.Reader) (*domain.ImportResponse, error) {
+	if agentID == "" || userID == "" {
+		return nil, fmt.Errorf("%w: agent_id and user_id are required", domain.ErrInvalidInput)
+	}
+
+	m.logger.Info("import",
+		"agent_id", agentID,
+		"user_id", userID,
+	)
+
+	resp, err := NewImportAction().WithStores(m.vectorStore, m.relationStore).Execute(ctx, agentID, userID, r)
+	if err != nil {
+		return nil, err
+	}
+
+	// 导入新增了记忆，缓存的检索结果失效
+	m.invalidateCache(agentID, userID)
+
+	return resp, nil
+}
+
+// DeleteUser 删除 agent+user 下全部文档、事件关系与短期记忆（被遗忘权）
+func (m *Memory) DeleteUser(ctx context.Context, agentID, userID string) (*domain.DeleteUserResponse, error) {
+	if agentID == "" || userID == "" {
+		return nil, fmt.Errorf("%w: agent_id and user_id are required", domain.ErrInvalidInput)
+	}
+
+	m.logger.Info("delete user",
+		"agent_id", agentID,
+		"user_id", userID,
+	)
+
+	resp, err := NewDeleteUserAction().WithStores(m.scopedScanner(agentID, userID), m.relationStore).Execute(ctx, agentID, userID)
+
+	// 部分删除也会改变数据，无论成功与否都使缓存失效
+	m.invalidateCache(agentID, userID)
+
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RestoreFact 恢复被一致性检查过期的 fact，可选将取代它的新 fact 过期
+func (m *Memory) RestoreFact(ctx context.Context, req *domain.RestoreFactRequest) (*domain.RestoreFactResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("restore fact",
+		"agent_id", req.AgentID,
+		"user_id", req.UserID,
+		"id", req.ID,
+		"expire_superseding", req.ExpireSuperseding,
+	)
+
+	resp, err := NewConsistencyAction().WithStore(m.scopedVectorStore(req.AgentID, req.UserID)).Restore(ctx, req.AgentID, req.UserID, req.ID, req.ExpireSuperseding)
+	if err != nil {
+		return nil, err
+	}
+
+	// 被召回的 fact 发生变化，缓存的检索结果失效
+	m.invalidateCache(req.AgentID, req.UserID)
+
+	return resp, nil
+}
+
+// Conflicts 返回 agent+user 下最近的冲突审计记录，按时间倒序
+func (m *Memory) Conflicts(ctx context.Context, agentID, userID string) (*domain.ConflictsResponse, error) {
+	if agentID == "" || userID == "" {
+		return nil, fmt.Errorf("%w: agent_id and user_id are required", domain.ErrInvalidInput)
+	}
+	if m.vectorStore == nil {
+		return nil, fmt.Errorf("%w: vector store is not configured", domain.ErrStoreUnavailable)
+	}
+
+	docs, err := m.scopedVectorStore(agentID, userID).Search(ctx, vector.SearchQuery{
+		Filters: map[string]any{"type": domain.DocTypeConflict},
+		Limit:   ConflictsLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: search conflicts: %w", domain.ErrStoreUnavailable, err)
+	}
+
+	base := NewBaseAction("conflicts")
+	resp := &domain.ConflictsResponse{Conflicts: make([]domain.ConflictRecord, 0, len(docs))}
+	for _, doc := range docs {
+		resp.Conflicts = append(resp.Conflicts, base.DocToConflictRecord(doc))
+	}
+	return resp, nil
+}
+
+// Activity 按 created_at 统计 agent+user 下各时间区间写入的记忆与事件数，interval 为空时按天
+func (m *Memory) Activity(ctx context.Context, agentID, userID, interval string) (*domain.ActivityResponse, error) {
+	if agentID == "" || userID == "" {
+		return nil, fmt.Errorf("%w: agent_id and user_id are required", domain.ErrInvalidInput)
+	}
+	if interval == "" {
+		interval = DefaultActivityInterval
+	}
+	if !vector.IsCalendarInterval(interval) {
+		return nil, fmt.Errorf("%w: unsupported interval %q", domain.ErrInvalidInput, interval)
+	}
+
+	if m.vectorStore == nil {
+		return nil, fmt.Errorf("%w: vector store is not configured", domain.ErrStoreUnavailable)
+	}
+	if _, ok := m.vectorStore.(vector.Aggregator); !ok {
+		return nil, fmt.Errorf("%w: vector store does not support aggregations", domain.ErrInvalidInput)
+	}
+
+	// 冲突审计记录同在索引中且为 active，只统计摘要记忆与事件
+	histogram, err := vector.NewScopedStore(m.vectorStore, agentID, userID).DateHistogram(ctx, "created_at", interval, map[string]any{
+		"type": []string{domain.DocTypeSummary, domain.DocTypeEvent},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: activity histogram: %w", domain.ErrStoreUnavailable, err)
+	}
+
+	resp := &domain.ActivityResponse{Interval: interval, Buckets: make([]domain.ActivityBucket, 0, len(histogram))}
+	for _, b := range histogram {
+		resp.Buckets = append(resp.Buckets, domain.ActivityBucket{Start: b.Start, Count: b.Count})
+	}
+	return resp, nil
+}
+
+// invalidateCache 使指定 agent/user 的缓存检索结果失效
+func (m *Memory) invalidateCache(agentID, userID string) {
+	if m.cache != nil {
+		m.cache.Invalidate(agentID, userID)
+	}
 }
 
 // Delete 删除记忆
 func (m *Memory) Delete(ctx context.Context, id string) error {
 	m.logger.Info("delete", "id", id)
-	// TODO: 实现删除逻辑
-	return nil
+	// TODO: 实现删除逻辑，实现前不报告成功
+	return fmt.Errorf("%w: delete memory %s", domain.ErrNotImplemented, id)
 }
 
 // inferUserAndAgent 从请求和 messages 中推断 user_id 和 agent_id
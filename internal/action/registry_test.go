@@ -0,0 +1,705 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestMemory_AddSurfacesWarnings(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	// 同一 mock 模型同时服务 memory_extract 和 event_extract
+	helper.SetModelJSON(map[string]any{
+		"memories": []ExtractedMemory{
+			{Content: "用户喜欢喝咖啡", Importance: 0.5, MemoryType: domain.MemoryTypeWorking},
+		},
+		"events": []ExtractedEvent{
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡"},
+		},
+	})
+
+	req := &domain.AddRequest{
+		AgentID:   "agent_1",
+		UserID:    "user_1",
+		SessionID: "session_warnings",
+		Messages: []domain.Message{
+			{Role: domain.RoleUser, Name: "小明", Content: "我每天都喝咖啡"},
+		},
+	}
+
+	t.Run("store failure surfaces as warning", func(t *testing.T) {
+		store := NewMockVectorStore()
+		store.StoreFunc = func(ctx context.Context, id string, doc map[string]any) error {
+			if doc["type"] == domain.DocTypeSummary {
+				return errors.New("opensearch unavailable")
+			}
+			return nil
+		}
+
+		resp, err := NewMemory().WithStores(store, NewMockRelationStore()).Add(ctx, req)
+		require.NoError(t, err)
+
+		assert.True(t, resp.Success)
+		assert.Empty(t, resp.Summaries)
+		assert.Len(t, resp.Events, 1)
+		require.Len(t, resp.Warnings, 1)
+		assert.Contains(t, resp.Warnings[0], "summary_memory")
+		assert.Contains(t, resp.Warnings[0], "opensearch unavailable")
+	})
+
+	t.Run("no warnings on clean run", func(t *testing.T) {
+		resp, err := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore()).Add(ctx, req)
+		require.NoError(t, err)
+
+		assert.Len(t, resp.Summaries, 1)
+		assert.Empty(t, resp.Warnings)
+	})
+}
+
+func TestMemory_AddReturnsChainError(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		return nil, errors.New("ark unavailable")
+	})
+
+	req := &domain.AddRequest{
+		AgentID:   "agent_1",
+		UserID:    "user_1",
+		SessionID: "session_error",
+		Messages: []domain.Message{
+			{Role: domain.RoleUser, Name: "小明", Content: "我每天都喝咖啡"},
+		},
+	}
+
+	resp, err := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore()).Add(ctx, req)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, domain.ErrLLMUnavailable)
+	assert.Contains(t, err.Error(), "ark unavailable")
+	require.NotNil(t, resp)
+	assert.False(t, resp.Success)
+	assert.Empty(t, resp.Summaries)
+}
+
+// abortAction 以给定原因终止链，用于测试
+type abortAction struct{ reason string }
+
+func (a *abortAction) Name() string { return "abort" }
+
+func (a *abortAction) Handle(c *domain.AddContext) { c.AbortWithReason(a.reason) }
+
+func (a *abortAction) HandleRecall(c *domain.RecallContext) { c.AbortWithReason(a.reason) }
+
+func TestMemory_AbortReasonInResponse(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("add", func(t *testing.T) {
+		chain := domain.NewActionChain()
+		chain.Use(&abortAction{reason: "nothing to remember"})
+
+		addCtx := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		chain.Run(addCtx)
+
+		resp := newAddResponse(addCtx)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "nothing to remember", resp.AbortReason)
+	})
+
+	t.Run("abort without reason", func(t *testing.T) {
+		chain := domain.NewActionChain()
+		chain.Use(&abortAction{})
+
+		addCtx := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		chain.Run(addCtx)
+
+		resp := newAddResponse(addCtx)
+		assert.False(t, resp.Success)
+		assert.Equal(t, unspecifiedAbortReason, resp.AbortReason)
+	})
+
+	t.Run("clean completion", func(t *testing.T) {
+		addCtx := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		domain.NewActionChain().Run(addCtx)
+
+		resp := newAddResponse(addCtx)
+		assert.True(t, resp.Success)
+		assert.Empty(t, resp.AbortReason)
+	})
+
+	t.Run("add with only system messages", func(t *testing.T) {
+		resp, err := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore()).Add(ctx, &domain.AddRequest{
+			AgentID:   "agent_1",
+			UserID:    "user_1",
+			SessionID: "session_1",
+			Messages:  []domain.Message{{Role: domain.RoleSystem, Content: "你是一个助手"}},
+		})
+		require.NoError(t, err)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "no non-system messages", resp.AbortReason)
+	})
+
+	t.Run("retrieve", func(t *testing.T) {
+		chain := domain.NewRecallChain()
+		chain.Use(&abortAction{reason: "query too short"})
+
+		recallCtx := domain.NewRecallContext(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "?"})
+		chain.Run(recallCtx)
+
+		resp := newRetrieveResponse(recallCtx)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "query too short", resp.AbortReason)
+	})
+}
+
+func TestMemory_AddSkipsSystemMessages(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	// 记录发送给 LLM 的 prompt
+	var prompts []string
+	helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		for _, msg := range req.Messages {
+			prompts = append(prompts, msg.Text())
+		}
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage(`{"memories":[],"events":[]}`)}, nil
+	})
+
+	memory := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore())
+
+	t.Run("system message excluded", func(t *testing.T) {
+		t.Cleanup(func() { GetShortTermStore().Clear("agent_1", "user_1", "session_system") })
+
+		resp, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID:   "agent_1",
+			UserID:    "user_1",
+			SessionID: "session_system",
+			Messages: []domain.Message{
+				{Role: domain.RoleSystem, Content: "你是一个乐于助人的助手"},
+				{Role: domain.RoleUser, Name: "小明", Content: "我住在北京"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.AbortReason)
+
+		require.Len(t, prompts, 2) // memory_extract + event_extract
+		for _, p := range prompts {
+			assert.Contains(t, p, "我住在北京")
+			assert.NotContains(t, p, "乐于助人")
+		}
+
+		w := GetShortTermStore().GetWindow("agent_1", "user_1", "session_system")
+		require.NotNil(t, w)
+		require.Len(t, w.Messages, 1)
+		assert.Equal(t, domain.RoleUser, w.Messages[0].Role)
+	})
+
+	t.Run("only system messages aborts", func(t *testing.T) {
+		prompts = nil
+		resp, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Messages: []domain.Message{
+				{Role: domain.RoleSystem, Content: "你是一个乐于助人的助手"},
+			},
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.AbortReason)
+		assert.Empty(t, prompts)
+	})
+
+	t.Run("invalid role rejected", func(t *testing.T) {
+		_, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID:  "agent_1",
+			UserID:   "user_1",
+			Messages: []domain.Message{{Role: "tool", Content: "{}"}},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestMemory_AddSkipsTrivialConversation(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	cfg := DefaultConfig()
+	cfg.MinMessages = 2
+	cfg.MinContentLength = 5
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Init(DefaultConfig()) })
+
+	var calls int
+	helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		calls++
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage(`{"memories":[],"events":[]}`)}, nil
+	})
+
+	memory := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore())
+
+	t.Run("single greeting skips extraction", func(t *testing.T) {
+		t.Cleanup(func() { GetShortTermStore().Clear("agent_1", "user_1", "session_hi") })
+
+		calls = 0
+		resp, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID:   "agent_1",
+			UserID:    "user_1",
+			SessionID: "session_hi",
+			Messages:  []domain.Message{{Role: domain.RoleUser, Content: "hi"}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.AbortReason)
+		assert.Zero(t, calls)
+
+		// 短期窗口照常写入
+		w := GetShortTermStore().GetWindow("agent_1", "user_1", "session_hi")
+		require.NotNil(t, w)
+		assert.Len(t, w.Messages, 1)
+	})
+
+	t.Run("conversation above minimum is extracted", func(t *testing.T) {
+		t.Cleanup(func() { GetShortTermStore().Clear("agent_1", "user_1", "session_trip") })
+
+		calls = 0
+		_, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID:   "agent_1",
+			UserID:    "user_1",
+			SessionID: "session_trip",
+			Messages: []domain.Message{
+				{Role: domain.RoleUser, Content: "我下个月要去上海出差"},
+				{Role: domain.RoleAssistant, Content: "好的，需要我帮你订酒店吗？"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls) // memory_extract + event_extract
+	})
+}
+
+func TestMemory_AgentActions(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(map[string]any{
+		"memories": []ExtractedMemory{
+			{Content: "用户喜欢喝咖啡", Importance: 0.5, MemoryType: domain.MemoryTypeWorking},
+		},
+		"events": []ExtractedEvent{
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡"},
+		},
+	})
+
+	store := NewMockVectorStore()
+	memory := NewMemory().WithStores(store, NewMockRelationStore()).WithAgents(map[string]AgentProfile{
+		"lite_agent": {Actions: []string{ActionShortTerm, ActionSummaryMemory, ActionShortTermRecall}},
+		"full_agent": {Actions: []string{
+			ActionShortTerm, ActionSummaryMemory, ActionEventExtraction, ActionConsistency,
+			ActionShortTermRecall, ActionCognitiveRetrieval,
+		}},
+	})
+
+	add := func(agentID string) *domain.AddResponse {
+		resp, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID:   agentID,
+			UserID:    "user_1",
+			SessionID: "session_agents",
+			Messages:  []domain.Message{{Role: domain.RoleUser, Name: "小明", Content: "我每天都喝咖啡"}},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("lite agent skips event extraction", func(t *testing.T) {
+		resp := add("lite_agent")
+		assert.Len(t, resp.Summaries, 1)
+		assert.Empty(t, resp.Events)
+	})
+
+	t.Run("full agent runs everything", func(t *testing.T) {
+		resp := add("full_agent")
+		assert.Len(t, resp.Summaries, 1)
+		assert.Len(t, resp.Events, 1)
+	})
+
+	t.Run("lite agent skips cognitive retrieval", func(t *testing.T) {
+		searches := len(store.SearchCalls)
+		resp, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "lite_agent", UserID: "user_1", SessionID: "session_agents", Query: "咖啡"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.ShortTerm)
+		assert.Len(t, store.SearchCalls, searches)
+	})
+
+	t.Run("unconfigured agent runs full chain", func(t *testing.T) {
+		resp := add("other_agent")
+		assert.Len(t, resp.Events, 1)
+	})
+}
+
+func TestMemory_RetrieveSkipFormatting(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["memory_type"] != domain.MemoryTypeFact {
+			return nil, nil
+		}
+		return []map[string]any{
+			{"id": "mem_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "content": "用户住在北京", "_score": 0.9},
+		}, nil
+	}
+	memory := NewMemory().WithStores(store, NewMockRelationStore())
+
+	retrieve := func(opts domain.RetrieveOptions) *domain.RetrieveResponse {
+		resp, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里", Options: opts})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("formatted by default", func(t *testing.T) {
+		resp := retrieve(domain.RetrieveOptions{})
+		require.Len(t, resp.Facts, 1)
+		assert.Contains(t, resp.MemoryContext, "用户住在北京")
+	})
+
+	t.Run("skip formatting returns typed results only", func(t *testing.T) {
+		resp := retrieve(domain.RetrieveOptions{SkipFormatting: true})
+		require.Len(t, resp.Facts, 1)
+		assert.Equal(t, "用户住在北京", resp.Facts[0].Content)
+		assert.Empty(t, resp.MemoryContext)
+	})
+}
+
+func TestMemory_AgentRetrieveDefaults(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["memory_type"] != domain.MemoryTypeFact {
+			return nil, nil
+		}
+		return []map[string]any{
+			{"id": "mem_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "content": "用户住在北京", "_score": 0.9},
+		}, nil
+	}
+	memory := NewMemory().WithStores(store, NewMockRelationStore()).WithAgents(map[string]AgentProfile{
+		"concise_agent": {
+			Actions:          []string{ActionShortTermRecall, ActionCognitiveRetrieval},
+			RetrieveDefaults: domain.RetrieveOptions{MaxFacts: -1}, // 关闭 Fact 桶
+		},
+	})
+
+	retrieve := func(agentID string, opts domain.RetrieveOptions) *domain.RetrieveResponse {
+		req := &domain.RetrieveRequest{AgentID: agentID, UserID: "user_1", Query: "用户住在哪里", Options: opts}
+		resp, err := memory.Retrieve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, opts, req.Options, "caller's request must not be modified")
+		return resp
+	}
+
+	t.Run("agent defaults apply when request omits options", func(t *testing.T) {
+		assert.Empty(t, retrieve("concise_agent", domain.RetrieveOptions{}).Facts)
+	})
+
+	t.Run("request options override agent defaults", func(t *testing.T) {
+		assert.Len(t, retrieve("concise_agent", domain.RetrieveOptions{MaxFacts: 100}).Facts, 1)
+	})
+
+	t.Run("unconfigured agent uses built-in defaults", func(t *testing.T) {
+		assert.Len(t, retrieve("other_agent", domain.RetrieveOptions{}).Facts, 1)
+	})
+}
+
+func TestMemory_Activity(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no store", func(t *testing.T) {
+		_, err := NewMemory().WithStores(nil, NewMockRelationStore()).Activity(ctx, "agent_1", "user_1", "")
+		assert.ErrorIs(t, err, domain.ErrStoreUnavailable)
+	})
+
+	t.Run("store without aggregations", func(t *testing.T) {
+		_, err := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore()).Activity(ctx, "agent_1", "user_1", "")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("unsupported interval", func(t *testing.T) {
+		_, err := NewMemory().WithStores(nil, NewMockRelationStore()).Activity(ctx, "agent_1", "user_1", "fortnight")
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("counts memories and events only", func(t *testing.T) {
+		store := &histogramVectorStore{MockVectorStore: NewMockVectorStore()}
+		resp, err := NewMemory().WithStores(store, NewMockRelationStore()).Activity(ctx, "agent_1", "user_1", "")
+		require.NoError(t, err)
+		assert.Equal(t, DefaultActivityInterval, resp.Interval)
+
+		assert.Equal(t, map[string]any{
+			"agent_id": "agent_1",
+			"user_id":  "user_1",
+			"type":     []string{domain.DocTypeSummary, domain.DocTypeEvent},
+		}, store.filters)
+	})
+}
+
+// histogramVectorStore 记录 DateHistogram 收到的过滤条件
+type histogramVectorStore struct {
+	*MockVectorStore
+
+	filters map[string]any
+}
+
+func (s *histogramVectorStore) Aggregate(ctx context.Context, field string, filters map[string]any, size int) (map[string]int, error) {
+	return map[string]int{}, nil
+}
+
+func (s *histogramVectorStore) DateHistogram(ctx context.Context, field, interval string, filters map[string]any) ([]vector.HistogramBucket, error) {
+	s.filters = filters
+	return nil, nil
+}
+
+func TestMemory_RetrieveDefaultLimit(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	// 等同 [storage] default_limit = 2
+	store := vector.NewMemoryStore(3).WithDefaultLimit(2)
+	for i := range 6 {
+		id := fmt.Sprintf("fact_%d", i)
+		require.NoError(t, store.Store(ctx, id, map[string]any{
+			"id": id, "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_1",
+			"content": fmt.Sprintf("用户的第 %d 条记忆", i), "embedding": []float32{1, 0, 0},
+		}))
+	}
+	memory := NewMemory().WithStores(store, NewMockRelationStore())
+
+	retrieve := func(limit int) *domain.RetrieveResponse {
+		req := &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户的记忆", Limit: limit, Options: domain.RetrieveOptions{DedupThreshold: -1}}
+		resp, err := memory.Retrieve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, limit, req.Limit, "caller's request must not be modified")
+		return resp
+	}
+
+	// 首轮按 limit 检索，剩余预算再按 2 倍 limit 补充，共 2·limit 条
+	t.Run("unset limit uses storage default_limit", func(t *testing.T) {
+		assert.Len(t, retrieve(0).Facts, 4)
+	})
+
+	t.Run("request limit overrides", func(t *testing.T) {
+		assert.Len(t, retrieve(3).Facts, 6)
+	})
+}
+
+func TestMemory_RetrieveRoles(t *testing.T) {
+	ctx := context.Background()
+	NewTestHelper(ctx)
+
+	GetShortTermStore().AppendMessages("agent_roles", "user_1", "session_1", domain.Messages{
+		{Role: domain.RoleUser, Content: "我下周要去上海出差"},
+		{Role: domain.RoleAssistant, Content: "好的，需要我帮你订酒店吗？"},
+		{Role: domain.RoleUser, Content: "订外滩附近的"},
+	})
+	t.Cleanup(func() { GetShortTermStore().Clear("agent_roles", "user_1", "session_1") })
+
+	memory := NewMemory().WithStores(NewMockVectorStore(), NewMockRelationStore()).WithAgents(map[string]AgentProfile{
+		"agent_roles": {Actions: []string{ActionShortTermRecall}},
+	})
+
+	retrieve := func(opts domain.RetrieveOptions) *domain.RetrieveResponse {
+		opts.SkipFormatting = true
+		resp, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_roles", UserID: "user_1", SessionID: "session_1", Query: "用户去哪里出差", Options: opts})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("only user messages when filtered", func(t *testing.T) {
+		resp := retrieve(domain.RetrieveOptions{Roles: []string{domain.RoleUser}})
+		require.Len(t, resp.ShortTerm, 2)
+		for _, msg := range resp.ShortTerm {
+			assert.Equal(t, domain.RoleUser, msg.Role)
+		}
+	})
+
+	t.Run("every role without filter", func(t *testing.T) {
+		assert.Len(t, retrieve(domain.RetrieveOptions{}).ShortTerm, 3)
+	})
+
+	t.Run("unknown role is rejected", func(t *testing.T) {
+		_, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_roles", UserID: "user_1", Query: "用户去哪里出差", Options: domain.RetrieveOptions{Roles: []string{"bot"}}})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestMemory_AddThenRetrieveWithMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	vectors := map[string][]float32{
+		"用户喜欢喝咖啡":     {1, 0, 0},
+		"用户周末常去咖啡馆看书": {0.6, 0.8, 0},
+		"用户住在上海":      {0, 0, 1},
+		"用户喜欢喝什么":     {1, 0, 0},
+	}
+	helper.SetEmbedderFunc(func(text string) []float32 {
+		if v, ok := vectors[text]; ok {
+			return v
+		}
+		return []float32{0, 1, 0}
+	})
+	helper.SetModelJSON(map[string]any{
+		"memories": []ExtractedMemory{
+			{Content: "用户喜欢喝咖啡", Importance: 0.6, MemoryType: domain.MemoryTypeFact},
+			{Content: "用户周末常去咖啡馆看书", Importance: 0.5, MemoryType: domain.MemoryTypeFact},
+			{Content: "用户住在上海", Importance: 0.6, MemoryType: domain.MemoryTypeFact},
+		},
+		"events": []ExtractedEvent{
+			{TriggerWord: "喝", Argument1: "用户", Argument2: "咖啡"},
+		},
+	})
+
+	cfg := DefaultConfig()
+	cfg.MinMessages = 2
+	require.NoError(t, Init(cfg))
+	t.Cleanup(func() { _ = Init(DefaultConfig()) })
+
+	store := vector.NewMemoryStore(3)
+	memory := NewMemory().WithStores(store, NewMockRelationStore())
+
+	add := func(userID string, messages ...domain.Message) *domain.AddResponse {
+		resp, err := memory.Add(ctx, &domain.AddRequest{AgentID: "agent_1", UserID: userID, SessionID: "session_" + userID, Messages: messages})
+		require.NoError(t, err)
+		return resp
+	}
+	conversation := []domain.Message{
+		{Role: domain.RoleUser, Content: "我每天都要喝咖啡，周末喜欢去咖啡馆看书"},
+		{Role: domain.RoleAssistant, Content: "听起来很惬意，你住在哪个城市？"},
+		{Role: domain.RoleUser, Content: "上海"},
+	}
+
+	resp := add("user_1", conversation...)
+	require.Len(t, resp.Summaries, 3)
+	require.Len(t, resp.Events, 1)
+	add("user_2", conversation...)
+
+	retrieve := func(opts domain.RetrieveOptions) *domain.RetrieveResponse {
+		resp, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户喜欢喝什么", Options: opts})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("retrieves only the user's memories ranked by similarity", func(t *testing.T) {
+		resp := retrieve(domain.RetrieveOptions{})
+
+		var contents []string
+		for _, f := range resp.Facts {
+			assert.Equal(t, "user_1", f.UserID)
+			contents = append(contents, f.Content)
+		}
+		assert.Equal(t, []string{"用户喜欢喝咖啡", "用户周末常去咖啡馆看书", "用户住在上海"}, contents)
+		require.Len(t, resp.Events, 1)
+		assert.Equal(t, "咖啡", resp.Events[0].Argument2)
+	})
+
+	t.Run("fact budget truncates lower ranked facts", func(t *testing.T) {
+		resp := retrieve(domain.RetrieveOptions{MaxFacts: estimateTokens("用户喜欢喝咖啡")})
+
+		require.Len(t, resp.Facts, 1)
+		assert.Equal(t, "用户喜欢喝咖啡", resp.Facts[0].Content)
+	})
+
+	t.Run("conversation below the minimum stores nothing", func(t *testing.T) {
+		before, err := store.Count(ctx, map[string]any{"user_id": "user_3"})
+		require.NoError(t, err)
+		require.Zero(t, before)
+
+		add("user_3", domain.Message{Role: domain.RoleUser, Content: "我每天都要喝咖啡"})
+
+		after, err := store.Count(ctx, map[string]any{"user_id": "user_3"})
+		require.NoError(t, err)
+		assert.Zero(t, after)
+	})
+}
+
+func TestMemory_RetrieveGroupBySession(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	memory := NewMemory().WithStores(vector.NewMemoryStore(3), NewMockRelationStore())
+	add := func(sessionID string, extracted map[string]any) {
+		helper.SetModelJSON(extracted)
+		_, err := memory.Add(ctx, &domain.AddRequest{
+			AgentID:   "agent_1",
+			UserID:    "user_1",
+			SessionID: sessionID,
+			Messages:  []domain.Message{{Role: domain.RoleUser, Name: "小明", Content: "随便聊聊"}},
+		})
+		require.NoError(t, err)
+	}
+
+	add("session_coffee", map[string]any{
+		"memories": []ExtractedMemory{{Content: "用户喜欢喝咖啡", Importance: 0.6, MemoryType: domain.MemoryTypeFact}},
+		"events":   []ExtractedEvent{{TriggerWord: "喝", Argument1: "用户", Argument2: "咖啡"}},
+	})
+	add("session_city", map[string]any{
+		"memories": []ExtractedMemory{
+			{Content: "用户住在上海", Importance: 0.6, MemoryType: domain.MemoryTypeFact},
+			{Content: "用户正在找房子", Importance: 0.4, MemoryType: domain.MemoryTypeWorking},
+		},
+	})
+
+	retrieve := func(opts domain.RetrieveOptions) *domain.RetrieveResponse {
+		resp, err := memory.Retrieve(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户的情况", Options: opts})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("results grouped by session", func(t *testing.T) {
+		// 所有记忆向量相同，关闭近重复过滤
+		resp := retrieve(domain.RetrieveOptions{GroupBySession: true, SkipFormatting: true, DedupThreshold: -1})
+
+		groups := make(map[string]domain.SessionGroup)
+		for _, g := range resp.Sessions {
+			groups[g.SessionID] = g
+		}
+		require.Len(t, groups, 2)
+
+		assert.Equal(t, []string{"用户喜欢喝咖啡"}, summaryContents(groups["session_coffee"].Facts))
+		require.Len(t, groups["session_coffee"].Events, 1)
+		assert.Equal(t, "咖啡", groups["session_coffee"].Events[0].Argument2)
+
+		assert.Equal(t, []string{"用户住在上海"}, summaryContents(groups["session_city"].Facts))
+		assert.Equal(t, []string{"用户正在找房子"}, summaryContents(groups["session_city"].WorkingMem))
+		assert.Empty(t, groups["session_city"].Events)
+
+		assert.Len(t, resp.Facts, 2, "flat results are still returned")
+	})
+
+	t.Run("not grouped by default", func(t *testing.T) {
+		assert.Empty(t, retrieve(domain.RetrieveOptions{}).Sessions)
+	})
+}
+
+func summaryContents(summaries []domain.SummaryMemory) []string {
+	var contents []string
+	for _, s := range summaries {
+		contents = append(contents, s.Content)
+	}
+	return contents
+}
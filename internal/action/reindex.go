@@ -0,0 +1,241 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// ReindexBatchSize 重建向量时每批读取的文档数
+const ReindexBatchSize = 100
+
+// ReindexAction 用当前 embedder 重建已存储文档的向量
+// 切换 embedding 模型后，旧向量与新查询向量不在同一空间，召回会静默退化
+type ReindexAction struct {
+	*BaseAction
+
+	vectorStore     vector.Store
+	summaryEmbedder string
+	eventEmbedder   string
+	batchSize       int
+}
+
+// NewReindexAction 创建 ReindexAction
+func NewReindexAction() *ReindexAction {
+	return &ReindexAction{
+		BaseAction:      NewBaseAction("reindex"),
+		vectorStore:     vector.NewStore(),
+		summaryEmbedder: GetConfig().Summary.Embedder,
+		eventEmbedder:   GetConfig().Event.Embedder,
+		batchSize:       ReindexBatchSize,
+	}
+}
+
+// WithStore 设置存储（用于测试注入 mock）
+func (a *ReindexAction) WithStore(store vector.Store) *ReindexAction {
+	a.vectorStore = store
+	return a
+}
+
+// WithBatchSize 设置每批读取的文档数
+func (a *ReindexAction) WithBatchSize(size int) *ReindexAction {
+	if size > 0 {
+		a.batchSize = size
+	}
+	return a
+}
+
+// Execute 分批重建 agent+user 下所有摘要记忆与事件的向量
+// 单个文档失败只计数，读取失败视为存储不可用
+func (a *ReindexAction) Execute(ctx context.Context, agentID, userID string) (*domain.ReindexResponse, error) {
+	a.logger.Info("executing reindex", "agent_id", agentID, "user_id", userID)
+
+	resp := &domain.ReindexResponse{Success: true}
+	if a.vectorStore == nil {
+		return resp, nil
+	}
+
+	summaries, failed, err := a.reindexType(ctx, agentID, userID, domain.DocTypeSummary, embedderOrDefault(a.summaryEmbedder))
+	resp.Summaries, resp.Failed = summaries, failed
+	if err != nil {
+		return nil, err
+	}
+
+	events, failed, err := a.reindexType(ctx, agentID, userID, domain.DocTypeEvent, embedderOrDefault(a.eventEmbedder))
+	resp.Events, resp.Failed = events, resp.Failed+failed
+	if err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("reindex completed",
+		"summaries", resp.Summaries,
+		"events", resp.Events,
+		"failed", resp.Failed,
+	)
+
+	return resp, nil
+}
+
+// reindexType 按 id 分页（search_after）重建一种文档类型的向量，返回成功与失败数
+// 只重建活跃文档（含一致性检查过期、可被恢复的旧 fact），与检索可见的范围一致
+func (a *ReindexAction) reindexType(ctx context.Context, agentID, userID, docType, embedder string) (int, int, error) {
+	scanner, ok := a.vectorStore.(vector.Scanner)
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: vector store does not support reindex", domain.ErrInvalidInput)
+	}
+
+	filters := map[string]any{
+		"type":     docType,
+		"agent_id": agentID,
+		"user_id":  userID,
+	}
+
+	reindexed, failed := 0, 0
+	after := ""
+	for {
+		docs, err := scanner.Scan(ctx, filters, after, a.batchSize)
+		if err != nil {
+			return reindexed, failed, fmt.Errorf("%w: reindex %s: %w", domain.ErrStoreUnavailable, docType, err)
+		}
+		if len(docs) == 0 {
+			return reindexed, failed, nil
+		}
+
+		for _, doc := range docs {
+			if status, _ := doc["status"].(string); status != "" && status != vector.StatusActive {
+				continue
+			}
+			id, _ := doc["id"].(string)
+			if err := a.reindexDoc(ctx, id, embedder, embeddingText(doc)); err != nil {
+				a.logger.Warn("failed to reindex document", "id", id, "type", docType, "error", err)
+				failed++
+				continue
+			}
+			reindexed++
+		}
+		after, _ = docs[len(docs)-1]["id"].(string)
+	}
+}
+
+// reindexDoc 重新生成单个文档的向量并写回
+func (a *ReindexAction) reindexDoc(ctx context.Context, id, embedder, text string) error {
+	if id == "" || text == "" {
+		return fmt.Errorf("document has no id or text")
+	}
+
+	embedding, err := a.GenEmbedding(ctx, embedder, text)
+	if err != nil {
+		return err
+	}
+
+	return a.vectorStore.UpdateFields(ctx, id, map[string]any{
+		"embedding":  embedding,
+		"updated_at": time.Now(),
+	})
+}
+
+// embeddingText 返回写入时用于生成向量的文本，须与 summary/event 写入保持一致
+// 向量化文本与 content 不同时（如带上下文的附件描述）写入时保存在 embedding_text 中
+func embeddingText(doc map[string]any) string {
+	if text, _ := doc["embedding_text"].(string); text != "" {
+		return text
+	}
+	if doc["type"] == domain.DocTypeEvent {
+		arg1, _ := doc["argument1"].(string)
+		trigger, _ := doc["trigger_word"].(string)
+		arg2, _ := doc["argument2"].(string)
+		return arg1 + " " + trigger + " " + arg2
+	}
+
+	content, _ := doc["content"].(string)
+	return content
+}
+
+// Migrate 迁移到新的向量维度：按新维度创建索引，用当前 embedder 重建全部文档的向量后写入，
+// 再原子切换别名。k-NN 字段的 dimension 建索引后不可修改，只能换索引
+// 存储名须是只指向一个索引的别名；迁移期间的新写入仍进入旧索引，不会被复制，应在维护窗口执行
+// 任一文档失败即中止，别名保持不变，新索引保留以便排查
+func (a *ReindexAction) Migrate(ctx context.Context, dim int) (*domain.MigrateIndexResponse, error) {
+	manager, ok := a.vectorStore.(vector.IndexManager)
+	if !ok {
+		return nil, fmt.Errorf("%w: vector store does not support index migration", domain.ErrInvalidInput)
+	}
+
+	alias := manager.IndexName()
+	targets, err := manager.AliasTargets(ctx, alias)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrStoreUnavailable, err)
+	}
+	if len(targets) != 1 {
+		return nil, fmt.Errorf("%w: index %s must be an alias of exactly one index, found %d", domain.ErrInvalidInput, alias, len(targets))
+	}
+
+	resp := &domain.MigrateIndexResponse{
+		OldIndex: targets[0],
+		NewIndex: fmt.Sprintf("%s_%s", alias, time.Now().UTC().Format("20060102150405")),
+	}
+	a.logger.Info("executing index migration", "alias", alias, "old_index", resp.OldIndex, "new_index", resp.NewIndex, "dim", dim)
+
+	if err := manager.CreateIndex(ctx, resp.NewIndex, dim); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrStoreUnavailable, err)
+	}
+	dest := manager.ForIndex(resp.NewIndex)
+
+	after := ""
+	for {
+		docs, err := manager.Scan(ctx, nil, after, a.batchSize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", domain.ErrStoreUnavailable, err)
+		}
+		if len(docs) == 0 {
+			break
+		}
+
+		for _, doc := range docs {
+			if err := a.migrateDoc(ctx, dest, doc, dim); err != nil {
+				return nil, fmt.Errorf("migrate document %v: %w", doc["id"], err)
+			}
+			resp.Migrated++
+		}
+		after, _ = docs[len(docs)-1]["id"].(string)
+	}
+
+	if err := manager.SwapAlias(ctx, alias, resp.OldIndex, resp.NewIndex); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrStoreUnavailable, err)
+	}
+
+	a.logger.Info("index migration completed", "alias", alias, "new_index", resp.NewIndex, "migrated", resp.Migrated)
+
+	resp.Success = true
+	return resp, nil
+}
+
+// migrateDoc 重建单个文档的向量并写入新索引，没有可向量化文本的文档原样复制（不带向量）
+func (a *ReindexAction) migrateDoc(ctx context.Context, dest vector.Store, doc map[string]any, dim int) error {
+	id, _ := doc["id"].(string)
+	if id == "" {
+		return fmt.Errorf("document has no id")
+	}
+
+	delete(doc, "embedding")
+	if text := embeddingText(doc); text != "" {
+		embedder := embedderOrDefault(a.summaryEmbedder)
+		if doc["type"] == domain.DocTypeEvent {
+			embedder = embedderOrDefault(a.eventEmbedder)
+		}
+
+		embedding, err := a.GenEmbedding(ctx, embedder, text)
+		if err != nil {
+			return err
+		}
+		if len(embedding) != dim {
+			return fmt.Errorf("%w: embedder %s returns %d dimensions, want %d", domain.ErrInvalidInput, embedder, len(embedding), dim)
+		}
+		doc["embedding"] = embedding
+	}
+
+	return dest.Store(ctx, id, doc)
+}
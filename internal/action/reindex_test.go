@@ -0,0 +1,226 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+func TestMemory_Reindex(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	oldVec := []float32{0.1, 0.2, 0.3}
+	newVec := []float32{0.9, 0.8, 0.7}
+	helper.SetEmbedderVector(newVec)
+
+	docs := map[string][]map[string]any{
+		domain.DocTypeSummary: {
+			{"id": "mem_1", "type": domain.DocTypeSummary, "content": "用户住在北京", "embedding": oldVec},
+			{"id": "mem_2", "type": domain.DocTypeSummary, "content": "用户喜欢咖啡", "embedding": oldVec},
+			{"id": "mem_3", "type": domain.DocTypeSummary, "content": "", "embedding": oldVec},
+			{"id": "mem_4", "type": domain.DocTypeSummary, "content": "已归档的记忆", "embedding": oldVec, "status": vector.StatusArchived},
+		},
+		domain.DocTypeEvent: {
+			{"id": "evt_1", "type": domain.DocTypeEvent, "trigger_word": "喝", "argument1": "小明", "argument2": "咖啡", "embedding": oldVec},
+		},
+	}
+
+	store := NewMockVectorStore()
+	store.ScanFunc = func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+		var page []map[string]any
+		for _, doc := range docs[filters["type"].(string)] {
+			if doc["id"].(string) > after && len(page) < size {
+				page = append(page, doc)
+			}
+		}
+		return page, nil
+	}
+
+	t.Run("re-embeds every document in batches", func(t *testing.T) {
+		resp, err := NewReindexAction().WithStore(store).WithBatchSize(2).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, resp.Summaries)
+		assert.Equal(t, 1, resp.Events)
+		assert.Equal(t, 1, resp.Failed) // mem_3 没有文本
+
+		require.Len(t, store.UpdateFieldsCalls, 3)
+		for _, call := range store.UpdateFieldsCalls {
+			assert.Equal(t, newVec, call.Fields["embedding"], call.ID)
+		}
+	})
+
+	t.Run("caption re-embeds from the stored embedding text", func(t *testing.T) {
+		var embedded []string
+		helper.SetEmbedderFunc(func(text string) []float32 {
+			embedded = append(embedded, text)
+			return newVec
+		})
+
+		captions := NewMockVectorStore()
+		captions.ScanFunc = func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+			if filters["type"] != domain.DocTypeSummary || after != "" {
+				return nil, nil
+			}
+			return []map[string]any{
+				{"id": "mem_cat", "type": domain.DocTypeSummary, "content": "一只橘猫趴在沙发上", "embedding_text": "小明: 看看我家的猫 [image: 一只橘猫趴在沙发上]"},
+			}, nil
+		}
+
+		_, err := NewReindexAction().WithStore(captions).Execute(ctx, "agent_1", "user_1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"小明: 看看我家的猫 [image: 一只橘猫趴在沙发上]"}, embedded)
+	})
+
+	t.Run("search failure reports store unavailable", func(t *testing.T) {
+		failing := NewMockVectorStore()
+		failing.ScanFunc = func(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+			return nil, errors.New("opensearch unavailable")
+		}
+
+		_, err := NewMemory().WithStores(failing, NewMockRelationStore()).Reindex(ctx, &domain.ReindexRequest{AgentID: "agent_1", UserID: "user_1"})
+		assert.ErrorIs(t, err, domain.ErrStoreUnavailable)
+	})
+}
+
+// mockIndexManager 在 MockVectorStore 之上模拟别名与多索引
+type mockIndexManager struct {
+	*MockVectorStore
+
+	alias   string
+	indices map[string]*MockVectorStore
+	targets []string
+	docs    []map[string]any // 旧索引中的文档
+}
+
+func newMockIndexManager(alias, index string, docs []map[string]any) *mockIndexManager {
+	return &mockIndexManager{
+		MockVectorStore: NewMockVectorStore(),
+		alias:           alias,
+		indices:         map[string]*MockVectorStore{index: NewMockVectorStore()},
+		targets:         []string{index},
+		docs:            docs,
+	}
+}
+
+func (m *mockIndexManager) IndexName() string { return m.alias }
+
+func (m *mockIndexManager) CreateIndex(ctx context.Context, name string, dim int) error {
+	m.indices[name] = NewMockVectorStore()
+	return nil
+}
+
+func (m *mockIndexManager) AliasTargets(ctx context.Context, alias string) ([]string, error) {
+	return m.targets, nil
+}
+
+func (m *mockIndexManager) SwapAlias(ctx context.Context, alias, oldIndex, newIndex string) error {
+	m.targets = []string{newIndex}
+	return nil
+}
+
+func (m *mockIndexManager) Scan(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+	var page []map[string]any
+	for _, doc := range m.docs {
+		if doc["id"].(string) > after && len(page) < size {
+			page = append(page, doc)
+		}
+	}
+	return page, nil
+}
+
+func (m *mockIndexManager) ForIndex(name string) vector.Store { return m.indices[name] }
+
+func TestMemory_MigrateIndex(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	newVec := []float32{0.1, 0.2, 0.3, 0.4}
+	helper.SetEmbedderVector(newVec)
+
+	newDocs := func() []map[string]any {
+		old := []float32{0.1, 0.2, 0.3}
+		return []map[string]any{
+			{"id": "evt_1", "type": domain.DocTypeEvent, "trigger_word": "喝", "argument1": "小明", "argument2": "咖啡", "embedding": old},
+			{"id": "mem_1", "type": domain.DocTypeSummary, "content": "用户住在北京", "status": vector.StatusActive, "embedding": old},
+			{"id": "mem_2", "type": domain.DocTypeSummary, "content": "用户住在上海", "status": vector.StatusArchived, "embedding": old},
+		}
+	}
+
+	t.Run("documents survive with new dimension", func(t *testing.T) {
+		manager := newMockIndexManager("memories", "memories_v1", newDocs())
+
+		resp, err := NewMemory().WithStores(manager, nil).MigrateIndex(ctx, &domain.MigrateIndexRequest{EmbeddingDim: 4})
+		require.NoError(t, err)
+
+		assert.Equal(t, "memories_v1", resp.OldIndex)
+		assert.Equal(t, 3, resp.Migrated)
+		assert.Equal(t, []string{resp.NewIndex}, manager.targets)
+
+		dest := manager.indices[resp.NewIndex]
+		require.Len(t, dest.StoreCalls, 3)
+		for _, call := range dest.StoreCalls {
+			assert.Equal(t, newVec, call.Doc["embedding"], call.ID)
+		}
+		assert.Equal(t, vector.StatusArchived, dest.StoreCalls[2].Doc["status"])
+	})
+
+	t.Run("dimension mismatch keeps the alias", func(t *testing.T) {
+		manager := newMockIndexManager("memories", "memories_v1", newDocs())
+
+		_, err := NewMemory().WithStores(manager, nil).MigrateIndex(ctx, &domain.MigrateIndexRequest{EmbeddingDim: 8})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+		assert.Equal(t, []string{"memories_v1"}, manager.targets)
+	})
+
+	t.Run("plain index is rejected", func(t *testing.T) {
+		manager := newMockIndexManager("memories", "memories_v1", newDocs())
+		manager.targets = nil
+
+		_, err := NewMemory().WithStores(manager, nil).MigrateIndex(ctx, &domain.MigrateIndexRequest{EmbeddingDim: 4})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+// TestMemory_MigrateIndexOpenSearch 在真实 OpenSearch 上验证维度迁移，未设置 MEMORY_TEST_OPENSEARCH_URL 时跳过
+func TestMemory_MigrateIndexOpenSearch(t *testing.T) {
+	url := os.Getenv("MEMORY_TEST_OPENSEARCH_URL")
+	if url == "" {
+		t.Skip("MEMORY_TEST_OPENSEARCH_URL not set")
+	}
+
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	alias := fmt.Sprintf("memory_migrate_test_%d", time.Now().UnixNano())
+	store, err := vector.NewOpenSearchStore(vector.OpenSearchConfig{Addresses: []string{url}, IndexName: alias, EmbeddingDim: 3, Refresh: vector.RefreshTrue})
+	require.NoError(t, err)
+
+	require.NoError(t, store.CreateIndex(ctx, alias+"_v1", 3))
+	require.NoError(t, store.SwapAlias(ctx, alias, "", alias+"_v1"))
+	require.NoError(t, store.Store(ctx, "mem_1", map[string]any{
+		"id": "mem_1", "type": domain.DocTypeSummary, "agent_id": "agent_1", "user_id": "user_1",
+		"content": "用户住在北京", "embedding": []float32{0.1, 0.2, 0.3}, "created_at": time.Now(),
+	}))
+
+	newVec := []float32{0.1, 0.2, 0.3, 0.4}
+	helper.SetEmbedderVector(newVec)
+
+	resp, err := NewMemory().WithStores(store, nil).MigrateIndex(ctx, &domain.MigrateIndexRequest{EmbeddingDim: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Migrated)
+
+	docs, err := store.Search(ctx, vector.SearchQuery{Embedding: newVec, Filters: map[string]any{"agent_id": "agent_1", "user_id": "user_1"}})
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "用户住在北京", docs[0]["content"])
+}
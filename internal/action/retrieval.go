@@ -1,13 +1,15 @@
 package action
 
 import (
-	"context"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
 	"github.com/Zereker/memory/pkg/vector"
 )
 
@@ -25,6 +27,31 @@ const (
 
 	// token 估算系数（中文约 1.5 字符/token）
 	CharsPerToken = 1.5
+
+	// 事件链扩展默认上限
+	DefaultMaxHops              = 1
+	DefaultMaxNeighborsPerEvent = 3
+	DefaultMaxChainEvents       = 10
+
+	// 扩展事件的分数按跳数衰减：邻居分数 = 来源事件分数 × ChainScoreDecay
+	ChainScoreDecay = 0.5
+
+	// 摘要记忆近重复判定的默认余弦相似度
+	DefaultDedupThreshold = 0.95
+
+	// 时间衰减的默认半衰期：写入 7 天后衰减因子为 0.5
+	DefaultRecencyHalfLifeHours = 168
+)
+
+// 检索阶段（用于 Explain 调试信息）
+const (
+	stageFactSearch        = "fact_search"
+	stageWorkingSearch     = "working_search"
+	stageEventSearch       = "event_search"
+	stageFactRedistribute  = "fact_redistribute"
+	stageEventRedistribute = "event_redistribute"
+	stageEventChain        = "event_chain"
+	stageDedup             = "dedup"
 )
 
 // 确保实现 domain.RecallAction 接口
@@ -35,20 +62,36 @@ var _ domain.RecallAction = (*CognitiveRetrievalAction)(nil)
 type CognitiveRetrievalAction struct {
 	*BaseAction
 
-	vectorStore vector.Store
+	vectorStore   vector.Store
+	relationStore relation.Store
+
+	// 查询向量须与文档使用同一 embedder
+	summaryEmbedder string
+	eventEmbedder   string
 }
 
 // NewCognitiveRetrievalAction 创建 CognitiveRetrievalAction
 func NewCognitiveRetrievalAction() *CognitiveRetrievalAction {
 	return &CognitiveRetrievalAction{
-		BaseAction:  NewBaseAction("cognitive_retrieval"),
-		vectorStore: vector.NewStore(),
+		BaseAction:      NewBaseAction(ActionCognitiveRetrieval),
+		vectorStore:     vector.NewStore(),
+		relationStore:   relation.NewStore(),
+		summaryEmbedder: GetConfig().Summary.Embedder,
+		eventEmbedder:   GetConfig().Event.Embedder,
 	}
 }
 
+// WithEmbedders 设置摘要记忆与事件的查询 embedder
+func (a *CognitiveRetrievalAction) WithEmbedders(summary, event string) *CognitiveRetrievalAction {
+	a.summaryEmbedder = summary
+	a.eventEmbedder = event
+	return a
+}
+
 // WithStores 设置存储（用于测试注入 mock）
-func (a *CognitiveRetrievalAction) WithStores(v vector.Store) *CognitiveRetrievalAction {
+func (a *CognitiveRetrievalAction) WithStores(v vector.Store, r relation.Store) *CognitiveRetrievalAction {
 	a.vectorStore = v
+	a.relationStore = r
 	return a
 }
 
@@ -67,6 +110,53 @@ type tokenBudget struct {
 	factUsed    int
 	graphUsed   int
 	workingUsed int
+
+	// 各类结果检索命中的候选 ID，用于 RetrieveMeta
+	found map[string]map[string]bool
+}
+
+// markFound 记录检索命中的候选
+func (b *tokenBudget) markFound(itemType string, docs []map[string]any) {
+	ids := b.found[itemType]
+	if ids == nil {
+		ids = make(map[string]bool, len(docs))
+		b.found[itemType] = ids
+	}
+	for _, doc := range docs {
+		if id, ok := doc["id"].(string); ok {
+			ids[id] = true
+		}
+	}
+}
+
+// meta 汇总各类结果的统计
+// 命中但既未返回、也不是被近重复过滤掉的候选，只可能是因预算不足被截断
+func (b *tokenBudget) meta(c *domain.RecallContext, deduped map[string]bool) *domain.RetrieveMeta {
+	typeMeta := func(itemType string, returned []string) domain.RetrieveTypeMeta {
+		kept := make(map[string]bool, len(returned))
+		for _, id := range returned {
+			kept[id] = true
+		}
+
+		m := domain.RetrieveTypeMeta{Found: len(b.found[itemType]), Returned: len(returned)}
+		for id := range b.found[itemType] {
+			if !kept[id] && !deduped[id] {
+				m.Truncated = true
+				break
+			}
+		}
+		return m
+	}
+
+	eventIDs := make([]string, 0, len(c.Events))
+	for _, e := range c.Events {
+		eventIDs = append(eventIDs, e.ID)
+	}
+	return &domain.RetrieveMeta{
+		Facts:   typeMeta(domain.MemoryTypeFact, summaryIDs(c.Facts)),
+		Working: typeMeta(domain.MemoryTypeWorking, summaryIDs(c.WorkingMem)),
+		Events:  typeMeta(domain.DocTypeEvent, eventIDs),
+	}
 }
 
 // HandleRecall 执行认知检索
@@ -74,32 +164,55 @@ type tokenBudget struct {
 func (a *CognitiveRetrievalAction) HandleRecall(c *domain.RecallContext) {
 	a.logger.Info("executing", "query", c.Query, "limit", c.Limit)
 
-	// 1. 生成查询向量
-	embedding, err := a.GenEmbedding(c.Context, EmbedderName, c.Query)
+	// 1. 生成查询向量（事件 embedder 不同时单独生成事件查询向量）
+	summaryEmbedder := embedderOrDefault(a.summaryEmbedder)
+	embedding, err := a.GenEmbedding(c.Context, summaryEmbedder, c.Query)
 	if err != nil {
 		a.logger.Error("failed to generate query embedding", "error", err)
-		c.Next()
+		a.embeddingUnavailable(c, err)
 		return
 	}
 	c.Embedding = embedding
+	c.EventEmbedding = embedding
+
+	if eventEmbedder := embedderOrDefault(a.eventEmbedder); eventEmbedder != summaryEmbedder {
+		eventEmbedding, err := a.GenEmbedding(c.Context, eventEmbedder, c.Query)
+		if err != nil {
+			a.logger.Error("failed to generate event query embedding", "error", err)
+			a.embeddingUnavailable(c, err)
+			return
+		}
+		c.EventEmbedding = eventEmbedding
+	}
 
 	// 2. 初始化 3-Bucket 预算
 	budget := a.initBudget(c)
 
-	// 3. Step 1: 保底填充 Graph 桶（强制 400 tokens）
+	// 3. Step 1: 保底填充 Graph 桶（强制 400 tokens），并沿因果/时序关系扩展
 	a.searchEvents(c, budget)
+	a.expandEventChains(c, budget)
 
 	// 4. Step 2: 优先级贪婪填充
 	// Fact 桶
 	a.searchFactMemories(c, budget)
 
+	// 没有 fact（如新用户）时释放 Fact 桶给低优先级的桶
+	a.releaseEmptyFactBucket(c, budget)
+
 	// Working 桶
 	a.searchWorkingMemories(c, budget)
 
 	// 5. Step 3: 未用空间再分配
 	a.redistributeUnused(c, budget)
 
-	// 6. 异步更新 access_count 和 last_accessed_at
+	// 6. 近重复过滤
+	deduped := a.dedupSummaries(c)
+
+	// 7. 上层摘要附带源摘要
+	a.expandSources(c, budget)
+	c.Meta = budget.meta(c, deduped)
+
+	// 8. 异步更新 access_count 和 last_accessed_at
 	go a.updateAccessStats(c)
 
 	a.logger.Info("cognitive retrieval completed",
@@ -114,6 +227,18 @@ func (a *CognitiveRetrievalAction) HandleRecall(c *domain.RecallContext) {
 	c.Next()
 }
 
+// embeddingUnavailable 查询向量生成失败时降级为只返回短期记忆，链继续执行
+// 短期窗口也为空、没有任何结果可返回时才终止链
+func (a *CognitiveRetrievalAction) embeddingUnavailable(c *domain.RecallContext, err error) {
+	if len(c.ShortTerm) == 0 {
+		c.SetError(err)
+		return
+	}
+	c.Degraded = true
+	c.AddWarning("%s: query embedding unavailable, returning short-term memory only: %v", a.Name(), err)
+	c.Next()
+}
+
 // initBudget 初始化 3-Bucket 预算
 func (a *CognitiveRetrievalAction) initBudget(c *domain.RecallContext) *tokenBudget {
 	budget := &tokenBudget{
@@ -121,6 +246,8 @@ func (a *CognitiveRetrievalAction) initBudget(c *domain.RecallContext) *tokenBud
 		fact:    DefaultFactTokens,
 		graph:   DefaultGraphTokens,
 		working: DefaultWorkingTokens,
+
+		found: make(map[string]map[string]bool),
 	}
 
 	if c.Options.MaxTokens > 0 {
@@ -165,21 +292,21 @@ func (a *CognitiveRetrievalAction) searchFactMemories(c *domain.RecallContext, b
 	}
 
 	docs, err := a.vectorStore.Search(c.Context, vector.SearchQuery{
-		Embedding: c.Embedding,
-		Filters: map[string]any{
-			"type":        domain.DocTypeSummary,
-			"memory_type": domain.MemoryTypeFact,
-			"agent_id":    c.AgentID,
-			"user_id":     c.UserID,
-		},
-		Limit: c.Limit,
+		Embedding:      c.Embedding,
+		Filters:        summaryFilters(c, domain.MemoryTypeFact),
+		MissingFields:  []string{"expired_at"}, // 一致性检查过期的旧 fact 不再召回
+		ScoreThreshold: c.Options.FactThreshold,
+		Limit:          c.Limit,
 	})
 	if err != nil {
 		a.logger.Warn("fact search failed", "error", err)
 		return
 	}
+	budget.markFound(domain.MemoryTypeFact, docs)
+	applyRecency(c, docs)
 
-	for _, doc := range docs {
+	top := maxScore(docs)
+	for i, doc := range docs {
 		s := a.DocToSummaryMemory(doc)
 		if score, ok := doc["_score"].(float64); ok {
 			s.Score = score
@@ -187,14 +314,30 @@ func (a *CognitiveRetrievalAction) searchFactMemories(c *domain.RecallContext, b
 
 		tokens := estimateTokens(s.Content)
 		if budget.factUsed+tokens > budget.fact {
+			a.explainTruncated(c, docs[i:], domain.MemoryTypeFact, stageFactSearch, top)
 			break
 		}
 
 		c.Facts = append(c.Facts, *s)
 		budget.factUsed += tokens
+		c.AddDebug(newRetrievalDebug(s.ID, domain.MemoryTypeFact, stageFactSearch, s.Score, top, tokens, false))
 	}
 }
 
+// summaryFilters 返回摘要记忆检索的等值过滤条件，设置 Sentiment 时按情感倾向过滤
+func summaryFilters(c *domain.RecallContext, memoryType string) map[string]any {
+	filters := map[string]any{
+		"type":        domain.DocTypeSummary,
+		"memory_type": memoryType,
+		"agent_id":    c.AgentID,
+		"user_id":     c.UserID,
+	}
+	if c.Options.Sentiment != "" {
+		filters["sentiment"] = c.Options.Sentiment
+	}
+	return filters
+}
+
 // searchWorkingMemories 检索 working 类型记忆
 func (a *CognitiveRetrievalAction) searchWorkingMemories(c *domain.RecallContext, budget *tokenBudget) {
 	if a.vectorStore == nil || budget.working <= 0 {
@@ -202,21 +345,20 @@ func (a *CognitiveRetrievalAction) searchWorkingMemories(c *domain.RecallContext
 	}
 
 	docs, err := a.vectorStore.Search(c.Context, vector.SearchQuery{
-		Embedding: c.Embedding,
-		Filters: map[string]any{
-			"type":        domain.DocTypeSummary,
-			"memory_type": domain.MemoryTypeWorking,
-			"agent_id":    c.AgentID,
-			"user_id":     c.UserID,
-		},
-		Limit: c.Limit,
+		Embedding:      c.Embedding,
+		Filters:        summaryFilters(c, domain.MemoryTypeWorking),
+		ScoreThreshold: c.Options.WorkingThreshold,
+		Limit:          c.Limit,
 	})
 	if err != nil {
 		a.logger.Warn("working memory search failed", "error", err)
 		return
 	}
+	budget.markFound(domain.MemoryTypeWorking, docs)
+	applyRecency(c, docs)
 
-	for _, doc := range docs {
+	top := maxScore(docs)
+	for i, doc := range docs {
 		s := a.DocToSummaryMemory(doc)
 		if score, ok := doc["_score"].(float64); ok {
 			s.Score = score
@@ -224,11 +366,13 @@ func (a *CognitiveRetrievalAction) searchWorkingMemories(c *domain.RecallContext
 
 		tokens := estimateTokens(s.Content)
 		if budget.workingUsed+tokens > budget.working {
+			a.explainTruncated(c, docs[i:], domain.MemoryTypeWorking, stageWorkingSearch, top)
 			break
 		}
 
 		c.WorkingMem = append(c.WorkingMem, *s)
 		budget.workingUsed += tokens
+		c.AddDebug(newRetrievalDebug(s.ID, domain.MemoryTypeWorking, stageWorkingSearch, s.Score, top, tokens, false))
 	}
 }
 
@@ -240,20 +384,24 @@ func (a *CognitiveRetrievalAction) searchEvents(c *domain.RecallContext, budget
 
 	// 从 OpenSearch 用触发词向量检索
 	docs, err := a.vectorStore.Search(c.Context, vector.SearchQuery{
-		Embedding: c.Embedding,
+		Embedding: c.EventEmbedding,
 		Filters: map[string]any{
 			"type":     domain.DocTypeEvent,
 			"agent_id": c.AgentID,
 			"user_id":  c.UserID,
 		},
-		Limit: c.Limit,
+		ScoreThreshold: c.Options.EventThreshold,
+		Limit:          c.Limit,
 	})
 	if err != nil {
 		a.logger.Warn("event search failed", "error", err)
 		return
 	}
+	budget.markFound(domain.DocTypeEvent, docs)
+	applyRecency(c, docs)
 
-	for _, doc := range docs {
+	top := maxScore(docs)
+	for i, doc := range docs {
 		e := a.DocToEventTriplet(doc)
 		if score, ok := doc["_score"].(float64); ok {
 			e.Score = score
@@ -262,14 +410,340 @@ func (a *CognitiveRetrievalAction) searchEvents(c *domain.RecallContext, budget
 		eventText := e.Argument1 + e.TriggerWord + e.Argument2
 		tokens := estimateTokens(eventText)
 		if budget.graphUsed+tokens > budget.graph {
+			a.explainTruncated(c, docs[i:], domain.DocTypeEvent, stageEventSearch, top)
 			break
 		}
 
 		c.Events = append(c.Events, *e)
 		budget.graphUsed += tokens
+		c.AddDebug(newRetrievalDebug(e.ID, domain.DocTypeEvent, stageEventSearch, e.Score, top, tokens, false))
 	}
 }
 
+// expandEventChains 沿 PostgreSQL 中的因果/时序关系逐跳扩展已召回事件
+// 近跳优先，同一跳内按来源事件分数从高到低扩展；每个事件最多扩展 MaxNeighborsPerEvent 个邻居，
+// 新增事件总数不超过 MaxChainEvents。邻居分数按跳数从来源事件衰减，
+// 同样占用 Graph 桶预算，预算不足时高分邻居优先保留。只保留两端都在结果中的关系
+// 关系存储不可用时降级为仅向量检索的事件，并在响应中给出警告
+func (a *CognitiveRetrievalAction) expandEventChains(c *domain.RecallContext, budget *tokenBudget) {
+	if a.vectorStore == nil || len(c.Events) == 0 {
+		return
+	}
+	if a.relationStore == nil {
+		a.degrade(c, "relation store not configured")
+		return
+	}
+
+	limits := newChainLimits(c.Options)
+
+	known := make(map[string]bool, len(c.Events))
+	scores := make(map[string]float64, len(c.Events))
+	frontier := make([]string, 0, len(c.Events))
+	for _, e := range c.Events {
+		known[e.ID] = true
+		scores[e.ID] = e.Score
+		frontier = append(frontier, e.ID)
+	}
+
+	seenRelations := make(map[string]bool)
+	var relations []domain.EventRelation
+	added := 0
+
+	for hop := 1; hop <= limits.maxHops && len(frontier) > 0 && added < limits.maxEvents; hop++ {
+		var neighborIDs []string
+
+		for _, id := range frontier {
+			rels, err := a.relationStore.FindByEventID(c.Context, id)
+			if err != nil {
+				a.logger.Warn("find event relations failed", "event_id", id, "error", err)
+				a.degrade(c, err.Error())
+				frontier = nil
+				break
+			}
+
+			expanded := 0
+			for _, rel := range rels {
+				if seenRelations[rel.ID] {
+					continue
+				}
+				seenRelations[rel.ID] = true
+
+				relations = append(relations, domain.EventRelation{
+					ID:           rel.ID,
+					RelationType: rel.RelationType,
+					FromEventID:  rel.FromEventID,
+					ToEventID:    rel.ToEventID,
+					CreatedAt:    rel.CreatedAt,
+				})
+
+				neighbor := rel.ToEventID
+				if neighbor == id {
+					neighbor = rel.FromEventID
+				}
+				if known[neighbor] || expanded >= limits.perEvent || added >= limits.maxEvents {
+					continue
+				}
+				known[neighbor] = true
+				scores[neighbor] = scores[id] * ChainScoreDecay
+				neighborIDs = append(neighborIDs, neighbor)
+				expanded++
+				added++
+			}
+		}
+
+		if len(neighborIDs) == 0 {
+			break
+		}
+		// 只有实际放入结果的邻居才继续向下一跳扩展
+		loaded := a.fetchNeighborEvents(c, budget, neighborIDs, scores)
+		if frontier == nil {
+			break
+		}
+		frontier = loaded
+	}
+
+	present := make(map[string]bool, len(c.Events))
+	for _, e := range c.Events {
+		present[e.ID] = true
+	}
+
+	for _, rel := range relations {
+		if present[rel.FromEventID] && present[rel.ToEventID] {
+			c.EventRelations = append(c.EventRelations, rel)
+		}
+	}
+}
+
+// chainLimits 事件链扩展的上限
+type chainLimits struct {
+	maxHops   int
+	perEvent  int
+	maxEvents int
+}
+
+// newChainLimits 按检索选项生成扩展上限，未设置的使用默认值
+func newChainLimits(opts domain.RetrieveOptions) chainLimits {
+	limits := chainLimits{
+		maxHops:   DefaultMaxHops,
+		perEvent:  DefaultMaxNeighborsPerEvent,
+		maxEvents: DefaultMaxChainEvents,
+	}
+	if opts.MaxHops > 0 {
+		limits.maxHops = opts.MaxHops
+	}
+	if opts.MaxNeighborsPerEvent > 0 {
+		limits.perEvent = opts.MaxNeighborsPerEvent
+	}
+	if opts.MaxChainEvents > 0 {
+		limits.maxEvents = opts.MaxChainEvents
+	}
+	return limits
+}
+
+// degrade 标记降级模式：事件保留向量检索结果，不再沿关系扩展
+func (a *CognitiveRetrievalAction) degrade(c *domain.RecallContext, reason string) {
+	c.Degraded = true
+	c.AddWarning("%s: event relations unavailable, returning vector-only events: %s", a.Name(), reason)
+}
+
+// fetchNeighborEvents 按 ID 加载邻居事件，按衰减后的分数从高到低填入 Graph 桶
+// 返回实际放入结果的事件 ID（按分数排序）
+func (a *CognitiveRetrievalAction) fetchNeighborEvents(c *domain.RecallContext, budget *tokenBudget, ids []string, scores map[string]float64) []string {
+	docs, err := a.vectorStore.Search(c.Context, vector.SearchQuery{
+		Filters: map[string]any{
+			"type":     domain.DocTypeEvent,
+			"agent_id": c.AgentID,
+			"user_id":  c.UserID,
+		},
+		TermsFilters: map[string][]string{"id": ids},
+		Limit:        len(ids),
+	})
+	if err != nil {
+		a.logger.Warn("neighbor event search failed", "error", err)
+		return nil
+	}
+	budget.markFound(domain.DocTypeEvent, docs)
+
+	for _, doc := range docs {
+		id, _ := doc["id"].(string)
+		doc["_score"] = scores[id]
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i]["_score"].(float64) > docs[j]["_score"].(float64)
+	})
+
+	var loaded []string
+
+	for i, doc := range docs {
+		e := a.DocToEventTriplet(doc)
+		e.Score = doc["_score"].(float64)
+
+		tokens := estimateTokens(e.Argument1 + e.TriggerWord + e.Argument2)
+		if budget.graphUsed+tokens > budget.graph {
+			a.explainTruncated(c, docs[i:], domain.DocTypeEvent, stageEventChain, 0)
+			break
+		}
+
+		c.Events = append(c.Events, *e)
+		budget.graphUsed += tokens
+		loaded = append(loaded, e.ID)
+		c.AddDebug(newRetrievalDebug(e.ID, domain.DocTypeEvent, stageEventChain, e.Score, 0, tokens, false))
+	}
+	return loaded
+}
+
+// dedupSummaries 过滤 Fact 与 Working 结果中的近重复摘要记忆，每组只保留分数最高的一条
+// 两条都有同维度向量时按余弦相似度判定，内容归一化后相同也视为重复；被过滤的条目释放的预算不再回填
+// 返回被过滤的 ID
+func (a *CognitiveRetrievalAction) dedupSummaries(c *domain.RecallContext) map[string]bool {
+	threshold := c.Options.DedupThreshold
+	if threshold < 0 || len(c.Facts)+len(c.WorkingMem) < 2 {
+		return nil
+	}
+	if threshold == 0 {
+		threshold = DefaultDedupThreshold
+	}
+
+	type candidate struct {
+		mem *domain.SummaryMemory
+		vec NormedVector
+		key string
+	}
+
+	candidates := make([]candidate, 0, len(c.Facts)+len(c.WorkingMem))
+	for _, list := range [][]domain.SummaryMemory{c.Facts, c.WorkingMem} {
+		for i := range list {
+			candidates = append(candidates, candidate{
+				mem: &list[i],
+				vec: NewNormedVector(list[i].Embedding),
+				key: strings.Join(strings.Fields(strings.ToLower(list[i].Content)), ""),
+			})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].mem.Score > candidates[j].mem.Score
+	})
+
+	dropped := make(map[string]bool)
+	var kept []candidate
+	for _, cand := range candidates {
+		duplicate := false
+		for _, k := range kept {
+			if (cand.key != "" && cand.key == k.key) || cand.vec.Similarity(k.vec) >= threshold {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, cand)
+			continue
+		}
+
+		dropped[cand.mem.ID] = true
+		d := newRetrievalDebug(cand.mem.ID, cand.mem.MemoryType, stageDedup, cand.mem.Score, 0, estimateTokens(cand.mem.Content), true)
+		d.Truncated = false
+		c.AddDebug(d)
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	c.Facts = withoutSummaries(c.Facts, dropped)
+	c.WorkingMem = withoutSummaries(c.WorkingMem, dropped)
+	a.logger.Debug("near-duplicate summaries dropped", "count", len(dropped))
+	return dropped
+}
+
+// expandSources 为上层摘要附带最多 ExpandSources 条源摘要（ChildIDs 按顺序），便于调用方追溯原始记忆
+// 源摘要占用所在桶的剩余预算，预算不足时停止展开该桶；存储不支持批量读取时跳过
+func (a *CognitiveRetrievalAction) expandSources(c *domain.RecallContext, budget *tokenBudget) {
+	n := c.Options.ExpandSources
+	if n <= 0 || a.vectorStore == nil {
+		return
+	}
+	getter, ok := a.vectorStore.(vector.MultiGetter)
+	if !ok {
+		a.logger.Debug("store does not support mget, sources not expanded")
+		return
+	}
+
+	sourceIDs := func(s domain.SummaryMemory) []string {
+		return s.ChildIDs[:min(n, len(s.ChildIDs))]
+	}
+
+	var ids []string
+	for _, list := range [][]domain.SummaryMemory{c.Facts, c.WorkingMem} {
+		for _, s := range list {
+			ids = append(ids, sourceIDs(s)...)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	docs, err := getter.MGet(c.Context, ids)
+	if err != nil {
+		a.logger.Warn("source summary fetch failed", "error", err)
+		return
+	}
+
+	attach := func(list []domain.SummaryMemory, used *int, quota int) {
+		for i := range list {
+			for _, id := range sourceIDs(list[i]) {
+				doc, ok := docs[id]
+				if !ok {
+					continue
+				}
+				if status, _ := doc["status"].(string); status != "" && status != vector.StatusActive {
+					continue
+				}
+
+				source := a.DocToSummaryMemory(doc)
+				tokens := estimateTokens(source.Content)
+				if *used+tokens > quota {
+					return
+				}
+				list[i].Sources = append(list[i].Sources, *source)
+				*used += tokens
+			}
+		}
+	}
+	attach(c.Facts, &budget.factUsed, budget.fact)
+	attach(c.WorkingMem, &budget.workingUsed, budget.working)
+}
+
+// summaryIDs 返回摘要记忆的 ID 列表，保持原顺序
+func summaryIDs(list []domain.SummaryMemory) []string {
+	ids := make([]string, 0, len(list))
+	for _, s := range list {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// withoutSummaries 返回去掉 dropped 中条目后的列表，保持原顺序
+func withoutSummaries(list []domain.SummaryMemory, dropped map[string]bool) []domain.SummaryMemory {
+	kept := list[:0:0]
+	for _, s := range list {
+		if !dropped[s.ID] {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// releaseEmptyFactBucket 没有召回任何 fact 时，把 Fact 桶配额转给 Working 桶
+// 随后 Working 桶仍用不完的部分在 redistributeUnused 中留给被截断的事件
+func (a *CognitiveRetrievalAction) releaseEmptyFactBucket(c *domain.RecallContext, budget *tokenBudget) {
+	if len(c.Facts) > 0 || budget.fact <= 0 {
+		return
+	}
+
+	a.logger.Debug("no facts recalled, releasing fact budget", "tokens", budget.fact)
+	budget.working += budget.fact
+	budget.fact = 0
+}
+
 // redistributeUnused 将未用空间再分配
 func (a *CognitiveRetrievalAction) redistributeUnused(c *domain.RecallContext, budget *tokenBudget) {
 	// 计算各桶剩余
@@ -286,6 +760,62 @@ func (a *CognitiveRetrievalAction) redistributeUnused(c *domain.RecallContext, b
 	// 优先补充 Fact
 	if factRemain > 0 && a.vectorStore != nil {
 		a.searchMoreFactMemories(c, budget, factRemain+graphRemain+workingRemain)
+		return
+	}
+
+	// Fact 桶已释放时再检索 fact 只会落空，剩余空间补充事件
+	if len(c.Facts) == 0 && a.vectorStore != nil {
+		a.searchMoreEvents(c, budget, totalRemain)
+	}
+}
+
+// searchMoreEvents 使用剩余预算补充 Graph 桶截断的事件
+func (a *CognitiveRetrievalAction) searchMoreEvents(c *domain.RecallContext, budget *tokenBudget, extraBudget int) {
+	if a.vectorStore == nil || extraBudget <= 0 {
+		return
+	}
+
+	docs, err := a.vectorStore.Search(c.Context, vector.SearchQuery{
+		Embedding: c.EventEmbedding,
+		Filters: map[string]any{
+			"type":     domain.DocTypeEvent,
+			"agent_id": c.AgentID,
+			"user_id":  c.UserID,
+		},
+		ScoreThreshold: c.Options.EventThreshold,
+		Limit:          c.Limit * 2,
+	})
+	if err != nil {
+		return
+	}
+	budget.markFound(domain.DocTypeEvent, docs)
+	applyRecency(c, docs)
+
+	seen := make(map[string]bool, len(c.Events))
+	for _, e := range c.Events {
+		seen[e.ID] = true
+	}
+
+	used := 0
+	top := maxScore(docs)
+	for i, doc := range docs {
+		e := a.DocToEventTriplet(doc)
+		if seen[e.ID] {
+			continue
+		}
+		if score, ok := doc["_score"].(float64); ok {
+			e.Score = score
+		}
+
+		tokens := estimateTokens(e.Argument1 + e.TriggerWord + e.Argument2)
+		if used+tokens > extraBudget {
+			a.explainTruncated(c, excludeSeen(docs[i:], seen), domain.DocTypeEvent, stageEventRedistribute, top)
+			break
+		}
+
+		c.Events = append(c.Events, *e)
+		used += tokens
+		c.AddDebug(newRetrievalDebug(e.ID, domain.DocTypeEvent, stageEventRedistribute, e.Score, top, tokens, false))
 	}
 }
 
@@ -297,18 +827,17 @@ func (a *CognitiveRetrievalAction) searchMoreFactMemories(c *domain.RecallContex
 
 	// 搜索更多（跳过已有的）
 	docs, err := a.vectorStore.Search(c.Context, vector.SearchQuery{
-		Embedding: c.Embedding,
-		Filters: map[string]any{
-			"type":        domain.DocTypeSummary,
-			"memory_type": domain.MemoryTypeFact,
-			"agent_id":    c.AgentID,
-			"user_id":     c.UserID,
-		},
-		Limit: c.Limit * 2,
+		Embedding:      c.Embedding,
+		Filters:        summaryFilters(c, domain.MemoryTypeFact),
+		MissingFields:  []string{"expired_at"},
+		ScoreThreshold: c.Options.FactThreshold,
+		Limit:          c.Limit * 2,
 	})
 	if err != nil {
 		return
 	}
+	budget.markFound(domain.MemoryTypeFact, docs)
+	applyRecency(c, docs)
 
 	seen := make(map[string]bool, len(c.Facts))
 	for _, f := range c.Facts {
@@ -316,7 +845,8 @@ func (a *CognitiveRetrievalAction) searchMoreFactMemories(c *domain.RecallContex
 	}
 
 	used := 0
-	for _, doc := range docs {
+	top := maxScore(docs)
+	for i, doc := range docs {
 		s := a.DocToSummaryMemory(doc)
 		if seen[s.ID] {
 			continue
@@ -327,23 +857,126 @@ func (a *CognitiveRetrievalAction) searchMoreFactMemories(c *domain.RecallContex
 
 		tokens := estimateTokens(s.Content)
 		if used+tokens > extraBudget {
+			a.explainTruncated(c, excludeSeen(docs[i:], seen), domain.MemoryTypeFact, stageFactRedistribute, top)
 			break
 		}
 
 		c.Facts = append(c.Facts, *s)
 		used += tokens
+		c.AddDebug(newRetrievalDebug(s.ID, domain.MemoryTypeFact, stageFactRedistribute, s.Score, top, tokens, false))
 	}
 }
 
-// updateAccessStats 异步更新访问统计
-func (a *CognitiveRetrievalAction) updateAccessStats(c *domain.RecallContext) {
-	// 通过类型断言获取 OpenSearchStore 的 UpdateFields 能力
-	type fieldUpdater interface {
-		UpdateFields(ctx context.Context, id string, fields map[string]any) error
+// applyRecency 按 RecencyWeight 混入基于 created_at 的指数时间衰减并重新排序，使较新的记忆在相似度相近时优先占用预算
+// 混合分数 = (1-w)·_score + w·0.5^(距写入小时数/半衰期)，w 为 0 时不做处理；没有 created_at 的文档衰减因子为 0
+func applyRecency(c *domain.RecallContext, docs []map[string]any) {
+	weight := c.Options.RecencyWeight
+	if weight <= 0 || len(docs) == 0 {
+		return
+	}
+	halfLife := c.Options.RecencyHalfLifeHours
+	if halfLife <= 0 {
+		halfLife = DefaultRecencyHalfLifeHours
 	}
 
-	updater, ok := a.vectorStore.(fieldUpdater)
-	if !ok {
+	now := time.Now()
+	for _, doc := range docs {
+		score, _ := doc["_score"].(float64)
+		decay := 0.0
+		if createdAt, ok := docCreatedAt(doc); ok {
+			age := max(now.Sub(createdAt).Hours(), 0)
+			decay = math.Pow(0.5, age/halfLife)
+		}
+		doc["_score"] = (1-weight)*score + weight*decay
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		return docs[i]["_score"].(float64) > docs[j]["_score"].(float64)
+	})
+}
+
+// docCreatedAt 读取文档的 created_at，兼容 OpenSearch 返回的字符串与内存存储中的 time.Time
+func docCreatedAt(doc map[string]any) (time.Time, bool) {
+	switch v := doc["created_at"].(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// explainTruncated 记录因预算不足被截断的候选
+func (a *CognitiveRetrievalAction) explainTruncated(c *domain.RecallContext, docs []map[string]any, itemType, stage string, top float64) {
+	if !c.Options.Explain {
+		return
+	}
+
+	for _, doc := range docs {
+		id, _ := doc["id"].(string)
+		score, _ := doc["_score"].(float64)
+		tokens := estimateTokens(docText(doc))
+		c.AddDebug(newRetrievalDebug(id, itemType, stage, score, top, tokens, true))
+	}
+}
+
+// excludeSeen 去掉已在之前阶段返回的文档，避免把已返回的结果记为截断
+func excludeSeen(docs []map[string]any, seen map[string]bool) []map[string]any {
+	var rest []map[string]any
+	for _, doc := range docs {
+		if id, _ := doc["id"].(string); !seen[id] {
+			rest = append(rest, doc)
+		}
+	}
+	return rest
+}
+
+// newRetrievalDebug 构建单条调试信息
+func newRetrievalDebug(id, itemType, stage string, score, top float64, tokens int, truncated bool) domain.RetrievalDebug {
+	normalized := 0.0
+	if top > 0 {
+		normalized = score / top
+	}
+
+	return domain.RetrievalDebug{
+		ID:              id,
+		Type:            itemType,
+		Stage:           stage,
+		RawScore:        score,
+		NormalizedScore: normalized,
+		Tokens:          tokens,
+		Selected:        !truncated,
+		Truncated:       truncated,
+	}
+}
+
+// maxScore 返回一批文档中的最高 _score
+func maxScore(docs []map[string]any) float64 {
+	top := 0.0
+	for _, doc := range docs {
+		if score, ok := doc["_score"].(float64); ok && score > top {
+			top = score
+		}
+	}
+	return top
+}
+
+// docText 返回文档用于 token 估算的文本
+func docText(doc map[string]any) string {
+	if content, ok := doc["content"].(string); ok {
+		return content
+	}
+
+	arg1, _ := doc["argument1"].(string)
+	trigger, _ := doc["trigger_word"].(string)
+	arg2, _ := doc["argument2"].(string)
+	return arg1 + trigger + arg2
+}
+
+// updateAccessStats 异步更新访问统计
+func (a *CognitiveRetrievalAction) updateAccessStats(c *domain.RecallContext) {
+	if a.vectorStore == nil {
 		return
 	}
 
@@ -351,7 +984,7 @@ func (a *CognitiveRetrievalAction) updateAccessStats(c *domain.RecallContext) {
 
 	// 更新 fact 记忆
 	for _, f := range c.Facts {
-		_ = updater.UpdateFields(c.Context, f.ID, map[string]any{
+		_ = a.vectorStore.UpdateFields(c.Context, f.ID, map[string]any{
 			"access_count":     f.AccessCount + 1,
 			"last_accessed_at": now,
 		})
@@ -359,7 +992,7 @@ func (a *CognitiveRetrievalAction) updateAccessStats(c *domain.RecallContext) {
 
 	// 更新 working 记忆
 	for _, w := range c.WorkingMem {
-		_ = updater.UpdateFields(c.Context, w.ID, map[string]any{
+		_ = a.vectorStore.UpdateFields(c.Context, w.ID, map[string]any{
 			"access_count":     w.AccessCount + 1,
 			"last_accessed_at": now,
 		})
@@ -367,7 +1000,7 @@ func (a *CognitiveRetrievalAction) updateAccessStats(c *domain.RecallContext) {
 
 	// 更新事件
 	for _, e := range c.Events {
-		_ = updater.UpdateFields(c.Context, e.ID, map[string]any{
+		_ = a.vectorStore.UpdateFields(c.Context, e.ID, map[string]any{
 			"access_count":     e.AccessCount + 1,
 			"last_accessed_at": now,
 		})
@@ -406,6 +1039,12 @@ func FormatMemoryContext(c *domain.RecallContext) string {
 		}
 	}
 
+	// 事件因果/时序链
+	if chains := FormatEventChains(c.Events, c.EventRelations); len(chains) > 0 {
+		parts = append(parts, "\n## 事件关联")
+		parts = append(parts, chains...)
+	}
+
 	// 短期记忆（底部）
 	if len(c.ShortTerm) > 0 {
 		parts = append(parts, "\n## 近期对话")
@@ -425,6 +1064,40 @@ func FormatMemoryContext(c *domain.RecallContext) string {
 	return strings.Join(parts, "\n")
 }
 
+// FormatEventChains 将事件关系格式化为可读的因果/时序链
+// causal: "因为 X，所以 Y"；temporal: "先 X，然后 Y"
+func FormatEventChains(events []domain.EventTriplet, relations []domain.EventRelation) []string {
+	if len(relations) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]domain.EventTriplet, len(events))
+	for _, e := range events {
+		byID[e.ID] = e
+	}
+
+	var lines []string
+	for _, rel := range relations {
+		from, okFrom := byID[rel.FromEventID]
+		to, okTo := byID[rel.ToEventID]
+		if !okFrom || !okTo {
+			continue
+		}
+
+		fromText := from.Argument1 + " " + from.TriggerWord + " " + from.Argument2
+		toText := to.Argument1 + " " + to.TriggerWord + " " + to.Argument2
+
+		switch rel.RelationType {
+		case domain.RelationCausal:
+			lines = append(lines, fmt.Sprintf("- 因为 %s，所以 %s", fromText, toText))
+		case domain.RelationTemporal:
+			lines = append(lines, fmt.Sprintf("- 先 %s，然后 %s", fromText, toText))
+		}
+	}
+
+	return lines
+}
+
 // estimateTokens 估算文本的 token 数量
 func estimateTokens(text string) int {
 	charCount := utf8.RuneCountInString(text)
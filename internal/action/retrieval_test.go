@@ -0,0 +1,872 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+	"github.com/Zereker/memory/pkg/relation"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// scoredDocs 按 ScoreThreshold 过滤，模拟 OpenSearchStore.Search 的行为
+func scoredDocs(query vector.SearchQuery, docs []map[string]any) []map[string]any {
+	var result []map[string]any
+	for _, doc := range docs {
+		if score, ok := doc["_score"].(float64); ok && query.ScoreThreshold > 0 && score < query.ScoreThreshold {
+			continue
+		}
+		result = append(result, doc)
+	}
+	return result
+}
+
+func TestCognitiveRetrieval_PerTypeScoreThreshold(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	facts := []map[string]any{
+		{"id": "fact_high", "content": "用户住在北京", "memory_type": domain.MemoryTypeFact, "_score": 0.9},
+		{"id": "fact_low", "content": "用户喜欢咖啡", "memory_type": domain.MemoryTypeFact, "_score": 0.5},
+	}
+	events := []map[string]any{
+		{"id": "evt_high", "trigger_word": "去了", "argument1": "用户", "argument2": "星巴克", "_score": 0.6},
+		{"id": "evt_low", "trigger_word": "喝", "argument1": "用户", "argument2": "咖啡", "_score": 0.4},
+	}
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		switch {
+		case query.Filters["type"] == domain.DocTypeEvent:
+			return scoredDocs(query, events), nil
+		case query.Filters["memory_type"] == domain.MemoryTypeFact:
+			return scoredDocs(query, facts), nil
+		default:
+			return nil, nil
+		}
+	}
+
+	action := helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore())
+
+	c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "用户去过哪里",
+		Options: domain.RetrieveOptions{
+			FactThreshold:  0.8,
+			EventThreshold: 0.3,
+		},
+	})
+	action.HandleRecall(c)
+
+	require.Len(t, c.Facts, 1)
+	assert.Equal(t, "fact_high", c.Facts[0].ID)
+	assert.Len(t, c.Events, 2)
+
+	for _, q := range store.SearchCalls {
+		switch {
+		case q.Filters["type"] == domain.DocTypeEvent:
+			assert.Equal(t, 0.3, q.ScoreThreshold)
+		case q.Filters["memory_type"] == domain.MemoryTypeFact:
+			assert.Equal(t, 0.8, q.ScoreThreshold)
+		case q.Filters["memory_type"] == domain.MemoryTypeWorking:
+			assert.Equal(t, 0.0, q.ScoreThreshold)
+		}
+	}
+}
+
+func TestCognitiveRetrieval_Explain(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	newStore := func() *MockVectorStore {
+		store := NewMockVectorStore()
+		store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+			if query.Filters["memory_type"] != domain.MemoryTypeFact {
+				return nil, nil
+			}
+			return []map[string]any{
+				{"id": "fact_1", "content": "用户住在北京朝阳区", "memory_type": domain.MemoryTypeFact, "_score": 0.8},
+				{"id": "fact_2", "content": "用户每天早上都会去楼下的咖啡店买一杯拿铁", "memory_type": domain.MemoryTypeFact, "_score": 0.4},
+			}, nil
+		}
+		return store
+	}
+
+	t.Run("results returned earlier are not reported as truncated", func(t *testing.T) {
+		// 补充检索中已返回的 fact_1 排在被截断的 fact_2 之后
+		factSearches := 0
+		store := NewMockVectorStore()
+		store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+			if query.Filters["memory_type"] != domain.MemoryTypeFact {
+				return nil, nil
+			}
+			factSearches++
+			fact1 := map[string]any{"id": "fact_1", "content": "用户住在北京朝阳区", "memory_type": domain.MemoryTypeFact, "_score": 0.8}
+			if factSearches == 1 {
+				return []map[string]any{fact1}, nil
+			}
+			return []map[string]any{
+				{"id": "fact_2", "content": "用户每天早上都会去楼下的咖啡店买一杯拿铁", "memory_type": domain.MemoryTypeFact, "_score": 0.9},
+				fact1,
+			}, nil
+		}
+
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "用户住在哪里",
+			Options: domain.RetrieveOptions{MaxFacts: 8, MaxWorking: -1, MaxGraph: -1, Explain: true},
+		})
+		helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+
+		require.Equal(t, 2, factSearches)
+		assert.Equal(t, []string{"fact_1"}, summaryIDs(c.Facts))
+
+		var truncated []string
+		for _, d := range c.Debug {
+			if d.Stage == stageFactRedistribute && d.Truncated {
+				truncated = append(truncated, d.ID)
+			}
+		}
+		assert.Equal(t, []string{"fact_2"}, truncated)
+	})
+
+	t.Run("debug populated when explain is set", func(t *testing.T) {
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "用户住在哪里",
+			Options: domain.RetrieveOptions{
+				MaxFacts:   8,
+				MaxWorking: -1,
+				MaxGraph:   -1,
+				Explain:    true,
+			},
+		})
+		helper.NewCognitiveRetrievalAction().WithStores(newStore(), NewMockRelationStore()).HandleRecall(c)
+
+		require.Len(t, c.Facts, 1)
+
+		var selected, truncated *domain.RetrievalDebug
+		for i := range c.Debug {
+			d := &c.Debug[i]
+			if d.Stage != stageFactSearch {
+				continue
+			}
+			switch d.ID {
+			case "fact_1":
+				selected = d
+			case "fact_2":
+				truncated = d
+			}
+		}
+
+		require.NotNil(t, selected)
+		assert.True(t, selected.Selected)
+		assert.False(t, selected.Truncated)
+		assert.Equal(t, 0.8, selected.RawScore)
+		assert.Equal(t, 1.0, selected.NormalizedScore)
+		assert.Equal(t, domain.MemoryTypeFact, selected.Type)
+
+		require.NotNil(t, truncated)
+		assert.False(t, truncated.Selected)
+		assert.True(t, truncated.Truncated)
+		assert.InDelta(t, 0.5, truncated.NormalizedScore, 1e-9)
+	})
+
+	t.Run("debug absent when explain is not set", func(t *testing.T) {
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "用户住在哪里",
+			Options: domain.RetrieveOptions{MaxFacts: 8},
+		})
+		helper.NewCognitiveRetrievalAction().WithStores(newStore(), NewMockRelationStore()).HandleRecall(c)
+
+		assert.NotEmpty(t, c.Facts)
+		assert.Nil(t, c.Debug)
+	})
+}
+
+func TestCognitiveRetrieval_RecallEventsBySimilarity(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	// 按文本关键字返回不同向量：咖啡相关 vs 工作相关
+	helper.SetEmbedderFunc(func(text string) []float32 {
+		if strings.Contains(text, "咖啡") {
+			return []float32{1, 0}
+		}
+		return []float32{0, 1}
+	})
+	helper.SetModelJSON(EventExtractResult{
+		Events: []ExtractedEvent{
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡"},
+			{TriggerWord: "开了", Argument1: "小明", Argument2: "会议"},
+		},
+	})
+
+	// 简易存储：记录写入的事件，按余弦相似度检索
+	base := NewBaseAction("test")
+	var stored []map[string]any
+	store := NewMockVectorStore()
+	store.StoreFunc = func(ctx context.Context, id string, doc map[string]any) error {
+		stored = append(stored, doc)
+		return nil
+	}
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["type"] != domain.DocTypeEvent {
+			return nil, nil
+		}
+		var results []map[string]any
+		for _, doc := range stored {
+			score := base.CosineSimilarity(query.Embedding, doc["embedding"].([]float32))
+			if score < 0.5 {
+				continue
+			}
+			hit := make(map[string]any, len(doc)+1)
+			for k, v := range doc {
+				hit[k] = v
+			}
+			hit["_score"] = score
+			results = append(results, hit)
+		}
+		return results, nil
+	}
+
+	addCtx := newEventAddContext(ctx)
+	helper.NewEventExtractionAction().WithStores(store, NewMockRelationStore()).Handle(addCtx)
+	require.Len(t, stored, 2)
+
+	c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "小明喜欢喝咖啡吗",
+	})
+	helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+
+	require.Len(t, c.Events, 1)
+	assert.Equal(t, "咖啡", c.Events[0].Argument2)
+	assert.Equal(t, 1.0, c.Events[0].Score)
+	assert.Contains(t, FormatMemoryContext(c), "## 相关事件")
+	assert.Contains(t, FormatMemoryContext(c), "小明 喝 咖啡")
+}
+
+func TestCognitiveRetrieval_EventChainTraversal(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["type"] != domain.DocTypeEvent {
+			return nil, nil
+		}
+		// 按 ID 加载邻居事件
+		if ids, ok := query.TermsFilters["id"]; ok {
+			assert.Equal(t, []string{"evt_cause"}, ids)
+			return []map[string]any{
+				{"id": "evt_cause", "trigger_word": "失眠了", "argument1": "小明", "argument2": "昨晚"},
+			}, nil
+		}
+		return []map[string]any{
+			{"id": "evt_effect", "trigger_word": "喝了", "argument1": "小明", "argument2": "咖啡", "_score": 0.9},
+		}, nil
+	}
+
+	relationStore := NewMockRelationStore()
+	relationStore.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+		if eventID != "evt_effect" {
+			return nil, nil
+		}
+		return []relation.Relation{
+			{ID: "rel_1", FromEventID: "evt_cause", ToEventID: "evt_effect", RelationType: domain.RelationCausal},
+		}, nil
+	}
+
+	c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "小明为什么喝咖啡",
+	})
+	helper.NewCognitiveRetrievalAction().WithStores(store, relationStore).HandleRecall(c)
+
+	require.Len(t, c.Events, 2)
+	assert.Equal(t, "evt_effect", c.Events[0].ID)
+	assert.Equal(t, "evt_cause", c.Events[1].ID)
+
+	require.Len(t, c.EventRelations, 1)
+	assert.Equal(t, domain.RelationCausal, c.EventRelations[0].RelationType)
+
+	assert.Contains(t, FormatMemoryContext(c), "因为 小明 失眠了 昨晚，所以 小明 喝了 咖啡")
+}
+
+// newChainStores 构建事件链测试用的存储：seeds 为向量召回结果，edges 为各事件的出边
+func newChainStores(seeds []map[string]any, edges map[string][]string) (*MockVectorStore, *MockRelationStore) {
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["type"] != domain.DocTypeEvent {
+			return nil, nil
+		}
+		ids, ok := query.TermsFilters["id"]
+		if !ok {
+			return seeds, nil
+		}
+		docs := make([]map[string]any, 0, len(ids))
+		for _, id := range ids {
+			docs = append(docs, map[string]any{"id": id, "trigger_word": "做了", "argument1": "小明", "argument2": id})
+		}
+		return docs, nil
+	}
+
+	relationStore := NewMockRelationStore()
+	relationStore.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+		var rels []relation.Relation
+		for _, to := range edges[eventID] {
+			rels = append(rels, relation.Relation{ID: eventID + "->" + to, FromEventID: eventID, ToEventID: to, RelationType: domain.RelationTemporal})
+		}
+		return rels, nil
+	}
+	return store, relationStore
+}
+
+func eventIDs(events []domain.EventTriplet) []string {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func TestCognitiveRetrieval_EventChainLimits(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	seeds := []map[string]any{
+		{"id": "evt_a", "trigger_word": "去了", "argument1": "小明", "argument2": "公园", "_score": 0.9},
+		{"id": "evt_b", "trigger_word": "买了", "argument1": "小明", "argument2": "咖啡", "_score": 0.8},
+	}
+	edges := map[string][]string{
+		"evt_a":  {"evt_a1", "evt_a2", "evt_a3", "evt_a4"},
+		"evt_b":  {"evt_b1", "evt_b2", "evt_b3"},
+		"evt_a1": {"evt_a1x"},
+		"evt_a2": {"evt_a2x"},
+	}
+
+	recall := func(opts domain.RetrieveOptions) *domain.RecallContext {
+		store, relationStore := newChainStores(seeds, edges)
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "小明做了什么",
+			Options: opts,
+		})
+		helper.NewCognitiveRetrievalAction().WithStores(store, relationStore).HandleRecall(c)
+		return c
+	}
+
+	t.Run("per-event and overall caps", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{MaxNeighborsPerEvent: 2, MaxChainEvents: 3})
+
+		assert.Equal(t, []string{"evt_a", "evt_b", "evt_a1", "evt_a2", "evt_b1"}, eventIDs(c.Events))
+		assert.Len(t, c.EventRelations, 3)
+	})
+
+	t.Run("defaults stop at one hop", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{})
+
+		assert.Equal(t, []string{"evt_a", "evt_b", "evt_a1", "evt_a2", "evt_a3", "evt_b1", "evt_b2", "evt_b3"}, eventIDs(c.Events))
+	})
+
+	t.Run("nearer hops fill the cap first", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{MaxHops: 2, MaxNeighborsPerEvent: 1, MaxChainEvents: 3})
+
+		assert.Equal(t, []string{"evt_a", "evt_b", "evt_a1", "evt_b1", "evt_a1x"}, eventIDs(c.Events))
+		assert.Len(t, c.EventRelations, 3)
+	})
+}
+
+func TestCognitiveRetrieval_EventChainScoreDecay(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	seeds := []map[string]any{
+		{"id": "evt_a", "trigger_word": "去了", "argument1": "小明", "argument2": "公园", "_score": 0.9},
+		{"id": "evt_b", "trigger_word": "买了", "argument1": "小明", "argument2": "咖啡", "_score": 0.6},
+	}
+	store, relationStore := newChainStores(seeds, map[string][]string{
+		"evt_a": {"evt_x"},
+		"evt_b": {"evt_z"},
+		"evt_x": {"evt_y"},
+	})
+
+	// OpenSearch 按 ID 查询不保证顺序：倒序返回邻居
+	search := store.SearchFunc
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		docs, err := search(ctx, query)
+		if _, ok := query.TermsFilters["id"]; ok {
+			for i, j := 0, len(docs)-1; i < j; i, j = i+1, j-1 {
+				docs[i], docs[j] = docs[j], docs[i]
+			}
+		}
+		return docs, err
+	}
+
+	recall := func(opts domain.RetrieveOptions) *domain.RecallContext {
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "小明做了什么",
+			Options: opts,
+		})
+		helper.NewCognitiveRetrievalAction().WithStores(store, relationStore).HandleRecall(c)
+		return c
+	}
+
+	t.Run("score decays with hop distance", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{MaxHops: 2})
+
+		require.Equal(t, []string{"evt_a", "evt_b", "evt_x", "evt_z", "evt_y"}, eventIDs(c.Events))
+		assert.InDelta(t, 0.45, c.Events[2].Score, 1e-9)
+		assert.InDelta(t, 0.3, c.Events[3].Score, 1e-9)
+		assert.InDelta(t, 0.225, c.Events[4].Score, 1e-9)
+		assert.Greater(t, c.Events[2].Score, c.Events[4].Score)
+	})
+
+	t.Run("neighbor of stronger seed survives budget", func(t *testing.T) {
+		// 两个种子事件各 4 tokens，邻居各 6 tokens，只够再放一个邻居
+		c := recall(domain.RetrieveOptions{MaxHops: 2, MaxGraph: 15})
+
+		assert.Equal(t, []string{"evt_a", "evt_b", "evt_x"}, eventIDs(c.Events))
+	})
+}
+
+func TestCognitiveRetrieval_DegradedWithoutRelations(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["type"] != domain.DocTypeEvent {
+			return nil, nil
+		}
+		return []map[string]any{
+			{"id": "evt_effect", "trigger_word": "喝了", "argument1": "小明", "argument2": "咖啡", "_score": 0.9},
+		}, nil
+	}
+
+	newContext := func() *domain.RecallContext {
+		return domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "小明为什么喝咖啡",
+		})
+	}
+
+	t.Run("nil relation store falls back to vector-only events", func(t *testing.T) {
+		c := newContext()
+		helper.NewCognitiveRetrievalAction().WithStores(store, nil).HandleRecall(c)
+
+		require.Len(t, c.Events, 1)
+		assert.Equal(t, "evt_effect", c.Events[0].ID)
+		assert.Empty(t, c.EventRelations)
+		assert.True(t, c.Degraded)
+		require.Len(t, c.Warnings, 1)
+		assert.Contains(t, c.Warnings[0], "vector-only")
+	})
+
+	t.Run("relation store failure degrades", func(t *testing.T) {
+		relationStore := NewMockRelationStore()
+		relationStore.FindByEventIDFunc = func(ctx context.Context, eventID string) ([]relation.Relation, error) {
+			return nil, errors.New("postgres unavailable")
+		}
+
+		c := newContext()
+		helper.NewCognitiveRetrievalAction().WithStores(store, relationStore).HandleRecall(c)
+
+		assert.Len(t, c.Events, 1)
+		assert.True(t, c.Degraded)
+		require.Len(t, c.Warnings, 1)
+		assert.Contains(t, c.Warnings[0], "postgres unavailable")
+	})
+
+	t.Run("healthy relation store is not degraded", func(t *testing.T) {
+		c := newContext()
+		helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+
+		assert.False(t, c.Degraded)
+		assert.Empty(t, c.Warnings)
+	})
+}
+
+func TestCognitiveRetrieval_EmbeddingUnavailable(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.MockPlugin.SetEmbedderResponse("doubao-embedding-text-240715", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		return nil, errors.New("embedder timeout")
+	})
+
+	newContext := func() *domain.RecallContext {
+		return domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "小明喜欢喝什么",
+		})
+	}
+
+	t.Run("short-term window is still returned", func(t *testing.T) {
+		c := newContext()
+		c.ShortTerm = domain.Messages{{Role: domain.RoleUser, Content: "我喜欢喝咖啡"}}
+		helper.NewCognitiveRetrievalAction().WithStores(NewMockVectorStore(), NewMockRelationStore()).HandleRecall(c)
+
+		require.NoError(t, c.Error())
+		assert.Len(t, c.ShortTerm, 1)
+		assert.Empty(t, c.Facts)
+		assert.True(t, c.Degraded)
+		require.Len(t, c.Warnings, 1)
+		assert.Contains(t, c.Warnings[0], "embedder timeout")
+	})
+
+	t.Run("nothing to serve fails", func(t *testing.T) {
+		c := newContext()
+		helper.NewCognitiveRetrievalAction().WithStores(NewMockVectorStore(), NewMockRelationStore()).HandleRecall(c)
+
+		assert.Error(t, c.Error())
+	})
+}
+
+func TestCognitiveRetrieval_PerTypeEmbedders(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	const eventEmbedder = "ark/doubao-embedding-event"
+	summaryVector := []float32{1, 0, 0}
+	eventVector := []float32{0, 1, 0}
+	helper.SetEmbedderVector(summaryVector)
+	helper.MockPlugin.SetEmbedderVectorResponse("doubao-embedding-event", eventVector)
+
+	helper.SetModelJSON(map[string]any{
+		"memories": []ExtractedMemory{
+			{Content: "用户喜欢喝咖啡", Importance: 0.5, MemoryType: domain.MemoryTypeFact},
+		},
+		"events": []ExtractedEvent{
+			{TriggerWord: "喝", Argument1: "小明", Argument2: "咖啡"},
+		},
+	})
+
+	store := NewMockVectorStore()
+	c := newEventAddContext(ctx)
+	helper.NewSummaryMemoryAction().WithStore(store).Handle(c)
+	helper.NewEventExtractionAction().
+		WithStores(store, NewMockRelationStore()).
+		WithConfig(EventConfig{DualWriteMode: DualWriteBestEffort, Embedder: eventEmbedder}).
+		Handle(c)
+
+	require.Len(t, store.StoreCalls, 2)
+	for _, call := range store.StoreCalls {
+		switch call.Doc["type"] {
+		case domain.DocTypeSummary:
+			assert.Equal(t, summaryVector, call.Doc["embedding"])
+		case domain.DocTypeEvent:
+			assert.Equal(t, eventVector, call.Doc["embedding"])
+		}
+	}
+
+	recallCtx := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "小明喜欢喝什么",
+	})
+	helper.NewCognitiveRetrievalAction().
+		WithStores(store, NewMockRelationStore()).
+		WithEmbedders(EmbedderName, eventEmbedder).
+		HandleRecall(recallCtx)
+
+	require.NotEmpty(t, store.SearchCalls)
+	for _, q := range store.SearchCalls {
+		if q.Filters["type"] == domain.DocTypeEvent {
+			assert.Equal(t, eventVector, q.Embedding)
+		} else {
+			assert.Equal(t, summaryVector, q.Embedding)
+		}
+	}
+}
+
+func TestCognitiveRetrieval_DedupSummaries(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	facts := []map[string]any{
+		{"id": "fact_1", "content": "用户住在北京", "memory_type": domain.MemoryTypeFact, "embedding": []float32{1, 0, 0}, "_score": 0.8},
+		{"id": "fact_2", "content": "用户目前住在北京市", "memory_type": domain.MemoryTypeFact, "embedding": []float32{0.99, 0.01, 0}, "_score": 0.9},
+		{"id": "fact_3", "content": "用户喜欢咖啡", "memory_type": domain.MemoryTypeFact, "embedding": []float32{0, 1, 0}, "_score": 0.7},
+	}
+	working := []map[string]any{
+		{"id": "work_1", "content": "用户喜欢 咖啡", "memory_type": domain.MemoryTypeWorking, "_score": 0.6}, // 无向量，内容与 fact_3 相同
+	}
+
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		switch query.Filters["memory_type"] {
+		case domain.MemoryTypeFact:
+			return facts, nil
+		case domain.MemoryTypeWorking:
+			return working, nil
+		default:
+			return nil, nil
+		}
+	}
+
+	recall := func(opts domain.RetrieveOptions) *domain.RecallContext {
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里", Options: opts})
+		helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+		return c
+	}
+
+	t.Run("keeps the highest scoring of each duplicate", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{Explain: true})
+
+		assert.Equal(t, []string{"fact_2", "fact_3"}, summaryIDs(c.Facts))
+		assert.Empty(t, c.WorkingMem)
+
+		var dropped []string
+		for _, d := range c.Debug {
+			if d.Stage == stageDedup {
+				assert.False(t, d.Selected)
+				dropped = append(dropped, d.ID)
+			}
+		}
+		assert.ElementsMatch(t, []string{"fact_1", "work_1"}, dropped)
+	})
+
+	t.Run("disabled keeps every result", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{DedupThreshold: -1})
+
+		assert.Len(t, c.Facts, 3)
+		assert.Len(t, c.WorkingMem, 1)
+	})
+}
+
+func TestCognitiveRetrieval_RecencyWeight(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	now := time.Now()
+	store := NewMockVectorStore()
+	store.SearchFunc = func(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+		if query.Filters["memory_type"] != domain.MemoryTypeFact {
+			return nil, nil
+		}
+		return []map[string]any{
+			{"id": "fact_old", "content": "用户住在上海", "memory_type": domain.MemoryTypeFact, "_score": 0.9, "created_at": now.Add(-60 * 24 * time.Hour)},
+			{"id": "fact_new", "content": "用户搬到了北京", "memory_type": domain.MemoryTypeFact, "_score": 0.8, "created_at": now.Add(-time.Hour).Format(time.RFC3339)},
+		}, nil
+	}
+
+	recall := func(opts domain.RetrieveOptions) *domain.RecallContext {
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里", Options: opts})
+		helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+		return c
+	}
+
+	t.Run("disabled keeps similarity order", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{DedupThreshold: -1})
+		assert.Equal(t, []string{"fact_old", "fact_new"}, summaryIDs(c.Facts))
+	})
+
+	t.Run("weight favors recent memories", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{DedupThreshold: -1, RecencyWeight: 0.3})
+		assert.Equal(t, []string{"fact_new", "fact_old"}, summaryIDs(c.Facts))
+	})
+
+	t.Run("weight out of range is rejected", func(t *testing.T) {
+		req := &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户住在哪里", Options: domain.RetrieveOptions{RecencyWeight: 1.5}}
+		assert.ErrorIs(t, req.Validate(), domain.ErrInvalidInput)
+	})
+}
+
+func TestCognitiveRetrieval_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	expiredAt := time.Now().Add(-time.Hour)
+	store := vector.NewMemoryStore(3)
+	for _, doc := range []map[string]any{
+		{"id": "fact_coffee", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户喜欢喝咖啡", "embedding": []float32{1, 0, 0}},
+		{"id": "fact_tea", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户偶尔喝茶", "embedding": []float32{0.8, 0.6, 0}},
+		{"id": "fact_expired", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户不喝咖啡", "embedding": []float32{1, 0, 0}, "expired_at": expiredAt},
+		{"id": "fact_other_user", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_2",
+			"content": "用户喜欢喝咖啡", "embedding": []float32{1, 0, 0}},
+		{"id": "work_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeWorking, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户正在找咖啡店", "embedding": []float32{0, 1, 0}},
+		{"id": "evt_1", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1",
+			"trigger_word": "喝", "argument1": "用户", "argument2": "咖啡", "embedding": []float32{1, 0, 0}},
+	} {
+		require.NoError(t, store.Store(ctx, doc["id"].(string), doc))
+	}
+
+	c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "用户喜欢喝什么",
+	})
+	helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+
+	assert.Equal(t, []string{"fact_coffee", "fact_tea"}, summaryIDs(c.Facts))
+	assert.Equal(t, 1.0, c.Facts[0].Score)
+	assert.Equal(t, []string{"work_1"}, summaryIDs(c.WorkingMem))
+	require.Len(t, c.Events, 1)
+	assert.Equal(t, "evt_1", c.Events[0].ID)
+}
+
+func TestCognitiveRetrieval_EmptyFactsReleaseBudget(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	// 新用户：只有 working 记忆和事件，没有 fact
+	store := vector.NewMemoryStore(3)
+	for _, doc := range []map[string]any{
+		{"id": "work_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeWorking, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户这周在准备搬家的各种事情呢", "embedding": []float32{1, 0, 0}},
+		{"id": "work_2", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeWorking, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户最近在学做饭想换个清淡口味", "embedding": []float32{0.6, 0.8, 0}},
+		{"id": "evt_1", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1",
+			"trigger_word": "搬到", "argument1": "用户", "argument2": "上海浦东", "embedding": []float32{1, 0, 0}},
+		{"id": "evt_2", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1",
+			"trigger_word": "买了", "argument1": "用户", "argument2": "新沙发", "embedding": []float32{0.6, 0.8, 0}},
+	} {
+		require.NoError(t, store.Store(ctx, doc["id"].(string), doc))
+	}
+
+	c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "用户最近在忙什么",
+		Options: domain.RetrieveOptions{
+			MaxTokens:  100, // Fact 桶 50
+			MaxGraph:   6,   // 只容得下一个事件
+			MaxWorking: 10,  // 只容得下一条 working 记忆
+			Explain:    true,
+		},
+	})
+	helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+
+	assert.Empty(t, c.Facts)
+	assert.Equal(t, []string{"work_1", "work_2"}, summaryIDs(c.WorkingMem), "working bucket gets the fact budget")
+	require.Len(t, c.Events, 2, "events truncated by the graph bucket get the leftover")
+	assert.Equal(t, "evt_2", c.Events[1].ID)
+
+	var evt2Stages []string
+	for _, d := range c.Debug {
+		assert.NotEqual(t, stageFactRedistribute, d.Stage, "no second fact search for a user without facts")
+		if d.ID == "evt_2" {
+			evt2Stages = append(evt2Stages, d.Stage)
+		}
+	}
+	assert.Equal(t, []string{stageEventSearch, stageEventRedistribute}, evt2Stages)
+}
+
+func TestCognitiveRetrieval_Meta(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	store := vector.NewMemoryStore(3)
+	for _, doc := range []map[string]any{
+		{"id": "fact_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户喜欢喝咖啡", "embedding": []float32{1, 0, 0}},
+		{"id": "fact_2", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户住在上海", "embedding": []float32{0.6, 0.8, 0}},
+		{"id": "work_1", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeWorking, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户在找咖啡店", "embedding": []float32{0, 1, 0}},
+		{"id": "work_2", "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeWorking, "agent_id": "agent_1", "user_id": "user_1",
+			"content": "用户在找咖啡店", "embedding": []float32{0, 0, 1}},
+		{"id": "evt_1", "type": domain.DocTypeEvent, "agent_id": "agent_1", "user_id": "user_1",
+			"trigger_word": "喝", "argument1": "用户", "argument2": "咖啡", "embedding": []float32{1, 0, 0}},
+	} {
+		require.NoError(t, store.Store(ctx, doc["id"].(string), doc))
+	}
+
+	c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{
+		AgentID: "agent_1",
+		UserID:  "user_1",
+		Query:   "用户喜欢喝什么",
+		Options: domain.RetrieveOptions{MaxFacts: estimateTokens("用户喜欢喝咖啡")},
+	})
+	helper.NewCognitiveRetrievalAction().WithStores(store, NewMockRelationStore()).HandleRecall(c)
+
+	require.NotNil(t, c.Meta)
+	assert.Equal(t, domain.RetrieveTypeMeta{Found: 2, Returned: 1, Truncated: true}, c.Meta.Facts, "budget truncation is reported")
+	assert.Equal(t, domain.RetrieveTypeMeta{Found: 2, Returned: 1}, c.Meta.Working, "near-duplicates are not truncation")
+	assert.Equal(t, domain.RetrieveTypeMeta{Found: 1, Returned: 1}, c.Meta.Events)
+}
+
+func TestCognitiveRetrieval_ExpandSources(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{1, 0, 0})
+
+	store := vector.NewMemoryStore(3)
+	fact := func(id, content string, embedding []float32, extra map[string]any) map[string]any {
+		doc := map[string]any{"id": id, "type": domain.DocTypeSummary, "memory_type": domain.MemoryTypeFact,
+			"agent_id": "agent_1", "user_id": "user_1", "content": content, "embedding": embedding}
+		for k, v := range extra {
+			doc[k] = v
+		}
+		return doc
+	}
+	for _, doc := range []map[string]any{
+		fact("parent", "用户喜欢各种咖啡", []float32{1, 0, 0}, map[string]any{"level": 1, "child_ids": []string{"child_1", "child_2", "child_3"}}),
+		fact("child_1", "用户喜欢拿铁", []float32{0, 1, 0}, map[string]any{"parent_id": "parent"}),
+		fact("child_2", "用户喜欢美式", []float32{0, 0, 1}, map[string]any{"parent_id": "parent"}),
+		fact("child_3", "用户喜欢摩卡", []float32{0, 1, 1}, map[string]any{"parent_id": "parent"}),
+	} {
+		require.NoError(t, store.Store(ctx, doc["id"].(string), doc))
+	}
+
+	recall := func(opts domain.RetrieveOptions) *domain.RecallContext {
+		opts.FactThreshold = 0.8 // 源摘要本身不被召回
+		c := domain.NewRecallContext(ctx, &domain.RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户喜欢喝什么", Options: opts})
+		NewMemory().WithStores(store, NewMockRelationStore()).newRecallChain("agent_1", "user_1").Run(c)
+		return c
+	}
+
+	t.Run("attaches sources in order", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{ExpandSources: 2})
+		require.Equal(t, []string{"parent"}, summaryIDs(c.Facts))
+		assert.Equal(t, []string{"child_1", "child_2"}, summaryIDs(c.Facts[0].Sources))
+	})
+
+	t.Run("sources respect the fact budget", func(t *testing.T) {
+		budget := estimateTokens("用户喜欢各种咖啡") + estimateTokens("用户喜欢拿铁")
+		c := recall(domain.RetrieveOptions{ExpandSources: 3, MaxFacts: budget})
+		require.Equal(t, []string{"parent"}, summaryIDs(c.Facts))
+		assert.Equal(t, []string{"child_1"}, summaryIDs(c.Facts[0].Sources))
+	})
+
+	t.Run("not expanded by default", func(t *testing.T) {
+		c := recall(domain.RetrieveOptions{})
+		require.Len(t, c.Facts, 1)
+		assert.Empty(t, c.Facts[0].Sources)
+	})
+}
@@ -1,6 +1,7 @@
 package action
 
 import (
+	"slices"
 	"sync"
 	"time"
 
@@ -47,7 +48,7 @@ func (s *ShortTermStore) GetWindow(agentID, userID, sessionID string) *domain.Sh
 	return nil
 }
 
-// AppendMessages 追加消息到窗口（自动滑动）
+// AppendMessages 追加消息到窗口（自动滑动），返回追加后的窗口快照，调用方读取时不受同一会话并发写入影响
 func (s *ShortTermStore) AppendMessages(agentID, userID, sessionID string, messages domain.Messages) *domain.ShortTermMemory {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -71,7 +72,9 @@ func (s *ShortTermStore) AppendMessages(agentID, userID, sessionID string, messa
 		w.Messages = w.Messages[len(w.Messages)-s.windowSize:]
 	}
 
-	return w
+	snapshot := *w
+	snapshot.Messages = append(domain.Messages(nil), w.Messages...)
+	return &snapshot
 }
 
 // Clear 清除指定会话的短期记忆
@@ -82,6 +85,21 @@ func (s *ShortTermStore) Clear(agentID, userID, sessionID string) {
 	delete(s.windows, windowKey(agentID, userID, sessionID))
 }
 
+// ClearUser 清除 agent+user 下所有会话的短期记忆，返回清除的窗口数
+func (s *ShortTermStore) ClearUser(agentID, userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cleared := 0
+	for key, w := range s.windows {
+		if w.AgentID == agentID && w.UserID == userID {
+			delete(s.windows, key)
+			cleared++
+		}
+	}
+	return cleared
+}
+
 // ============================================================================
 // ShortTermAction - 写入时追加消息到滑动窗口
 // ============================================================================
@@ -97,7 +115,7 @@ type ShortTermAction struct {
 // NewShortTermAction 创建 ShortTermAction
 func NewShortTermAction() *ShortTermAction {
 	return &ShortTermAction{
-		BaseAction: NewBaseAction("short_term"),
+		BaseAction: NewBaseAction(ActionShortTerm),
 		store:      GetShortTermStore(),
 	}
 }
@@ -140,7 +158,7 @@ type ShortTermRecallAction struct {
 // NewShortTermRecallAction 创建 ShortTermRecallAction
 func NewShortTermRecallAction() *ShortTermRecallAction {
 	return &ShortTermRecallAction{
-		BaseAction: NewBaseAction("short_term_recall"),
+		BaseAction: NewBaseAction(ActionShortTermRecall),
 		store:      GetShortTermStore(),
 	}
 }
@@ -150,11 +168,11 @@ func (a *ShortTermRecallAction) Name() string {
 	return "short_term_recall"
 }
 
-// HandleRecall 获取短期记忆窗口内容
+// HandleRecall 获取短期记忆窗口内容，设置 Options.Roles 时只保留这些角色的消息
 func (a *ShortTermRecallAction) HandleRecall(c *domain.RecallContext) {
 	w := a.store.GetWindow(c.AgentID, c.UserID, c.SessionID)
 	if w != nil && len(w.Messages) > 0 {
-		c.ShortTerm = w.Messages
+		c.ShortTerm = filterRoles(w.Messages, c.Options.Roles)
 		a.logger.Info("short term recall",
 			"session_id", c.SessionID,
 			"messages", len(c.ShortTerm),
@@ -163,3 +181,18 @@ func (a *ShortTermRecallAction) HandleRecall(c *domain.RecallContext) {
 
 	c.Next()
 }
+
+// filterRoles 返回 roles 中角色的消息，roles 为空时原样返回
+func filterRoles(messages domain.Messages, roles []string) domain.Messages {
+	if len(roles) == 0 {
+		return messages
+	}
+
+	var filtered domain.Messages
+	for _, msg := range messages {
+		if slices.Contains(roles, msg.Role) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
@@ -1,8 +1,13 @@
 package action
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 
@@ -17,17 +22,43 @@ var _ domain.AddAction = (*SummaryMemoryAction)(nil)
 // LLM 单次调用输出：content + importance + memory_type + keywords
 type SummaryMemoryAction struct {
 	*BaseAction
-	store vector.Store
+	store         vector.Store
+	embedCaptions bool
+	// contextualCaptions 为 true 时附件描述连同发送者、所在消息与上一条消息一起向量化
+	contextualCaptions bool
+	cfg                SummaryConfig
 }
 
 // NewSummaryMemoryAction 创建 SummaryMemoryAction
 func NewSummaryMemoryAction() *SummaryMemoryAction {
 	return &SummaryMemoryAction{
-		BaseAction: NewBaseAction("summary_memory"),
-		store:      vector.NewStore(),
+		BaseAction:    NewBaseAction(ActionSummaryMemory),
+		store:         vector.NewStore(),
+		embedCaptions: GetConfig().EmbedAttachmentCaptions,
+		cfg:           GetConfig().Summary,
+
+		contextualCaptions: GetConfig().ContextualCaptionEmbedding,
 	}
 }
 
+// WithConfig 设置摘要记忆提取配置
+func (a *SummaryMemoryAction) WithConfig(cfg SummaryConfig) *SummaryMemoryAction {
+	a.cfg = cfg
+	return a
+}
+
+// WithAttachmentCaptions 设置是否将附件描述单独存为记忆
+func (a *SummaryMemoryAction) WithAttachmentCaptions(enabled bool) *SummaryMemoryAction {
+	a.embedCaptions = enabled
+	return a
+}
+
+// WithContextualCaptions 设置附件描述是否结合对话上下文向量化
+func (a *SummaryMemoryAction) WithContextualCaptions(enabled bool) *SummaryMemoryAction {
+	a.contextualCaptions = enabled
+	return a
+}
+
 // WithStore 设置存储（用于测试注入 mock）
 func (a *SummaryMemoryAction) WithStore(store vector.Store) *SummaryMemoryAction {
 	a.store = store
@@ -50,6 +81,7 @@ type ExtractedMemory struct {
 	Importance float64  `json:"importance"`
 	MemoryType string   `json:"memory_type"`
 	Keywords   []string `json:"keywords"`
+	Sentiment  string   `json:"sentiment"` // positive / negative / neutral
 }
 
 // Handle 执行摘要记忆提取
@@ -61,14 +93,28 @@ func (a *SummaryMemoryAction) Handle(c *domain.AddContext) {
 		return
 	}
 
+	if cfg := GetConfig(); cfg.BelowExtractionMinimum(c.Messages) {
+		a.logger.Debug("conversation below extraction minimum, skipping", "message_count", len(c.Messages))
+		c.Next()
+		return
+	}
+
+	// 附件描述直接作为 working 记忆
+	if a.embedCaptions {
+		a.storeAttachmentCaptions(c)
+	}
+
 	// 调用 LLM 提取记忆
 	conversation := c.Messages.Format()
-	var result MemoryExtractResult
-	if err := a.Generate(c, "memory_extract", map[string]any{
-		"conversation": conversation,
-		"language":     c.LanguageName(),
-	}, &result); err != nil {
+	maxLength := a.cfg.TargetLength(utf8.RuneCountInString(conversation))
+	result, err := a.extractMemories(c, conversation, maxLength)
+	if err != nil {
 		a.logger.Error("memory extraction failed", "error", err)
+		// LLM 不可用时终止链，其余错误（如输出解析失败）跳过本步骤
+		if errors.Is(err, domain.ErrLLMUnavailable) {
+			c.SetError(err)
+			return
+		}
 		c.Next()
 		return
 	}
@@ -79,41 +125,18 @@ func (a *SummaryMemoryAction) Handle(c *domain.AddContext) {
 		return
 	}
 
-	now := time.Now()
-	for _, mem := range result.Memories {
-		// 生成 embedding
-		embedding, err := a.GenEmbedding(c.Context, EmbedderName, mem.Content)
-		if err != nil {
-			a.logger.Warn("failed to generate embedding", "error", err)
-			continue
-		}
-
-		// importance >= 0.9 自动标记保护
-		isProtected := mem.Importance >= 0.9
-
-		summary := domain.SummaryMemory{
-			ID:             fmt.Sprintf("mem_%s", uuid.New().String()[:8]),
-			AgentID:        c.AgentID,
-			UserID:         c.UserID,
-			Content:        mem.Content,
-			MemoryType:     mem.MemoryType,
-			Importance:     mem.Importance,
-			Keywords:       mem.Keywords,
-			Embedding:      embedding,
-			IsProtected:    isProtected,
-			AccessCount:    0,
-			LastAccessedAt: now,
-			CreatedAt:      now,
-			UpdatedAt:      now,
-		}
+	// 超出上限时按重要性保留 top-N
+	if a.cfg.MaxMemories > 0 && len(result.Memories) > a.cfg.MaxMemories {
+		a.logger.Info("dropping low-importance memories", "extracted", len(result.Memories), "max_memories", a.cfg.MaxMemories)
+		result.Memories = capMemories(result.Memories, a.cfg.MaxMemories)
+	}
 
-		// 存储到 OpenSearch
-		if err := a.storeSummary(c, summary); err != nil {
-			a.logger.Warn("failed to store summary", "id", summary.ID, "error", err)
+	for _, r := range a.persistMemories(c, result.Memories) {
+		if r.warning != "" {
+			c.AddWarning("%s", r.warning)
 			continue
 		}
-
-		c.AddSummaries(summary)
+		c.AddSummaries(r.summary)
 	}
 
 	a.logger.Info("summary memories extracted",
@@ -123,8 +146,153 @@ func (a *SummaryMemoryAction) Handle(c *domain.AddContext) {
 	c.Next()
 }
 
+// persistedMemory 单条记忆向量化并存储的结果，失败时 warning 非空
+type persistedMemory struct {
+	summary domain.SummaryMemory
+	warning string
+}
+
+// persistMemories 为每条记忆生成 embedding 并存储，最多 cfg.Workers 条并行，结果保持提取顺序
+func (a *SummaryMemoryAction) persistMemories(c *domain.AddContext, memories []ExtractedMemory) []persistedMemory {
+	now := time.Now()
+	occurredAt := c.OccurredAtOr(now)
+
+	results := make([]persistedMemory, len(memories))
+	slots := make(chan struct{}, max(a.cfg.Workers, 1))
+	var wg sync.WaitGroup
+	for i, mem := range memories {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			results[i] = a.persistMemory(c, mem, now, occurredAt)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// persistMemory 生成单条记忆的 embedding 并存储，不修改 AddContext，可并发调用
+func (a *SummaryMemoryAction) persistMemory(c *domain.AddContext, mem ExtractedMemory, now, occurredAt time.Time) persistedMemory {
+	// 生成 embedding
+	embedding, err := a.GenEmbedding(c.Context, embedderOrDefault(a.cfg.Embedder), mem.Content)
+	if err != nil {
+		a.logger.Warn("failed to generate embedding", "error", err)
+		return persistedMemory{warning: fmt.Sprintf("%s: failed to embed memory: %v", a.Name(), err)}
+	}
+
+	// importance >= 0.9 自动标记保护
+	isProtected := mem.Importance >= 0.9
+
+	summary := domain.SummaryMemory{
+		ID:             fmt.Sprintf("mem_%s", uuid.New().String()[:8]),
+		AgentID:        c.AgentID,
+		UserID:         c.UserID,
+		SessionID:      c.SessionID,
+		Content:        mem.Content,
+		MemoryType:     mem.MemoryType,
+		Importance:     mem.Importance,
+		Keywords:       mem.Keywords,
+		Sentiment:      normalizeSentiment(mem.Sentiment),
+		Embedding:      embedding,
+		IsProtected:    isProtected,
+		AccessCount:    0,
+		LastAccessedAt: now,
+		OccurredAt:     occurredAt,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	// 存储到 OpenSearch
+	if err := a.storeSummary(c, summary, ""); err != nil {
+		a.logger.Warn("failed to store summary", "id", summary.ID, "error", err)
+		return persistedMemory{warning: fmt.Sprintf("%s: failed to store summary %s: %v", a.Name(), summary.ID, err)}
+	}
+
+	return persistedMemory{summary: summary}
+}
+
+// summaryMaxRetries 记忆超长时的最大重新提取次数
+const summaryMaxRetries = 1
+
+// extractMemories 调用 LLM 提取记忆，超过 maxLength 时重新提取，仍超长则截断
+func (a *SummaryMemoryAction) extractMemories(c *domain.AddContext, conversation string, maxLength int) (MemoryExtractResult, error) {
+	var result MemoryExtractResult
+	for attempt := 0; ; attempt++ {
+		result = MemoryExtractResult{}
+		if err := a.Generate(c, "memory_extract", map[string]any{
+			"conversation": conversation,
+			"context":      recentContext(c).Format(),
+			"language":     c.LanguageName(),
+			"max_length":   maxLength,
+		}, &result); err != nil {
+			return result, err
+		}
+
+		if !hasOverlongMemory(result.Memories, maxLength) || attempt >= summaryMaxRetries {
+			break
+		}
+		a.logger.Info("memory too long, re-prompting", "max_length", maxLength, "attempt", attempt+1)
+	}
+
+	for i := range result.Memories {
+		if content := []rune(result.Memories[i].Content); len(content) > maxLength {
+			a.logger.Warn("truncating overlong memory", "length", len(content), "max_length", maxLength)
+			result.Memories[i].Content = string(content[:maxLength])
+		}
+	}
+
+	return result, nil
+}
+
+// recentContext 返回短期窗口中本轮消息之前的部分，供提取时理解指代与上下文
+// 窗口由 ShortTermAction 在本 action 之前追加本轮消息，未启用短期窗口时为空
+func recentContext(c *domain.AddContext) domain.Messages {
+	if c.ShortTermWindow == nil {
+		return nil
+	}
+	window := c.ShortTermWindow.Messages
+	return window[:max(len(window)-len(c.Messages), 0)]
+}
+
+// capMemories 按 importance 保留前 n 条记忆（保持原有顺序）
+func capMemories(memories []ExtractedMemory, n int) []ExtractedMemory {
+	order := make([]int, len(memories))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return memories[order[i]].Importance > memories[order[j]].Importance
+	})
+
+	keep := make([]bool, len(memories))
+	for _, idx := range order[:n] {
+		keep[idx] = true
+	}
+
+	capped := make([]ExtractedMemory, 0, n)
+	for i, mem := range memories {
+		if keep[i] {
+			capped = append(capped, mem)
+		}
+	}
+	return capped
+}
+
+// hasOverlongMemory 是否存在超过 maxLength 的记忆
+func hasOverlongMemory(memories []ExtractedMemory, maxLength int) bool {
+	for _, mem := range memories {
+		if utf8.RuneCountInString(mem.Content) > maxLength {
+			return true
+		}
+	}
+	return false
+}
+
 // storeSummary 存储摘要记忆到 OpenSearch
-func (a *SummaryMemoryAction) storeSummary(c *domain.AddContext, s domain.SummaryMemory) error {
+// embeddingText 与 content 不同时一并保存，重建向量时按写入时的文本生成
+func (a *SummaryMemoryAction) storeSummary(c *domain.AddContext, s domain.SummaryMemory, embeddingText string) error {
 	if a.store == nil {
 		return nil
 	}
@@ -134,6 +302,7 @@ func (a *SummaryMemoryAction) storeSummary(c *domain.AddContext, s domain.Summar
 		"type":             domain.DocTypeSummary,
 		"agent_id":         s.AgentID,
 		"user_id":          s.UserID,
+		"session_id":       s.SessionID,
 		"content":          s.Content,
 		"memory_type":      s.MemoryType,
 		"importance":       s.Importance,
@@ -142,9 +311,100 @@ func (a *SummaryMemoryAction) storeSummary(c *domain.AddContext, s domain.Summar
 		"is_protected":     s.IsProtected,
 		"access_count":     s.AccessCount,
 		"last_accessed_at": s.LastAccessedAt,
+		"occurred_at":      s.OccurredAt,
 		"created_at":       s.CreatedAt,
 		"updated_at":       s.UpdatedAt,
 	}
 
+	if len(s.Attachments) > 0 {
+		doc["attachments"] = s.Attachments
+	}
+	if s.Sentiment != "" {
+		doc["sentiment"] = s.Sentiment
+	}
+	if embeddingText != "" && embeddingText != s.Content {
+		doc["embedding_text"] = embeddingText
+	}
+
 	return a.store.Store(c.Context, s.ID, doc)
 }
+
+// normalizeSentiment 规范化 LLM 输出的情感倾向，无法识别时返回空（不参与情感过滤）
+func normalizeSentiment(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !domain.IsSentiment(s) {
+		return ""
+	}
+	return s
+}
+
+// storeAttachmentCaptions 将带描述的附件存为 working 记忆，使其可被向量检索
+func (a *SummaryMemoryAction) storeAttachmentCaptions(c *domain.AddContext) {
+	now := time.Now()
+	for i, msg := range c.Messages {
+		// 附件描述属于单条消息，优先使用该消息的时间戳
+		occurredAt := c.OccurredAtOr(now)
+		if msg.Timestamp != nil {
+			occurredAt = *msg.Timestamp
+		}
+
+		for _, att := range msg.Attachments {
+			if att.Caption == "" {
+				continue
+			}
+
+			text := att.Caption
+			if a.contextualCaptions {
+				text = captionContext(c.Messages, i, att)
+			}
+
+			embedding, err := a.GenEmbedding(c.Context, embedderOrDefault(a.cfg.Embedder), text)
+			if err != nil {
+				a.logger.Warn("failed to generate caption embedding", "uri", att.URI, "error", err)
+				c.AddWarning("%s: failed to embed attachment %s: %v", a.Name(), att.URI, err)
+				continue
+			}
+
+			summary := domain.SummaryMemory{
+				ID:             fmt.Sprintf("mem_%s", uuid.New().String()[:8]),
+				AgentID:        c.AgentID,
+				UserID:         c.UserID,
+				SessionID:      c.SessionID,
+				Content:        att.Caption,
+				MemoryType:     domain.MemoryTypeWorking,
+				Importance:     0.5,
+				Keywords:       []string{att.Type},
+				Attachments:    []domain.Attachment{att},
+				Embedding:      embedding,
+				LastAccessedAt: now,
+				OccurredAt:     occurredAt,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}
+
+			if err := a.storeSummary(c, summary, text); err != nil {
+				a.logger.Warn("failed to store attachment caption", "id", summary.ID, "error", err)
+				c.AddWarning("%s: failed to store attachment %s: %v", a.Name(), att.URI, err)
+				continue
+			}
+
+			c.AddSummaries(summary)
+		}
+	}
+}
+
+// captionContext 返回用于向量化的附件上下文：上一条消息、发送者与所在消息正文，再附上描述
+// "它""这个"之类的指代只能从上下文中还原，单独向量化描述会丢失这些信息；记忆内容仍只保存描述本身
+func captionContext(messages domain.Messages, i int, att domain.Attachment) string {
+	var text string
+	if i > 0 {
+		text = messages[i-1 : i].Format()
+	}
+
+	msg := messages[i]
+	name := msg.Name
+	if name == "" {
+		name = msg.Role
+	}
+	return text + name + ": " + msg.Content + " [" + att.Type + ": " + att.Caption + "]"
+}
@@ -0,0 +1,379 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/domain"
+)
+
+func TestSummaryMemory_AttachmentCaptions(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetModelJSON(MemoryExtractResult{})
+
+	var embedded []string
+	helper.SetEmbedderFunc(func(text string) []float32 {
+		embedded = append(embedded, text)
+		return []float32{0.1, 0.2, 0.3}
+	})
+
+	newContext := func() *domain.AddContext {
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Messages = domain.Messages{
+			{
+				Role:    domain.RoleUser,
+				Name:    "小明",
+				Content: "看看我家的猫",
+				Attachments: []domain.Attachment{
+					{Type: domain.AttachmentTypeImage, URI: "https://example.com/cat.jpg", Caption: "一只橘猫趴在沙发上"},
+					{Type: domain.AttachmentTypeFile, URI: "https://example.com/raw.bin"},
+				},
+			},
+		}
+		return c
+	}
+
+	t.Run("caption embedded and stored", func(t *testing.T) {
+		embedded = nil
+		store := NewMockVectorStore()
+		c := newContext()
+
+		helper.NewSummaryMemoryAction().WithStore(store).WithAttachmentCaptions(true).Handle(c)
+
+		assert.Equal(t, []string{"一只橘猫趴在沙发上"}, embedded)
+		require.Len(t, store.StoreCalls, 1)
+		doc := store.StoreCalls[0].Doc
+		assert.Equal(t, "一只橘猫趴在沙发上", doc["content"])
+		assert.NotContains(t, doc, "embedding_text")
+		assert.Equal(t, domain.MemoryTypeWorking, doc["memory_type"])
+		assert.Equal(t, []domain.Attachment{c.Messages[0].Attachments[0]}, doc["attachments"])
+
+		require.Len(t, c.Summaries, 1)
+		assert.Equal(t, "https://example.com/cat.jpg", c.Summaries[0].Attachments[0].URI)
+	})
+
+	t.Run("contextual caption embedding", func(t *testing.T) {
+		embedded = nil
+		store := NewMockVectorStore()
+		c := newContext()
+		c.Messages = append(domain.Messages{{Role: domain.RoleAssistant, Name: "助手", Content: "你养了什么宠物？"}}, c.Messages...)
+
+		helper.NewSummaryMemoryAction().WithStore(store).WithAttachmentCaptions(true).WithContextualCaptions(true).Handle(c)
+
+		require.Len(t, embedded, 1)
+		assert.Equal(t, "助手: 你养了什么宠物？\n小明: 看看我家的猫 [image: 一只橘猫趴在沙发上]", embedded[0])
+		assert.NotEqual(t, "一只橘猫趴在沙发上", embedded[0])
+
+		require.Len(t, store.StoreCalls, 1)
+		assert.Equal(t, "一只橘猫趴在沙发上", store.StoreCalls[0].Doc["content"])
+		assert.Equal(t, embedded[0], store.StoreCalls[0].Doc["embedding_text"])
+		assert.Equal(t, embedded[0], embeddingText(store.StoreCalls[0].Doc))
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		embedded = nil
+		store := NewMockVectorStore()
+
+		helper.NewSummaryMemoryAction().WithStore(store).Handle(newContext())
+
+		assert.Empty(t, embedded)
+		assert.Empty(t, store.StoreCalls)
+	})
+
+	t.Run("caption included in conversation text", func(t *testing.T) {
+		text := newContext().Messages.Format()
+		assert.Contains(t, text, "[image: 一只橘猫趴在沙发上]")
+		assert.NotContains(t, text, "raw.bin")
+	})
+}
+
+func TestSummaryMemory_RecentContext(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	var prompt string
+	helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+		prompt = req.Messages[len(req.Messages)-1].Text()
+		return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage(`{"memories":[]}`)}, nil
+	})
+
+	current := domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "我也很喜欢它"}}
+
+	t.Run("earlier window messages are passed as context", func(t *testing.T) {
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Messages = current
+		c.ShortTermWindow = &domain.ShortTermMemory{Messages: append(domain.Messages{
+			{Role: domain.RoleUser, Name: "小红", Content: "我刚买了一台徕卡相机"},
+		}, current...)}
+
+		helper.NewSummaryMemoryAction().WithStore(NewMockVectorStore()).Handle(c)
+
+		require.NoError(t, c.Error())
+		assert.Contains(t, prompt, "# Recent Context")
+		assert.Contains(t, prompt, "小红: 我刚买了一台徕卡相机")
+		assert.Equal(t, 1, strings.Count(prompt, "我也很喜欢它"), "current messages are not repeated as context")
+	})
+
+	t.Run("no context section without earlier messages", func(t *testing.T) {
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Messages = current
+		c.ShortTermWindow = &domain.ShortTermMemory{Messages: current}
+
+		helper.NewSummaryMemoryAction().WithStore(NewMockVectorStore()).Handle(c)
+
+		assert.NotContains(t, prompt, "# Recent Context")
+		assert.Contains(t, prompt, "小明: 我也很喜欢它")
+	})
+}
+
+func TestSummaryMemory_Workers(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+
+	contents := []string{"记忆一", "记忆二", "记忆三", "记忆四"}
+	var memories []ExtractedMemory
+	for _, content := range contents {
+		memories = append(memories, ExtractedMemory{Content: content, Importance: 0.5, MemoryType: domain.MemoryTypeWorking})
+	}
+	helper.SetModelJSON(MemoryExtractResult{Memories: memories})
+
+	// 越靠前的记忆 embedding 越慢，并行时完成顺序与提取顺序相反
+	var inFlight, maxInFlight atomic.Int32
+	helper.SetEmbedderFunc(func(text string) []float32 {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		for i, content := range contents {
+			if content == text {
+				time.Sleep(time.Duration(len(contents)-i) * 10 * time.Millisecond)
+			}
+		}
+		return []float32{0.1, 0.2, 0.3}
+	})
+
+	run := func(workers int) *domain.AddContext {
+		inFlight.Store(0)
+		maxInFlight.Store(0)
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Messages = domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "今天做了很多事"}}
+		helper.NewSummaryMemoryAction().WithStore(NewMockVectorStore()).WithConfig(SummaryConfig{MaxLength: 50, Workers: workers}).Handle(c)
+		require.NoError(t, c.Error())
+		return c
+	}
+
+	stored := func(c *domain.AddContext) []string {
+		var got []string
+		for _, s := range c.Summaries {
+			got = append(got, s.Content)
+		}
+		return got
+	}
+
+	t.Run("parallel keeps extraction order", func(t *testing.T) {
+		c := run(4)
+		assert.Equal(t, contents, stored(c))
+		assert.Greater(t, maxInFlight.Load(), int32(1))
+	})
+
+	t.Run("sequential by default", func(t *testing.T) {
+		c := run(0)
+		assert.Equal(t, contents, stored(c))
+		assert.Equal(t, int32(1), maxInFlight.Load())
+	})
+
+	t.Run("negative workers rejected", func(t *testing.T) {
+		cfg := SummaryConfig{Workers: -1}
+		assert.Error(t, cfg.Validate())
+	})
+}
+
+func TestSummaryMemory_MaxLength(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+
+	long := strings.Repeat("长", 80)
+	short := strings.Repeat("短", 30)
+
+	// 依次返回 responses，超出后重复最后一个
+	setResponses := func(contents ...string) *[]string {
+		var prompts []string
+		calls := 0
+		helper.MockPlugin.SetModelResponse("doubao-pro-32k", func(ctx context.Context, req *ai.ModelRequest) (*ai.ModelResponse, error) {
+			prompts = append(prompts, req.Messages[len(req.Messages)-1].Text())
+			content := contents[min(calls, len(contents)-1)]
+			calls++
+			data, _ := json.Marshal(MemoryExtractResult{Memories: []ExtractedMemory{
+				{Content: content, Importance: 0.5, MemoryType: domain.MemoryTypeWorking},
+			}})
+			return &ai.ModelResponse{Request: req, Message: ai.NewModelTextMessage(string(data))}, nil
+		})
+		return &prompts
+	}
+
+	newContext := func() *domain.AddContext {
+		c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+		c.Messages = domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "今天天气不错"}}
+		return c
+	}
+
+	t.Run("re-prompts when too long", func(t *testing.T) {
+		prompts := setResponses(long, short)
+		c := newContext()
+
+		helper.NewSummaryMemoryAction().WithStore(NewMockVectorStore()).WithConfig(SummaryConfig{MaxLength: 50}).Handle(c)
+
+		assert.Len(t, *prompts, 2)
+		assert.Contains(t, (*prompts)[0], "不超过 50 个字")
+		require.Len(t, c.Summaries, 1)
+		assert.Equal(t, short, c.Summaries[0].Content)
+	})
+
+	t.Run("truncates when still too long", func(t *testing.T) {
+		prompts := setResponses(long)
+		c := newContext()
+
+		helper.NewSummaryMemoryAction().WithStore(NewMockVectorStore()).WithConfig(SummaryConfig{MaxLength: 50}).Handle(c)
+
+		assert.Len(t, *prompts, 1+summaryMaxRetries)
+		require.Len(t, c.Summaries, 1)
+		assert.Equal(t, 50, utf8.RuneCountInString(c.Summaries[0].Content))
+	})
+}
+
+func TestSummaryMemory_MaxMemories(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(MemoryExtractResult{Memories: []ExtractedMemory{
+		{Content: "用户今天心情不错", Importance: 0.3, MemoryType: domain.MemoryTypeWorking},
+		{Content: "用户住在北京", Importance: 0.9, MemoryType: domain.MemoryTypeFact},
+		{Content: "用户提到了天气", Importance: 0.1, MemoryType: domain.MemoryTypeWorking},
+		{Content: "用户是程序员", Importance: 0.8, MemoryType: domain.MemoryTypeFact},
+	}})
+
+	store := NewMockVectorStore()
+	c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+	c.Messages = domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "我是程序员，住在北京，今天天气不错"}}
+
+	helper.NewSummaryMemoryAction().WithStore(store).WithConfig(SummaryConfig{MaxLength: 50, MaxMemories: 2}).Handle(c)
+
+	require.Len(t, c.Summaries, 2)
+	assert.Equal(t, "用户住在北京", c.Summaries[0].Content)
+	assert.Equal(t, "用户是程序员", c.Summaries[1].Content)
+	assert.Len(t, store.StoreCalls, 2)
+}
+
+func TestSummaryMemory_Sentiment(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(MemoryExtractResult{Memories: []ExtractedMemory{
+		{Content: "用户非常喜欢这家咖啡店的拿铁", Importance: 0.7, MemoryType: domain.MemoryTypeFact, Sentiment: "Positive"},
+		{Content: "用户讨厌排长队", Importance: 0.7, MemoryType: domain.MemoryTypeFact, Sentiment: "negative"},
+		{Content: "用户住在杭州", Importance: 0.8, MemoryType: domain.MemoryTypeFact, Sentiment: "unsure"},
+	}})
+
+	store := newMemoryVectorStore(3)
+	c := domain.NewAddContext(ctx, "agent_1", "user_1", "session_1")
+	c.Messages = domain.Messages{{Role: domain.RoleUser, Name: "小明", Content: "我太喜欢这家店的拿铁了！就是排队太烦"}}
+
+	helper.NewSummaryMemoryAction().WithStore(store).Handle(c)
+	require.Len(t, c.Summaries, 3)
+
+	t.Run("positive message gets a positive tag", func(t *testing.T) {
+		assert.Equal(t, domain.SentimentPositive, c.Summaries[0].Sentiment)
+		assert.Equal(t, domain.SentimentPositive, store.docs[c.Summaries[0].ID]["sentiment"])
+		assert.Equal(t, domain.SentimentNegative, c.Summaries[1].Sentiment)
+	})
+
+	t.Run("unknown sentiment is not stored", func(t *testing.T) {
+		assert.Empty(t, c.Summaries[2].Sentiment)
+		assert.NotContains(t, store.docs[c.Summaries[2].ID], "sentiment")
+	})
+
+	t.Run("retrieval filters by sentiment", func(t *testing.T) {
+		resp, err := NewMemory().WithStores(store, NewMockRelationStore()).Retrieve(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "用户喜欢什么",
+			Options: domain.RetrieveOptions{Sentiment: domain.SentimentPositive},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{c.Summaries[0].ID}, summaryIDs(resp.Facts))
+	})
+
+	t.Run("invalid sentiment filter is rejected", func(t *testing.T) {
+		_, err := NewMemory().WithStores(store, NewMockRelationStore()).Retrieve(ctx, &domain.RetrieveRequest{
+			AgentID: "agent_1",
+			UserID:  "user_1",
+			Query:   "用户喜欢什么",
+			Options: domain.RetrieveOptions{Sentiment: "happy"},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+func TestMemory_AddBackfillsTimestamp(t *testing.T) {
+	ctx := context.Background()
+	helper := NewTestHelper(ctx)
+	helper.SetEmbedderVector([]float32{0.1, 0.2, 0.3})
+	helper.SetModelJSON(map[string]any{
+		"memories": []ExtractedMemory{
+			{Content: "用户 2023 年搬到了上海", Importance: 0.8, MemoryType: domain.MemoryTypeFact},
+		},
+		"events": []ExtractedEvent{
+			{TriggerWord: "搬到了", Argument1: "小明", Argument2: "上海"},
+		},
+	})
+
+	past := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := NewMockVectorStore()
+	resp, err := NewMemory().WithStores(store, NewMockRelationStore()).Add(ctx, &domain.AddRequest{
+		AgentID:   "agent_1",
+		UserID:    "user_1",
+		SessionID: "session_backfill",
+		Messages:  []domain.Message{{Role: domain.RoleUser, Name: "小明", Content: "我搬到上海了", Timestamp: &past}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, resp.Summaries, 1)
+	assert.Equal(t, past, resp.Summaries[0].OccurredAt)
+	assert.WithinDuration(t, time.Now(), resp.Summaries[0].CreatedAt, time.Minute)
+
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, past, resp.Events[0].OccurredAt)
+
+	for _, call := range store.StoreCalls {
+		assert.Equal(t, past, call.Doc["occurred_at"])
+	}
+}
+
+func TestSummaryConfig_TargetLength(t *testing.T) {
+	cfg := SummaryConfig{MaxLength: 200}
+	assert.Equal(t, 200, cfg.TargetLength(10000))
+
+	cfg.CompressionRatio = 0.1
+	assert.Equal(t, 100, cfg.TargetLength(1000))
+	assert.Equal(t, 200, cfg.TargetLength(5000))
+	assert.Equal(t, MinSummaryLength, cfg.TargetLength(50))
+
+	invalid := SummaryConfig{CompressionRatio: 1.5}
+	assert.Error(t, invalid.Validate())
+}
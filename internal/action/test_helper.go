@@ -3,6 +3,8 @@ package action
 import (
 	"context"
 
+	"github.com/firebase/genkit/go/ai"
+
 	pkggenkit "github.com/Zereker/memory/pkg/genkit"
 )
 
@@ -18,10 +20,15 @@ func NewTestHelper(ctx context.Context) *TestHelper {
 		Provider: "ark",
 		Models: []pkggenkit.ModelConfig{
 			{Name: "doubao-pro-32k", Type: pkggenkit.ModelTypeLLM, Model: "doubao-pro-32k"},
+			{Name: "doubao-lite", Type: pkggenkit.ModelTypeLLM, Model: "doubao-lite"},
 			{Name: "doubao-embedding-text-240715", Type: pkggenkit.ModelTypeEmbedding, Model: "doubao-embedding", Dim: 4096},
+			{Name: "doubao-embedding-event", Type: pkggenkit.ModelTypeEmbedding, Model: "doubao-embedding-event", Dim: 4096},
 		},
 	}, "prompts")
 
+	// 每个测试从关闭的熔断器开始，避免前序失败用例影响
+	LLMBreaker().Reset()
+
 	return &TestHelper{
 		MockPlugin: mockPlugin,
 	}
@@ -32,6 +39,21 @@ func (h *TestHelper) SetEmbedderVector(vector []float32) {
 	h.MockPlugin.SetEmbedderVectorResponse("doubao-embedding-text-240715", vector)
 }
 
+// SetEmbedderFunc sets a text-dependent vector response for the default embedder
+func (h *TestHelper) SetEmbedderFunc(fn func(text string) []float32) {
+	h.MockPlugin.SetEmbedderResponse("doubao-embedding-text-240715", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+		embeddings := make([]*ai.Embedding, len(req.Input))
+		for i, doc := range req.Input {
+			var text string
+			for _, part := range doc.Content {
+				text += part.Text
+			}
+			embeddings[i] = &ai.Embedding{Embedding: fn(text)}
+		}
+		return &ai.EmbedResponse{Embeddings: embeddings}, nil
+	})
+}
+
 // SetModelJSON sets the JSON response for the default model
 func (h *TestHelper) SetModelJSON(response any) {
 	h.MockPlugin.SetModelJSONResponse("doubao-pro-32k", response)
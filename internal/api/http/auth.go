@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKey grants access to the HTTP API, optionally scoped to a set of agents
+type APIKey struct {
+	Key      string   `toml:"key"`
+	AgentIDs []string `toml:"agent_ids"` // empty means all agents
+	Admin    bool     `toml:"admin"`     // allows maintenance endpoints under /api/v1/admin and memory deletion by ID
+}
+
+// Validate checks an API key entry
+func (k *APIKey) Validate() error {
+	if k.Key == "" {
+		return fmt.Errorf("key is required")
+	}
+	return nil
+}
+
+// allowsAgent reports whether the key may access the given agent
+func (k *APIKey) allowsAgent(agentID string) bool {
+	if len(k.AgentIDs) == 0 {
+		return true
+	}
+	for _, id := range k.AgentIDs {
+		if id == agentID {
+			return true
+		}
+	}
+	return false
+}
+
+type apiKeyContextKey struct{}
+
+// authMiddleware rejects requests without a valid API key with 401.
+// The key is read from "Authorization: Bearer <key>" or "X-API-Key".
+// Health checks are always allowed; with no keys configured auth is disabled.
+func authMiddleware(keys []APIKey, next http.Handler) http.Handler {
+	if len(keys) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" || r.URL.Path == "/api/v1/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, ok := matchAPIKey(keys, requestAPIKey(r))
+		if !ok {
+			writeJSON(w, http.StatusUnauthorized, Response{Success: false, Error: "missing or invalid api key"})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+	})
+}
+
+// requestAPIKey extracts the API key from the request headers
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// matchAPIKey finds the configured key equal to the presented one
+func matchAPIKey(keys []APIKey, presented string) (*APIKey, bool) {
+	if presented == "" {
+		return nil, false
+	}
+	for i := range keys {
+		if subtle.ConstantTimeCompare([]byte(keys[i].Key), []byte(presented)) == 1 {
+			return &keys[i], true
+		}
+	}
+	return nil, false
+}
+
+// authorizeAdmin reports whether the authenticated key may call maintenance endpoints.
+// Requests that passed no auth (auth disabled) are always allowed.
+func authorizeAdmin(ctx context.Context) bool {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	if !ok {
+		return true
+	}
+	return key.Admin
+}
+
+// authorizeAgent reports whether the authenticated key may access agentID.
+// Requests that passed no auth (auth disabled) are always allowed.
+func authorizeAgent(ctx context.Context, agentID string) bool {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*APIKey)
+	if !ok {
+		return true
+	}
+	return key.allowsAgent(agentID)
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	mux, _ := newTestMux(t)
+	h := authMiddleware([]APIKey{
+		{Key: "scoped-key", AgentIDs: []string{"agent_1"}},
+		{Key: "admin-key", Admin: true},
+	}, mux)
+
+	// 只有 system 消息的 add 会直接终止，不依赖 LLM 和存储
+	const addBody = `{"agent_id":"%s","user_id":"user_1","messages":[{"role":"system","content":"你是助手"}]}`
+
+	do := func(agentID string, header, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/memories/add", strings.NewReader(fmt.Sprintf(addBody, agentID)))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("valid bearer key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("agent_1", "Authorization", "Bearer scoped-key"))
+	})
+
+	t.Run("valid x-api-key header", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do("agent_2", "X-API-Key", "admin-key"))
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("agent_1", "", ""))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, do("agent_1", "Authorization", "Bearer nope"))
+	})
+
+	t.Run("scope mismatch", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, do("agent_2", "Authorization", "Bearer scoped-key"))
+	})
+
+	t.Run("admin endpoint needs admin key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/reindex", strings.NewReader(`{"agent_id":"agent_1"}`))
+		req.Header.Set("Authorization", "Bearer scoped-key")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("memory delete needs admin key", func(t *testing.T) {
+		del := func(key string) int {
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/memories/mem_1", nil)
+			req.Header.Set("Authorization", "Bearer "+key)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusForbidden, del("scoped-key"))
+		assert.Equal(t, http.StatusNotImplemented, del("admin-key"))
+	})
+
+	t.Run("health needs no key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -2,18 +2,40 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"unicode/utf8"
 
 	"github.com/Zereker/memory/internal/action"
 	"github.com/Zereker/memory/internal/domain"
 	"github.com/Zereker/memory/pkg/log"
 )
 
+// Limits caps the size of incoming requests
+type Limits struct {
+	MaxBodyBytes     int64 // request body size, exceeded yields 413
+	MaxMessages      int   // messages per add request
+	MaxContentLength int   // total characters across all message contents
+	MaxImportBytes   int64 // import body size, exported documents carry their embeddings
+}
+
+// DefaultLimits returns default request limits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxBodyBytes:     1 << 20, // 1 MiB
+		MaxMessages:      100,
+		MaxContentLength: 20000,
+		MaxImportBytes:   64 << 20, // 64 MiB
+	}
+}
+
 // Handler handles HTTP API requests
 type Handler struct {
 	logger *slog.Logger
 	memory *action.Memory
+	limits Limits
 }
 
 // NewHandler creates a new HTTP handler
@@ -21,10 +43,29 @@ func NewHandler(memory *action.Memory) *Handler {
 	return &Handler{
 		logger: log.Logger("http.handler"),
 		memory: memory,
+		limits: DefaultLimits(),
+	}
+}
+
+// WithLimits sets request limits, zero fields keep their defaults
+func (h *Handler) WithLimits(limits Limits) *Handler {
+	if limits.MaxBodyBytes > 0 {
+		h.limits.MaxBodyBytes = limits.MaxBodyBytes
+	}
+	if limits.MaxMessages > 0 {
+		h.limits.MaxMessages = limits.MaxMessages
 	}
+	if limits.MaxContentLength > 0 {
+		h.limits.MaxContentLength = limits.MaxContentLength
+	}
+	if limits.MaxImportBytes > 0 {
+		h.limits.MaxImportBytes = limits.MaxImportBytes
+	}
+	return h
 }
 
-// Response represents a standard API response
+// Response represents a standard API response.
+// For add and retrieve, Success mirrors the payload's success: an aborted chain is a 200 with success false.
 type Response struct {
 	Success bool   `json:"success"`
 	Data    any    `json:"data,omitempty"`
@@ -38,8 +79,21 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /api/v1/memories/retrieve", h.Retrieve)
 	mux.HandleFunc("GET /api/v1/memories/retrieve", h.Retrieve)
 	mux.HandleFunc("POST /api/v1/memories/forget", h.Forget)
+	mux.HandleFunc("POST /api/v1/memories/compact", h.Compact)
+	mux.HandleFunc("POST /api/v1/memories/restore", h.RestoreFact)
 	mux.HandleFunc("DELETE /api/v1/memories/{id}", h.Delete)
 
+	// User data
+	mux.HandleFunc("GET /api/v1/users/{agent}/{user}/export", h.Export)
+	mux.HandleFunc("POST /api/v1/users/{agent}/{user}/import", h.Import)
+	mux.HandleFunc("DELETE /api/v1/users/{agent}/{user}", h.DeleteUser)
+	mux.HandleFunc("GET /api/v1/users/{agent}/{user}/activity", h.Activity)
+	mux.HandleFunc("GET /api/v1/users/{agent}/{user}/conflicts", h.Conflicts)
+
+	// Maintenance (admin keys only)
+	mux.HandleFunc("POST /api/v1/admin/reindex", h.Reindex)
+	mux.HandleFunc("POST /api/v1/admin/migrate", h.MigrateIndex)
+
 	// Health check
 	mux.HandleFunc("GET /health", h.Health)
 	mux.HandleFunc("GET /api/v1/health", h.Health)
@@ -48,20 +102,32 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 // Add handles POST /api/v1/memories/add
 func (h *Handler) Add(w http.ResponseWriter, r *http.Request) {
 	var req domain.AddRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	agentID := req.AgentID
+	if agentID == "" {
+		agentID = domain.Messages(req.Messages).AssistantName()
+	}
+	if !h.authorize(w, r, agentID) {
+		return
+	}
+
+	if err := h.checkMessageLimits(req.Messages); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	resp, err := h.memory.Add(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("add failed", "error", err)
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+		h.writeError(w, statusFromError(err), err.Error())
 		return
 	}
 
 	h.writeJSON(w, http.StatusOK, Response{
-		Success: true,
+		Success: resp.Success,
 		Data:    resp,
 	})
 }
@@ -76,26 +142,24 @@ func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
 		req.SessionID = r.URL.Query().Get("session_id")
 		req.Query = r.URL.Query().Get("query")
 	} else {
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			h.writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		if !h.decode(w, r, &req) {
 			return
 		}
 	}
 
-	if req.AgentID == "" || req.UserID == "" || req.Query == "" {
-		h.writeError(w, http.StatusBadRequest, "agent_id, user_id, and query are required")
+	if !h.authorize(w, r, req.AgentID) {
 		return
 	}
 
 	resp, err := h.memory.Retrieve(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("retrieve failed", "error", err)
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+		h.writeError(w, statusFromError(err), err.Error())
 		return
 	}
 
 	h.writeJSON(w, http.StatusOK, Response{
-		Success: true,
+		Success: resp.Success,
 		Data:    resp,
 	})
 }
@@ -103,20 +167,90 @@ func (h *Handler) Retrieve(w http.ResponseWriter, r *http.Request) {
 // Forget handles POST /api/v1/memories/forget
 func (h *Handler) Forget(w http.ResponseWriter, r *http.Request) {
 	var req domain.ForgetRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+	if !h.decode(w, r, &req) {
 		return
 	}
 
-	if req.AgentID == "" || req.UserID == "" {
-		h.writeError(w, http.StatusBadRequest, "agent_id and user_id are required")
+	if !h.authorize(w, r, req.AgentID) {
 		return
 	}
 
 	resp, err := h.memory.Forget(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("forget failed", "error", err)
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// RestoreFact handles POST /api/v1/memories/restore
+func (h *Handler) RestoreFact(w http.ResponseWriter, r *http.Request) {
+	var req domain.RestoreFactRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if !h.authorize(w, r, req.AgentID) {
+		return
+	}
+
+	resp, err := h.memory.RestoreFact(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("restore fact failed", "id", req.ID, "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Compact handles POST /api/v1/memories/compact
+func (h *Handler) Compact(w http.ResponseWriter, r *http.Request) {
+	var req domain.CompactRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if !h.authorize(w, r, req.AgentID) {
+		return
+	}
+
+	resp, err := h.memory.Compact(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("compact failed", "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Reindex handles POST /api/v1/admin/reindex
+func (h *Handler) Reindex(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req domain.ReindexRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	resp, err := h.memory.Reindex(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("reindex failed", "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
 		return
 	}
 
@@ -126,8 +260,158 @@ func (h *Handler) Forget(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// MigrateIndex handles POST /api/v1/admin/migrate
+func (h *Handler) MigrateIndex(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
+	var req domain.MigrateIndexRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	resp, err := h.memory.MigrateIndex(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("migrate index failed", "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Export handles GET /api/v1/users/{agent}/{user}/export
+// It streams one JSON record per line; errors after the first line can only be logged.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	agentID, userID := r.PathValue("agent"), r.PathValue("user")
+	if !h.authorize(w, r, agentID) {
+		return
+	}
+
+	out := &streamWriter{w: w}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+
+	resp, err := h.memory.Export(r.Context(), agentID, userID, out)
+	if err != nil {
+		h.logger.Error("export failed", "agent_id", agentID, "user_id", userID, "error", err)
+		if !out.written {
+			h.writeError(w, statusFromError(err), err.Error())
+		}
+		return
+	}
+
+	h.logger.Info("export completed", "agent_id", agentID, "user_id", userID, "documents", resp.Documents, "relations", resp.Relations)
+}
+
+// Import handles POST /api/v1/users/{agent}/{user}/import
+// The body is the NDJSON produced by Export, capped by MaxImportBytes instead of MaxBodyBytes.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	agentID, userID := r.PathValue("agent"), r.PathValue("user")
+	if !h.authorize(w, r, agentID) {
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, h.limits.MaxImportBytes)
+	resp, err := h.memory.Import(r.Context(), agentID, userID, body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		h.logger.Error("import failed", "agent_id", agentID, "user_id", userID, "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// DeleteUser handles DELETE /api/v1/users/{agent}/{user}
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	agentID, userID := r.PathValue("agent"), r.PathValue("user")
+	if !h.authorize(w, r, agentID) {
+		return
+	}
+
+	resp, err := h.memory.DeleteUser(r.Context(), agentID, userID)
+	if err != nil {
+		h.logger.Error("delete user failed", "agent_id", agentID, "user_id", userID, "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Activity handles GET /api/v1/users/{agent}/{user}/activity?interval=day
+func (h *Handler) Activity(w http.ResponseWriter, r *http.Request) {
+	agentID, userID := r.PathValue("agent"), r.PathValue("user")
+	if !h.authorize(w, r, agentID) {
+		return
+	}
+
+	resp, err := h.memory.Activity(r.Context(), agentID, userID, r.URL.Query().Get("interval"))
+	if err != nil {
+		h.logger.Error("activity failed", "agent_id", agentID, "user_id", userID, "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Conflicts handles GET /api/v1/users/{agent}/{user}/conflicts
+func (h *Handler) Conflicts(w http.ResponseWriter, r *http.Request) {
+	agentID, userID := r.PathValue("agent"), r.PathValue("user")
+	if !h.authorize(w, r, agentID) {
+		return
+	}
+
+	resp, err := h.memory.Conflicts(r.Context(), agentID, userID)
+	if err != nil {
+		h.logger.Error("conflicts failed", "agent_id", agentID, "user_id", userID, "error", err)
+		h.writeError(w, statusFromError(err), err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, Response{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// streamWriter records whether a streamed response has started, after which the status can no longer change
+type streamWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.written = true
+	return s.w.Write(p)
+}
+
 // Delete handles DELETE /api/v1/memories/{id}
+// The memory ID does not identify its agent, so only admin keys may call it
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.authorizeAdmin(w, r) {
+		return
+	}
+
 	id := r.PathValue("id")
 	if id == "" {
 		h.writeError(w, http.StatusBadRequest, "memory id is required")
@@ -136,7 +420,7 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	if err := h.memory.Delete(r.Context(), id); err != nil {
 		h.logger.Error("delete failed", "id", id, "error", err)
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+		h.writeError(w, statusFromError(err), err.Error())
 		return
 	}
 
@@ -156,8 +440,81 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// statusFromError maps domain error categories to HTTP status codes
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrLLMUnavailable):
+		return http.StatusBadGateway
+	case errors.Is(err, domain.ErrStoreUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrNotImplemented):
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// decode reads the JSON body into v under the body size limit.
+// It writes 413 when the body is too large and 400 when it is malformed.
+func (h *Handler) decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, h.limits.MaxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return false
+		}
+		h.writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// checkMessageLimits enforces the message count and total content length limits
+func (h *Handler) checkMessageLimits(messages []domain.Message) error {
+	if len(messages) > h.limits.MaxMessages {
+		return fmt.Errorf("too many messages: %d, max %d", len(messages), h.limits.MaxMessages)
+	}
+
+	total := 0
+	for _, msg := range messages {
+		total += utf8.RuneCountInString(msg.Content)
+	}
+	if total > h.limits.MaxContentLength {
+		return fmt.Errorf("message content too long: %d characters, max %d", total, h.limits.MaxContentLength)
+	}
+	return nil
+}
+
+// authorize writes 403 and returns false when the API key is not scoped to agentID
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request, agentID string) bool {
+	if authorizeAgent(r.Context(), agentID) {
+		return true
+	}
+	h.writeError(w, http.StatusForbidden, "api key is not allowed for agent: "+agentID)
+	return false
+}
+
+// authorizeAdmin writes 403 and returns false when the API key is not an admin key
+func (h *Handler) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if authorizeAdmin(r.Context()) {
+		return true
+	}
+	h.writeError(w, http.StatusForbidden, "api key is not allowed to call admin endpoints")
+	return false
+}
+
 // writeJSON writes a JSON response
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, data)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(data)
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/action"
+	"github.com/Zereker/memory/internal/domain"
+	pkggenkit "github.com/Zereker/memory/pkg/genkit"
+)
+
+func newTestMux(t *testing.T) (*http.ServeMux, *pkggenkit.MockPlugin) {
+	t.Helper()
+
+	mockPlugin := pkggenkit.InitForTest(context.Background(), pkggenkit.MockConfig{
+		Provider: "ark",
+		Models: []pkggenkit.ModelConfig{
+			{Name: "doubao-pro-32k", Type: pkggenkit.ModelTypeLLM, Model: "doubao-pro-32k"},
+			{Name: "doubao-embedding-text-240715", Type: pkggenkit.ModelTypeEmbedding, Model: "doubao-embedding", Dim: 4096},
+		},
+	}, "../../action/prompts")
+
+	mux := http.NewServeMux()
+	NewHandler(action.NewMemory()).RegisterRoutes(mux)
+	return mux, mockPlugin
+}
+
+func doJSON(mux *http.ServeMux, method, path, body string) (*httptest.ResponseRecorder, Response) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var resp Response
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec, resp
+}
+
+func TestHandler_ErrorStatus(t *testing.T) {
+	mux, mockPlugin := newTestMux(t)
+
+	t.Run("llm failure yields 502", func(t *testing.T) {
+		mockPlugin.SetEmbedderResponse("doubao-embedding-text-240715", func(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+			return nil, errors.New("upstream timeout")
+		})
+
+		rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/retrieve",
+			`{"agent_id":"agent_1","user_id":"user_1","query":"用户住在哪里"}`)
+
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		assert.False(t, resp.Success)
+		assert.Contains(t, resp.Error, "upstream timeout")
+	})
+
+	t.Run("invalid retrieve input yields 400", func(t *testing.T) {
+		rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/retrieve",
+			`{"agent_id":"agent_1","user_id":"user_1"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, resp.Error, "query")
+	})
+
+	t.Run("invalid add input yields 400", func(t *testing.T) {
+		rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/add",
+			`{"agent_id":"agent_1","user_id":"user_1","messages":[]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, resp.Error, "messages")
+	})
+}
+
+func TestHandler_AbortedEnvelope(t *testing.T) {
+	mux, _ := newTestMux(t)
+
+	rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/add",
+		`{"agent_id":"agent_1","user_id":"user_1","messages":[{"role":"system","content":"你是助手"}]}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, resp.Success)
+	data, ok := resp.Data.(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, false, data["success"])
+	assert.NotEmpty(t, data["abort_reason"])
+}
+
+func TestStatusFromError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{domain.ErrInvalidInput, http.StatusBadRequest},
+		{domain.ErrNotFound, http.StatusNotFound},
+		{domain.ErrLLMUnavailable, http.StatusBadGateway},
+		{domain.ErrStoreUnavailable, http.StatusServiceUnavailable},
+		{domain.ErrNotImplemented, http.StatusNotImplemented},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, statusFromError(errors.Join(errors.New("wrapped"), tc.err)), tc.err.Error())
+	}
+}
+
+func TestHandler_RequestLimits(t *testing.T) {
+	newTestMux(t)
+
+	mux := http.NewServeMux()
+	NewHandler(action.NewMemory()).WithLimits(Limits{
+		MaxBodyBytes:     512,
+		MaxMessages:      2,
+		MaxContentLength: 10,
+	}).RegisterRoutes(mux)
+
+	t.Run("oversized body yields 413", func(t *testing.T) {
+		body := `{"agent_id":"agent_1","user_id":"user_1","messages":[{"role":"user","content":"` + strings.Repeat("a", 1024) + `"}]}`
+		rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/add", body)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Contains(t, resp.Error, "512 bytes")
+	})
+
+	t.Run("too many messages yields 400", func(t *testing.T) {
+		rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/add",
+			`{"agent_id":"agent_1","user_id":"user_1","messages":[{"role":"user","content":"a"},{"role":"user","content":"b"},{"role":"user","content":"c"}]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, resp.Error, "too many messages")
+	})
+
+	t.Run("content too long yields 400", func(t *testing.T) {
+		rec, resp := doJSON(mux, http.MethodPost, "/api/v1/memories/add",
+			`{"agent_id":"agent_1","user_id":"user_1","messages":[{"role":"user","content":"我今天去了星巴克喝咖啡"}]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, resp.Error, "too long")
+	})
+
+	t.Run("oversized retrieve body yields 413", func(t *testing.T) {
+		body := `{"agent_id":"agent_1","user_id":"user_1","query":"` + strings.Repeat("a", 1024) + `"}`
+		rec, _ := doJSON(mux, http.MethodPost, "/api/v1/memories/retrieve", body)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
@@ -24,6 +24,8 @@ type ServerConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	APIKeys      []APIKey // empty disables authentication
+	Limits       Limits
 }
 
 // DefaultServerConfig returns default server configuration
@@ -33,19 +35,21 @@ func DefaultServerConfig() ServerConfig {
 		Port:         8080,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
+		Limits:       DefaultLimits(),
 	}
 }
 
 // NewServer creates a new HTTP server
 func NewServer(memory *action.Memory, config ServerConfig) *Server {
 	logger := log.Logger("http")
-	handler := NewHandler(memory)
+	handler := NewHandler(memory).WithLimits(config.Limits)
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
 
 	// Wrap with middleware
 	var h http.Handler = mux
+	h = authMiddleware(config.APIKeys, h)
 	h = loggingMiddleware(logger, h)
 	h = recoveryMiddleware(logger, h)
 	h = corsMiddleware(h)
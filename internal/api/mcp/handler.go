@@ -68,12 +68,17 @@ func (h *Handler) handleAdd(ctx context.Context, args json.RawMessage) ToolCallR
 		return errorResponse(fmt.Sprintf("add failed: %v", err))
 	}
 
-	return successResponse(fmt.Sprintf(
+	text := fmt.Sprintf(
 		"成功添加记忆:\n- 摘要记忆: %d\n- 事件三元组: %d\n- 事件关系: %d",
 		len(resp.Summaries),
 		len(resp.Events),
 		len(resp.EventRelations),
-	))
+	)
+	if len(resp.Warnings) > 0 {
+		text += "\n\n警告:\n- " + strings.Join(resp.Warnings, "\n- ")
+	}
+
+	return successResponse(text)
 }
 
 // handleRetrieve handles memory_retrieve tool call
@@ -164,6 +169,11 @@ func formatRetrieveResponse(resp *domain.RetrieveResponse) string {
 		}
 	}
 
+	if chains := action.FormatEventChains(resp.Events, resp.EventRelations); len(chains) > 0 {
+		parts = append(parts, "\n## 事件关联")
+		parts = append(parts, chains...)
+	}
+
 	if len(resp.ShortTerm) > 0 {
 		parts = append(parts, "\n## 近期对话")
 		for _, msg := range resp.ShortTerm {
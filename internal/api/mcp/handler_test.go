@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/action"
+	pkggenkit "github.com/Zereker/memory/pkg/genkit"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+// recordingStore 记录写入文档的 vector.Store
+type recordingStore struct {
+	mu   sync.Mutex
+	docs map[string]map[string]any
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{docs: make(map[string]map[string]any)}
+}
+
+func (s *recordingStore) Store(ctx context.Context, id string, doc map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[id] = doc
+	return nil
+}
+
+func (s *recordingStore) Search(ctx context.Context, query vector.SearchQuery) ([]map[string]any, error) {
+	return nil, nil
+}
+
+func (s *recordingStore) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s *recordingStore) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+	return nil
+}
+
+func (s *recordingStore) Count(ctx context.Context, filters map[string]any) (int, error) {
+	return 0, nil
+}
+
+func (s *recordingStore) DeleteByQuery(ctx context.Context, filters map[string]any) (int, error) {
+	return 0, nil
+}
+
+func TestHandler_AddSessionDate(t *testing.T) {
+	ctx := context.Background()
+	mockPlugin := pkggenkit.InitForTest(ctx, pkggenkit.MockConfig{
+		Provider: "ark",
+		Models: []pkggenkit.ModelConfig{
+			{Name: "doubao-pro-32k", Type: pkggenkit.ModelTypeLLM, Model: "doubao-pro-32k"},
+			{Name: "doubao-embedding-text-240715", Type: pkggenkit.ModelTypeEmbedding, Model: "doubao-embedding", Dim: 4096},
+		},
+	}, "../../action/prompts")
+	mockPlugin.SetEmbedderVectorResponse("doubao-embedding-text-240715", []float32{0.1, 0.2, 0.3})
+	mockPlugin.SetModelJSONResponse("doubao-pro-32k", map[string]any{
+		"memories": []action.ExtractedMemory{
+			{Content: "用户 2023 年搬到了上海", Importance: 0.8, MemoryType: "fact"},
+		},
+		"events": []action.ExtractedEvent{
+			{TriggerWord: "搬到了", Argument1: "小明", Argument2: "上海"},
+		},
+	})
+
+	store := newRecordingStore()
+	handler := NewHandler(action.NewMemory().WithStores(store, nil))
+
+	args, err := json.Marshal(map[string]any{
+		"agent_id":     "agent_1",
+		"user_id":      "user_1",
+		"session_id":   "session_1",
+		"session_date": "2023-05-01",
+		"messages": []map[string]string{
+			{"role": "user", "name": "小明", "content": "我搬到上海了"},
+		},
+	})
+	require.NoError(t, err)
+
+	resp := handler.HandleToolCall(ctx, ToolCallRequest{Name: "memory_add", Arguments: args})
+	require.False(t, resp.IsError, resp.Content[0].Text)
+
+	want := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	var types []string
+	for _, doc := range store.docs {
+		types = append(types, doc["type"].(string))
+		assert.Equal(t, want, doc["occurred_at"], doc["type"])
+	}
+	assert.ElementsMatch(t, []string{"summary", "event"}, types)
+}
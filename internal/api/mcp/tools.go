@@ -44,6 +44,10 @@ var MemoryTools = []Tool{
 					Type:        "string",
 					Description: "会话标识",
 				},
+				"session_date": {
+					Type:        "string",
+					Description: "会话发生时间（RFC3339 或 YYYY-MM-DD），回填历史对话时使用；缺省为消息时间或当前时间",
+				},
 				"messages": {
 					Type:        "array",
 					Description: "对话消息列表",
@@ -53,6 +57,18 @@ var MemoryTools = []Tool{
 							"role":    {Type: "string", Description: "角色: user/assistant/system"},
 							"content": {Type: "string", Description: "消息内容"},
 							"name":    {Type: "string", Description: "发送者名称"},
+							"attachments": {
+								Type:        "array",
+								Description: "附件列表（可选）",
+								Items: &Property{
+									Type: "object",
+									Properties: map[string]Property{
+										"type":    {Type: "string", Description: "附件类型: image/file"},
+										"uri":     {Type: "string", Description: "附件地址"},
+										"caption": {Type: "string", Description: "附件描述"},
+									},
+								},
+							},
 						},
 					},
 				},
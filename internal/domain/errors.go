@@ -0,0 +1,25 @@
+package domain
+
+import "errors"
+
+// ============================================================================
+// Errors - 处理链错误分类
+// ============================================================================
+
+// 错误分类，action 通过 %w 包装具体错误，API 层据此映射状态码
+var (
+	// ErrInvalidInput 请求参数不合法
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrNotFound 目标记忆不存在
+	ErrNotFound = errors.New("not found")
+
+	// ErrLLMUnavailable LLM / Embedding 调用失败
+	ErrLLMUnavailable = errors.New("llm unavailable")
+
+	// ErrStoreUnavailable 存储（OpenSearch / PostgreSQL）不可用
+	ErrStoreUnavailable = errors.New("store unavailable")
+
+	// ErrNotImplemented 操作尚未实现
+	ErrNotImplemented = errors.New("not implemented")
+)
@@ -1,6 +1,10 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"time"
+)
 
 // ============================================================================
 // Action Interfaces - 处理链
@@ -43,10 +47,14 @@ type baseContext struct {
 	// Token 使用量统计
 	TokenUsages map[string]TokenUsage
 
+	// 非致命问题（部分写入失败等），随响应返回
+	Warnings []string
+
 	// 链式控制
-	index   int
-	aborted bool
-	err     error
+	index       int
+	aborted     bool
+	abortReason string
+	err         error
 }
 
 // Set 存储元数据
@@ -65,6 +73,17 @@ func (c *baseContext) Abort() {
 	c.aborted = true
 }
 
+// AbortWithReason 终止链式执行并记录原因（非错误的提前结束）
+func (c *baseContext) AbortWithReason(reason string) {
+	c.aborted = true
+	c.abortReason = reason
+}
+
+// AbortReason 返回终止原因，未通过 AbortWithReason 终止时为空
+func (c *baseContext) AbortReason() string {
+	return c.abortReason
+}
+
 // IsAborted 返回链是否被终止
 func (c *baseContext) IsAborted() bool {
 	return c.aborted
@@ -81,6 +100,11 @@ func (c *baseContext) Error() error {
 	return c.err
 }
 
+// AddWarning 记录非致命问题，链继续执行
+func (c *baseContext) AddWarning(format string, args ...any) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
+}
+
 // AddTokenUsage 记录 token 使用量
 func (c *baseContext) AddTokenUsage(actionName string, inputTokens, outputTokens int) {
 	if c.TokenUsages == nil {
@@ -119,7 +143,8 @@ type AddContext struct {
 	baseContext
 
 	// 输入
-	Messages Messages
+	Messages   Messages
+	OccurredAt time.Time // 对话发生时间，零值表示使用写入时间
 
 	// 输出 - 三层认知模型
 	ShortTermWindow *ShortTermMemory // Layer 1: 短期记忆窗口
@@ -177,6 +202,14 @@ func (c *AddContext) AddEventRelations(relations ...EventRelation) {
 	c.EventRelations = append(c.EventRelations, relations...)
 }
 
+// OccurredAtOr 返回对话发生时间，未设置时返回 fallback（通常为写入时间）
+func (c *AddContext) OccurredAtOr(fallback time.Time) time.Time {
+	if c.OccurredAt.IsZero() {
+		return fallback
+	}
+	return c.OccurredAt
+}
+
 // LanguageName 返回语言名称
 func (c *AddContext) LanguageName() string {
 	switch c.Language {
@@ -198,10 +231,11 @@ type RecallContext struct {
 	baseContext
 
 	// 查询参数
-	Query     string
-	Embedding []float32
-	Limit     int
-	Options   RetrieveOptions
+	Query          string
+	Embedding      []float32 // 摘要记忆查询向量
+	EventEmbedding []float32 // 事件查询向量，与 Embedding 可能来自不同 embedder
+	Limit          int
+	Options        RetrieveOptions
 
 	// 检索结果 - 三层认知结构
 	Facts      []SummaryMemory // fact 类型摘要
@@ -209,6 +243,18 @@ type RecallContext struct {
 	Events     []EventTriplet  // 事件三元组
 	ShortTerm  Messages        // 短期记忆窗口
 
+	// 召回事件间的因果/时序关系
+	EventRelations []EventRelation
+
+	// 调试信息 (Options.Explain 时填充)
+	Debug []RetrievalDebug
+
+	// 结果统计 (CognitiveRetrievalAction 填充)
+	Meta *RetrieveMeta
+
+	// 降级模式：关系存储不可用时事件仅来自向量检索，查询向量生成失败时只返回短期记忆
+	Degraded bool
+
 	// 链式处理器
 	actions []RecallAction
 }
@@ -217,7 +263,7 @@ type RecallContext struct {
 func NewRecallContext(ctx context.Context, req *RetrieveRequest) *RecallContext {
 	limit := req.Limit
 	if limit <= 0 {
-		limit = 10
+		limit = DefaultRetrieveLimit
 	}
 
 	return &RecallContext{
@@ -247,6 +293,14 @@ func (c *RecallContext) Next() {
 	}
 }
 
+// AddDebug 记录检索调试信息，未开启 Explain 时忽略
+func (c *RecallContext) AddDebug(items ...RetrievalDebug) {
+	if !c.Options.Explain {
+		return
+	}
+	c.Debug = append(c.Debug, items...)
+}
+
 // TotalResults 返回检索结果总数
 func (c *RecallContext) TotalResults() int {
 	return len(c.Facts) + len(c.WorkingMem) + len(c.Events) + len(c.ShortTerm)
@@ -82,6 +82,16 @@ func TestBaseContext(t *testing.T) {
 		assert.True(t, ctx.IsAborted())
 	})
 
+	t.Run("abort with reason", func(t *testing.T) {
+		ctx := NewAddContext(context.Background(), "agent_1", "user_1", "session_1")
+
+		assert.Empty(t, ctx.AbortReason())
+		ctx.AbortWithReason("duplicate conversation")
+		assert.True(t, ctx.IsAborted())
+		assert.Equal(t, "duplicate conversation", ctx.AbortReason())
+		assert.NoError(t, ctx.Error())
+	})
+
 	t.Run("add summaries", func(t *testing.T) {
 		ctx := NewAddContext(context.Background(), "agent_1", "user_1", "session_1")
 
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -9,8 +10,9 @@ import (
 // ============================================================================
 
 const (
-	DocTypeSummary = "summary" // 摘要记忆（Layer 2）
-	DocTypeEvent   = "event"   // 事件三元组（Layer 3）
+	DocTypeSummary  = "summary"  // 摘要记忆（Layer 2）
+	DocTypeEvent    = "event"    // 事件三元组（Layer 3）
+	DocTypeConflict = "conflict" // 一致性检查的冲突审计记录
 )
 
 // ============================================================================
@@ -22,6 +24,21 @@ const (
 	MemoryTypeWorking = "working" // 工作记忆（短期会话相关）
 )
 
+// ============================================================================
+// 情感倾向常量
+// ============================================================================
+
+const (
+	SentimentPositive = "positive"
+	SentimentNegative = "negative"
+	SentimentNeutral  = "neutral"
+)
+
+// IsSentiment 判断是否为合法的情感倾向
+func IsSentiment(s string) bool {
+	return s == SentimentPositive || s == SentimentNegative || s == SentimentNeutral
+}
+
 // ============================================================================
 // 角色常量
 // ============================================================================
@@ -67,15 +84,20 @@ type ShortTermMemory struct {
 
 // SummaryMemory 摘要记忆（带重要性打分 + fact/working 分类）
 type SummaryMemory struct {
-	ID      string `json:"id"`
-	AgentID string `json:"agent_id"`
-	UserID  string `json:"user_id"`
+	ID        string `json:"id"`
+	AgentID   string `json:"agent_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id,omitempty"` // 提取自的会话，上层摘要可能跨会话因而为空
 
 	// 内容
-	Content    string   `json:"content"`     // 摘要内容
-	MemoryType string   `json:"memory_type"` // fact / working
-	Importance float64  `json:"importance"`  // 重要性 0-1
-	Keywords   []string `json:"keywords"`    // 关键词列表
+	Content    string   `json:"content"`             // 摘要内容
+	MemoryType string   `json:"memory_type"`         // fact / working
+	Importance float64  `json:"importance"`          // 重要性 0-1
+	Keywords   []string `json:"keywords"`            // 关键词列表
+	Sentiment  string   `json:"sentiment,omitempty"` // 情感倾向 positive / negative / neutral
+
+	// 来源附件（附件描述记忆）
+	Attachments []Attachment `json:"attachments,omitempty"`
 
 	// 向量
 	Embedding []float32 `json:"embedding,omitempty"`
@@ -87,10 +109,19 @@ type SummaryMemory struct {
 	// 保护标记
 	IsProtected bool `json:"is_protected"` // importance >= 0.9 自动标记
 
+	// 分层压缩：上层摘要概括同一主题的多条摘要记忆
+	Level    int      `json:"level,omitempty"`     // 0 为提取的摘要记忆，1 为上层摘要
+	ChildIDs []string `json:"child_ids,omitempty"` // 上层摘要覆盖的摘要记忆
+	ParentID string   `json:"parent_id,omitempty"` // 已被压缩进的上层摘要
+
+	// 检索时按 RetrieveOptions.ExpandSources 展开的源摘要
+	Sources []SummaryMemory `json:"sources,omitempty"`
+
 	// 时间
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	ExpiredAt *time.Time `json:"expired_at,omitempty"` // 软删除/冲突过期
+	OccurredAt time.Time  `json:"occurred_at"` // 对话发生时间（回填历史对话时早于 CreatedAt）
+	CreatedAt  time.Time  `json:"created_at"`  // 写入时间
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ExpiredAt  *time.Time `json:"expired_at,omitempty"` // 软删除/冲突过期
 
 	// 检索分数 (查询时填充)
 	Score float64 `json:"score,omitempty"`
@@ -102,9 +133,10 @@ type SummaryMemory struct {
 
 // EventTriplet 事件三元组
 type EventTriplet struct {
-	ID      string `json:"id"`
-	AgentID string `json:"agent_id"`
-	UserID  string `json:"user_id"`
+	ID        string `json:"id"`
+	AgentID   string `json:"agent_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id,omitempty"` // 提取自的会话
 
 	// 三元组
 	TriggerWord string `json:"trigger_word"` // 触发词（谓词）
@@ -119,7 +151,10 @@ type EventTriplet struct {
 	LastAccessedAt time.Time `json:"last_accessed_at"`
 
 	// 时间
-	CreatedAt time.Time `json:"created_at"`
+	OccurredAt time.Time  `json:"occurred_at"`          // 对话发生时间
+	ValidAt    *time.Time `json:"valid_at,omitempty"`   // 对话中提到的事件开始时间（如"2020 年入职"）
+	InvalidAt  *time.Time `json:"invalid_at,omitempty"` // 对话中提到的事件结束时间
+	CreatedAt  time.Time  `json:"created_at"`           // 写入时间
 
 	// 检索分数 (查询时填充)
 	Score float64 `json:"score,omitempty"`
@@ -142,16 +177,52 @@ type EventRelation struct {
 // Message 对话消息
 // ============================================================================
 
+// 附件类型
+const (
+	AttachmentTypeImage = "image"
+	AttachmentTypeFile  = "file"
+)
+
+// Attachment 消息附件（图片等），Caption 作为可检索的文本
+type Attachment struct {
+	Type    string `json:"type"`              // image / file
+	URI     string `json:"uri"`               // 附件地址
+	Caption string `json:"caption,omitempty"` // 附件描述
+}
+
 // Message 表示一条对话消息
 type Message struct {
-	Role    string `json:"role"`           // user / assistant / system
-	Content string `json:"content"`        // 消息内容
-	Name    string `json:"name,omitempty"` // 发言者名称
+	Role        string       `json:"role"`                  // user / assistant / system
+	Content     string       `json:"content"`               // 消息内容
+	Name        string       `json:"name,omitempty"`        // 发言者名称
+	Attachments []Attachment `json:"attachments,omitempty"` // 附件
+	Timestamp   *time.Time   `json:"timestamp,omitempty"`   // 消息发送时间（回填历史对话时设置）
+}
+
+// Validate 校验消息角色
+func (m Message) Validate() error {
+	switch m.Role {
+	case RoleUser, RoleAssistant, RoleSystem:
+		return nil
+	default:
+		return fmt.Errorf("%w: invalid message role %q, must be %s, %s or %s", ErrInvalidInput, m.Role, RoleUser, RoleAssistant, RoleSystem)
+	}
 }
 
 // Messages 消息列表
 type Messages []Message
 
+// WithoutSystem 返回去除 system 消息后的列表
+func (m Messages) WithoutSystem() Messages {
+	result := make(Messages, 0, len(m))
+	for _, msg := range m {
+		if msg.Role != RoleSystem {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
 // UserName 获取用户名称
 func (m Messages) UserName() string {
 	for _, msg := range m {
@@ -183,7 +254,13 @@ func (m Messages) Format() string {
 			name = msg.Role
 		}
 
-		result += name + ": " + msg.Content + "\n"
+		result += name + ": " + msg.Content
+		for _, att := range msg.Attachments {
+			if att.Caption != "" {
+				result += " [" + att.Type + ": " + att.Caption + "]"
+			}
+		}
+		result += "\n"
 	}
 
 	return result
@@ -199,28 +276,97 @@ type AddRequest struct {
 	UserID    string    `json:"user_id"`
 	SessionID string    `json:"session_id"`
 	Messages  []Message `json:"messages"`
+
+	// SessionDate 会话日期（RFC3339 或 2006-01-02），消息未带时间戳时作为对话发生时间
+	SessionDate string `json:"session_date,omitempty"`
+}
+
+// Validate 校验添加请求
+func (r *AddRequest) Validate() error {
+	if len(r.Messages) == 0 {
+		return fmt.Errorf("%w: messages are required", ErrInvalidInput)
+	}
+	for i, msg := range r.Messages {
+		if err := msg.Validate(); err != nil {
+			return fmt.Errorf("messages[%d]: %w", i, err)
+		}
+	}
+	if _, err := parseSessionDate(r.SessionDate); err != nil {
+		return fmt.Errorf("%w: invalid session_date %q, must be RFC3339 or YYYY-MM-DD", ErrInvalidInput, r.SessionDate)
+	}
+	return nil
+}
+
+// OccurredAt 返回对话发生时间：最晚的消息时间戳，其次是 SessionDate，都没有时返回零值
+func (r *AddRequest) OccurredAt() time.Time {
+	var latest time.Time
+	for _, msg := range r.Messages {
+		if msg.Timestamp != nil && msg.Timestamp.After(latest) {
+			latest = *msg.Timestamp
+		}
+	}
+	if !latest.IsZero() {
+		return latest
+	}
+
+	sessionDate, _ := parseSessionDate(r.SessionDate)
+	return sessionDate
+}
+
+// parseSessionDate 解析会话日期，空字符串返回零值
+func parseSessionDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
 }
 
 // AddResponse 添加记忆响应
 type AddResponse struct {
-	Success        bool            `json:"success"`
+	Success        bool            `json:"success"` // 链完整执行为 true，提前终止时为 false 并给出 abort_reason
 	Summaries      []SummaryMemory `json:"summaries,omitempty"`
 	Events         []EventTriplet  `json:"events,omitempty"`
 	EventRelations []EventRelation `json:"event_relations,omitempty"`
+	Warnings       []string        `json:"warnings,omitempty"`     // 非致命问题（部分存储失败等）
+	AbortReason    string          `json:"abort_reason,omitempty"` // 链提前终止的原因
+}
+
+// BackfillResponse 批量回填历史对话的统计
+type BackfillResponse struct {
+	Success   bool     `json:"success"`
+	Processed int      `json:"processed"` // 本次添加的对话数
+	Skipped   int      `json:"skipped"`   // 按进度跳过的已完成对话数
+	Summaries int      `json:"summaries"`
+	Events    int      `json:"events"`
+	Warnings  []string `json:"warnings,omitempty"`
 }
 
+// DefaultRetrieveLimit 未指定 limit 且存储未提供默认条数时每类记忆的检索条数
+const DefaultRetrieveLimit = 10
+
 // RetrieveRequest 检索记忆请求
 type RetrieveRequest struct {
 	AgentID   string `json:"agent_id"`
 	UserID    string `json:"user_id"`
 	SessionID string `json:"session_id,omitempty"`
 	Query     string `json:"query"`
-	Limit     int    `json:"limit,omitempty"`
+	Limit     int    `json:"limit,omitempty"` // 每类记忆的检索条数，默认 DefaultRetrieveLimit
 
 	// 检索选项
 	Options RetrieveOptions `json:"options,omitempty"`
 }
 
+// Validate 校验检索请求
+func (r *RetrieveRequest) Validate() error {
+	if r.AgentID == "" || r.UserID == "" || r.Query == "" {
+		return fmt.Errorf("%w: agent_id, user_id, and query are required", ErrInvalidInput)
+	}
+	return r.Options.Validate()
+}
+
 // RetrieveOptions 检索选项
 // 3-Bucket Token 预算分配策略
 type RetrieveOptions struct {
@@ -230,11 +376,127 @@ type RetrieveOptions struct {
 	MaxFacts   int `json:"max_facts,omitempty"`   // Fact 桶 token 预算
 	MaxGraph   int `json:"max_graph,omitempty"`   // Graph 桶 token 预算
 	MaxWorking int `json:"max_working,omitempty"` // Working 桶 token 预算
+
+	// 各类型 _score 阈值（0 不过滤），不同类型的分数分布不同，需分别设置
+	FactThreshold    float64 `json:"fact_threshold,omitempty"`    // Fact 记忆分数阈值
+	WorkingThreshold float64 `json:"working_threshold,omitempty"` // Working 记忆分数阈值
+	EventThreshold   float64 `json:"event_threshold,omitempty"`   // 事件三元组分数阈值
+
+	// 事件链扩展（0 使用默认值）
+	MaxHops              int `json:"max_hops,omitempty"`                // 沿关系扩展的最大跳数，默认 1
+	MaxNeighborsPerEvent int `json:"max_neighbors_per_event,omitempty"` // 每个事件最多扩展的邻居数，默认 3
+	MaxChainEvents       int `json:"max_chain_events,omitempty"`        // 扩展新增事件总数上限，默认 10
+
+	// 近重复过滤：Fact 与 Working 结果中余弦相似度不低于阈值（或内容相同）的摘要记忆只保留分数最高的一条
+	DedupThreshold float64 `json:"dedup_threshold,omitempty"` // 0 使用默认值 0.95，-1 禁用
+
+	// 时间衰减：混合分数 = (1-RecencyWeight)·相似度 + RecencyWeight·0.5^(写入小时数/半衰期)，在预算截断前重新排序
+	RecencyWeight        float64 `json:"recency_weight,omitempty"`          // [0, 1]，0 不考虑时间
+	RecencyHalfLifeHours float64 `json:"recency_half_life_hours,omitempty"` // 半衰期（小时），默认 168
+
+	// 情感过滤：只召回该情感倾向的 Fact 与 Working 记忆（空不过滤）
+	Sentiment string `json:"sentiment,omitempty"`
+
+	// 源摘要展开：每条上层摘要最多附带的源摘要数（0 不展开），占用所在桶的预算
+	ExpandSources int `json:"expand_sources,omitempty"`
+
+	// 按会话分组：额外在 Sessions 中按 session_id 返回 Fact / Working / 事件结果
+	GroupBySession bool `json:"group_by_session,omitempty"`
+
+	// 角色过滤：短期记忆只召回这些角色的消息（如 ["user"] 排除助手自己的发言，空不过滤）
+	Roles []string `json:"roles,omitempty"`
+
+	// 调试选项
+	Explain bool `json:"explain,omitempty"` // 附带每条候选的选中/截断原因
+
+	// 输出选项
+	SkipFormatting bool `json:"skip_formatting,omitempty"` // 只返回结构化结果，不生成 MemoryContext
+}
+
+// Validate 校验检索选项，请求与 agent 级默认检索选项共用
+func (o RetrieveOptions) Validate() error {
+	if o.MaxTokens < 0 || o.MaxHops < 0 || o.MaxNeighborsPerEvent < 0 || o.MaxChainEvents < 0 || o.ExpandSources < 0 {
+		return fmt.Errorf("%w: max_tokens, max_hops, max_neighbors_per_event, max_chain_events and expand_sources must not be negative", ErrInvalidInput)
+	}
+	if o.MaxFacts < -1 || o.MaxGraph < -1 || o.MaxWorking < -1 {
+		return fmt.Errorf("%w: bucket budgets must be -1, 0 or positive", ErrInvalidInput)
+	}
+	if o.FactThreshold < 0 || o.WorkingThreshold < 0 || o.EventThreshold < 0 {
+		return fmt.Errorf("%w: score thresholds must not be negative", ErrInvalidInput)
+	}
+	if o.DedupThreshold > 1 || (o.DedupThreshold < 0 && o.DedupThreshold != -1) {
+		return fmt.Errorf("%w: dedup_threshold must be -1, 0 or in (0, 1]", ErrInvalidInput)
+	}
+	if o.RecencyWeight < 0 || o.RecencyWeight > 1 {
+		return fmt.Errorf("%w: recency_weight must be between 0 and 1", ErrInvalidInput)
+	}
+	if o.RecencyHalfLifeHours < 0 {
+		return fmt.Errorf("%w: recency_half_life_hours must not be negative", ErrInvalidInput)
+	}
+	if o.Sentiment != "" && !IsSentiment(o.Sentiment) {
+		return fmt.Errorf("%w: unknown sentiment %q", ErrInvalidInput, o.Sentiment)
+	}
+	for _, role := range o.Roles {
+		if role != RoleUser && role != RoleAssistant && role != RoleSystem {
+			return fmt.Errorf("%w: unknown role %q", ErrInvalidInput, role)
+		}
+	}
+	return nil
+}
+
+// WithDefaults 用 defaults 填充未设置（零值）的字段，已设置的字段保持不变
+func (o RetrieveOptions) WithDefaults(defaults RetrieveOptions) RetrieveOptions {
+	if o.MaxTokens == 0 {
+		o.MaxTokens = defaults.MaxTokens
+	}
+	if o.MaxFacts == 0 {
+		o.MaxFacts = defaults.MaxFacts
+	}
+	if o.MaxGraph == 0 {
+		o.MaxGraph = defaults.MaxGraph
+	}
+	if o.MaxWorking == 0 {
+		o.MaxWorking = defaults.MaxWorking
+	}
+	if o.FactThreshold == 0 {
+		o.FactThreshold = defaults.FactThreshold
+	}
+	if o.WorkingThreshold == 0 {
+		o.WorkingThreshold = defaults.WorkingThreshold
+	}
+	if o.EventThreshold == 0 {
+		o.EventThreshold = defaults.EventThreshold
+	}
+	if o.MaxHops == 0 {
+		o.MaxHops = defaults.MaxHops
+	}
+	if o.MaxNeighborsPerEvent == 0 {
+		o.MaxNeighborsPerEvent = defaults.MaxNeighborsPerEvent
+	}
+	if o.MaxChainEvents == 0 {
+		o.MaxChainEvents = defaults.MaxChainEvents
+	}
+	if o.DedupThreshold == 0 {
+		o.DedupThreshold = defaults.DedupThreshold
+	}
+	if o.ExpandSources == 0 {
+		o.ExpandSources = defaults.ExpandSources
+	}
+	if o.RecencyWeight == 0 {
+		o.RecencyWeight = defaults.RecencyWeight
+	}
+	if o.RecencyHalfLifeHours == 0 {
+		o.RecencyHalfLifeHours = defaults.RecencyHalfLifeHours
+	}
+	if len(o.Roles) == 0 {
+		o.Roles = defaults.Roles
+	}
+	return o
 }
 
 // RetrieveResponse 检索记忆响应
 type RetrieveResponse struct {
-	Success bool `json:"success"`
+	Success bool `json:"success"` // 链完整执行为 true，提前终止时为 false 并给出 abort_reason
 
 	// 三层结果
 	Facts      []SummaryMemory `json:"facts,omitempty"`       // fact 类型摘要
@@ -243,8 +505,61 @@ type RetrieveResponse struct {
 	ShortTerm  Messages        `json:"short_term,omitempty"`  // 短期记忆窗口
 	Total      int             `json:"total"`
 
+	// 召回事件间的因果/时序链
+	EventRelations []EventRelation `json:"event_relations,omitempty"`
+
 	// 格式化后的记忆上下文 (用于 LLM prompt)
 	MemoryContext string `json:"memory_context,omitempty"`
+
+	// 检索调试信息 (Options.Explain 时填充)
+	Debug []RetrievalDebug `json:"debug,omitempty"`
+
+	// 各类结果的候选数、返回数与预算截断情况
+	Meta *RetrieveMeta `json:"meta,omitempty"`
+
+	// 按会话分组的结果 (Options.GroupBySession 时填充)，按会话首次出现的顺序排列
+	Sessions []SessionGroup `json:"sessions,omitempty"`
+
+	// 链提前终止的原因
+	AbortReason string `json:"abort_reason,omitempty"`
+
+	// 降级模式：关系存储不可用时事件未沿因果/时序链扩展，查询向量生成失败时只有短期记忆
+	Degraded bool     `json:"degraded,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// SessionGroup 同一会话中提取的检索结果，SessionID 为空的分组包含未记录会话的记忆（如上层摘要）
+type SessionGroup struct {
+	SessionID  string          `json:"session_id"`
+	Facts      []SummaryMemory `json:"facts,omitempty"`
+	WorkingMem []SummaryMemory `json:"working_mem,omitempty"`
+	Events     []EventTriplet  `json:"events,omitempty"`
+}
+
+// RetrieveMeta 检索结果统计，帮助调用方判断结果少是因为预算不足还是本身稀少
+type RetrieveMeta struct {
+	Facts   RetrieveTypeMeta `json:"facts"`
+	Working RetrieveTypeMeta `json:"working"`
+	Events  RetrieveTypeMeta `json:"events"`
+}
+
+// RetrieveTypeMeta 单类结果的统计
+type RetrieveTypeMeta struct {
+	Found     int  `json:"found"`     // 检索命中的不同候选数
+	Returned  int  `json:"returned"`  // 最终返回数（近重复过滤后）
+	Truncated bool `json:"truncated"` // 是否有候选因 token 预算未返回
+}
+
+// RetrievalDebug 单条候选的检索调试信息
+type RetrievalDebug struct {
+	ID              string  `json:"id"`
+	Type            string  `json:"type"`             // fact / working / event
+	Stage           string  `json:"stage"`            // 产生该候选的检索阶段
+	RawScore        float64 `json:"raw_score"`        // OpenSearch 原始 _score
+	NormalizedScore float64 `json:"normalized_score"` // 同阶段内按最高分归一化
+	Tokens          int     `json:"tokens"`           // 估算 token 数
+	Selected        bool    `json:"selected"`         // 是否进入结果
+	Truncated       bool    `json:"truncated"`        // 是否因 token 预算被截断
 }
 
 // ForgetRequest 遗忘记忆请求
@@ -253,6 +568,14 @@ type ForgetRequest struct {
 	UserID  string `json:"user_id"`
 }
 
+// Validate 校验遗忘请求
+func (r *ForgetRequest) Validate() error {
+	if r.AgentID == "" || r.UserID == "" {
+		return fmt.Errorf("%w: agent_id and user_id are required", ErrInvalidInput)
+	}
+	return nil
+}
+
 // ForgetResponse 遗忘记忆响应
 type ForgetResponse struct {
 	Success        bool `json:"success"`
@@ -260,3 +583,161 @@ type ForgetResponse struct {
 	EventsForgot   int  `json:"events_forgot"`
 	FactsExpired   int  `json:"facts_expired"`
 }
+
+// ReindexRequest 重建向量请求
+type ReindexRequest struct {
+	AgentID string `json:"agent_id"`
+	UserID  string `json:"user_id"`
+}
+
+// Validate 校验重建向量请求
+func (r *ReindexRequest) Validate() error {
+	if r.AgentID == "" || r.UserID == "" {
+		return fmt.Errorf("%w: agent_id and user_id are required", ErrInvalidInput)
+	}
+	return nil
+}
+
+// ReindexResponse 重建向量响应
+type ReindexResponse struct {
+	Success   bool `json:"success"`
+	Summaries int  `json:"summaries"` // 重建的摘要记忆数
+	Events    int  `json:"events"`    // 重建的事件数
+	Failed    int  `json:"failed"`    // embedding 或更新失败的文档数
+}
+
+// MigrateIndexRequest 向量维度迁移请求
+type MigrateIndexRequest struct {
+	EmbeddingDim int `json:"embedding_dim"` // 新 embedder 的向量维度
+}
+
+// Validate 校验维度迁移请求
+func (r *MigrateIndexRequest) Validate() error {
+	if r.EmbeddingDim <= 0 {
+		return fmt.Errorf("%w: embedding_dim must be positive", ErrInvalidInput)
+	}
+	return nil
+}
+
+// MigrateIndexResponse 向量维度迁移响应
+type MigrateIndexResponse struct {
+	Success  bool   `json:"success"`
+	OldIndex string `json:"old_index"` // 迁移前别名指向的索引，保留以便回滚
+	NewIndex string `json:"new_index"` // 别名当前指向的索引
+	Migrated int    `json:"migrated"`  // 复制到新索引的文档数
+}
+
+// 导出记录类型
+const (
+	ExportKindDocument = "document" // OpenSearch 文档（摘要记忆、事件），含向量
+	ExportKindRelation = "relation" // PostgreSQL 事件关系
+)
+
+// ExportRecord 用户数据导出（NDJSON）中的一行
+type ExportRecord struct {
+	Kind     string         `json:"kind"`
+	Document map[string]any `json:"document,omitempty"`
+	Relation *EventRelation `json:"relation,omitempty"`
+}
+
+// ExportResponse 导出统计
+type ExportResponse struct {
+	Documents int `json:"documents"`
+	Relations int `json:"relations"`
+}
+
+// ImportResponse 导入统计
+type ImportResponse struct {
+	Success   bool `json:"success"`
+	Documents int  `json:"documents"` // 写入的文档数
+	Relations int  `json:"relations"` // 写入的事件关系数
+}
+
+// DeleteUserResponse 用户数据删除统计
+type DeleteUserResponse struct {
+	Success          bool `json:"success"`
+	Documents        int  `json:"documents"`          // 删除的 OpenSearch 文档数（含已归档）
+	Relations        int  `json:"relations"`          // 删除的 PostgreSQL 事件关系数
+	ShortTermWindows int  `json:"short_term_windows"` // 清除的短期记忆窗口数
+}
+
+// ConflictRecord 一致性检查过期旧 fact 的审计记录，用于复核与回滚
+type ConflictRecord struct {
+	ID         string     `json:"id"`
+	AgentID    string     `json:"agent_id"`
+	UserID     string     `json:"user_id"`
+	OldID      string     `json:"old_id"` // 被过期的旧 fact
+	NewID      string     `json:"new_id"` // 取代它的新 fact
+	OldContent string     `json:"old_content"`
+	NewContent string     `json:"new_content"`
+	Score      float64    `json:"score"` // 新旧 fact 的相似度
+	CreatedAt  time.Time  `json:"created_at"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"` // 旧 fact 被恢复（判定被撤销）的时间
+}
+
+// RestoreFactRequest 恢复被一致性检查过期的 fact 请求
+type RestoreFactRequest struct {
+	AgentID string `json:"agent_id"`
+	UserID  string `json:"user_id"`
+	ID      string `json:"id"` // 被过期的 fact ID
+
+	// ExpireSuperseding 为 true 时按审计记录将取代它的新 fact 一并过期
+	ExpireSuperseding bool `json:"expire_superseding"`
+}
+
+// Validate 校验恢复请求
+func (r *RestoreFactRequest) Validate() error {
+	if r.AgentID == "" || r.UserID == "" {
+		return fmt.Errorf("%w: agent_id and user_id are required", ErrInvalidInput)
+	}
+	if r.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidInput)
+	}
+	return nil
+}
+
+// RestoreFactResponse 恢复 fact 响应
+type RestoreFactResponse struct {
+	Success  bool     `json:"success"`
+	Restored string   `json:"restored"`
+	Expired  []string `json:"expired"` // 被重新过期的新 fact
+}
+
+// ConflictsResponse 冲突审计记录，按时间倒序
+type ConflictsResponse struct {
+	Conflicts []ConflictRecord `json:"conflicts"`
+}
+
+// ActivityBucket 一个时间区间内写入的文档数
+type ActivityBucket struct {
+	Start time.Time `json:"start"` // 区间起点（UTC）
+	Count int       `json:"count"`
+}
+
+// ActivityResponse 用户活动时间线，按 created_at 分桶
+type ActivityResponse struct {
+	Interval string           `json:"interval"`
+	Buckets  []ActivityBucket `json:"buckets"`
+}
+
+// CompactRequest 摘要记忆分层压缩请求
+type CompactRequest struct {
+	AgentID string `json:"agent_id"`
+	UserID  string `json:"user_id"`
+}
+
+// Validate 校验分层压缩请求
+func (r *CompactRequest) Validate() error {
+	if r.AgentID == "" || r.UserID == "" {
+		return fmt.Errorf("%w: agent_id and user_id are required", ErrInvalidInput)
+	}
+	return nil
+}
+
+// CompactResponse 摘要记忆分层压缩响应
+type CompactResponse struct {
+	Success       bool     `json:"success"`
+	Summaries     int      `json:"summaries"`      // 参与压缩的摘要记忆数
+	MetaSummaries []string `json:"meta_summaries"` // 新生成的上层摘要 ID
+	Compacted     int      `json:"compacted"`      // 被上层摘要覆盖的摘要记忆数
+}
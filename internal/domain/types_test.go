@@ -228,6 +228,19 @@ func TestMessage(t *testing.T) {
 }
 
 func TestMessages(t *testing.T) {
+	t.Run("without system", func(t *testing.T) {
+		messages := Messages{
+			{Role: RoleSystem, Content: "你是一个助手"},
+			{Role: RoleUser, Content: "Hello"},
+			{Role: RoleAssistant, Content: "Hi!"},
+		}
+
+		filtered := messages.WithoutSystem()
+		assert.Len(t, filtered, 2)
+		assert.NotContains(t, filtered.Format(), "你是一个助手")
+		assert.Len(t, messages, 3)
+	})
+
 	t.Run("UserName with name", func(t *testing.T) {
 		msgs := Messages{
 			{Role: "user", Content: "Hello", Name: "阿信"},
@@ -311,6 +324,40 @@ func TestAddRequest(t *testing.T) {
 	assert.Equal(t, 2, len(req.Messages))
 	assert.Equal(t, "张三", req.Messages[0].Name)
 	assert.Equal(t, "贾维斯", req.Messages[1].Name)
+	assert.NoError(t, req.Validate())
+
+	req.Messages = append(req.Messages, Message{Role: "tool", Content: "{}"})
+	err := req.Validate()
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Contains(t, err.Error(), "messages[2]")
+
+	req.Messages = nil
+	assert.ErrorIs(t, req.Validate(), ErrInvalidInput)
+}
+
+func TestAddRequest_OccurredAt(t *testing.T) {
+	earlier := time.Date(2023, 5, 1, 9, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	req := AddRequest{Messages: []Message{
+		{Role: "user", Content: "我昨天搬家了", Timestamp: &earlier},
+		{Role: "assistant", Content: "辛苦了", Timestamp: &later},
+		{Role: "user", Content: "还好"},
+	}}
+	assert.Equal(t, later, req.OccurredAt())
+
+	req = AddRequest{Messages: []Message{{Role: "user", Content: "我昨天搬家了"}}, SessionDate: "2023-05-01"}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), req.OccurredAt())
+
+	req.SessionDate = "2023-05-01T09:00:00Z"
+	assert.Equal(t, earlier, req.OccurredAt())
+
+	req.SessionDate = ""
+	assert.True(t, req.OccurredAt().IsZero())
+
+	req.SessionDate = "yesterday"
+	assert.ErrorIs(t, req.Validate(), ErrInvalidInput)
 }
 
 func TestRetrieveRequest(t *testing.T) {
@@ -344,6 +391,23 @@ func TestRetrieveRequest(t *testing.T) {
 
 		assert.Equal(t, 0, req.Limit)
 		assert.Equal(t, 0, req.Options.MaxTokens)
+		assert.NoError(t, req.Validate())
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, opts := range []RetrieveOptions{
+			{MaxHops: -1},
+			{MaxFacts: -2},
+			{EventThreshold: -0.1},
+			{DedupThreshold: -0.5},
+			{RecencyWeight: 2},
+			{Roles: []string{"tool"}},
+		} {
+			req := RetrieveRequest{AgentID: "agent_1", UserID: "user_1", Query: "用户喜欢什么", Options: opts}
+			assert.ErrorIs(t, req.Validate(), ErrInvalidInput, "%+v", opts)
+		}
+
+		assert.NoError(t, RetrieveOptions{MaxFacts: -1, DedupThreshold: -1}.Validate())
 	})
 }
 
@@ -6,6 +6,9 @@ import (
 
 	"github.com/pelletier/go-toml/v2"
 
+	"github.com/Zereker/memory/internal/action"
+	"github.com/Zereker/memory/internal/api/http"
+	"github.com/Zereker/memory/internal/domain"
 	"github.com/Zereker/memory/pkg/genkit"
 	"github.com/Zereker/memory/pkg/log"
 	"github.com/Zereker/memory/pkg/relation"
@@ -19,12 +22,23 @@ type Config struct {
 	Models  genkit.Config         `toml:"genkit"`
 	Storage  vector.OpenSearchConfig  `toml:"storage"`
 	Postgres relation.PostgresConfig `toml:"postgres"`
+	Memory   action.Config           `toml:"memory"`
+	Agents   []AgentConfig           `toml:"agents"`
+
+	path string // file the config was loaded from, used for reload
 }
 
 // ServerConfig contains server configuration
 type ServerConfig struct {
-	Mode string `toml:"mode"` // http, mcp, or both
-	Port int    `toml:"port"`
+	Mode    string        `toml:"mode"` // http, mcp, or both
+	Port    int           `toml:"port"`
+	APIKeys []http.APIKey `toml:"api_keys"` // HTTP API keys, empty disables auth
+
+	// HTTP request limits, 0 uses the default
+	MaxBodyBytes     int64 `toml:"max_body_bytes"`
+	MaxMessages      int   `toml:"max_messages"`
+	MaxContentLength int   `toml:"max_content_length"`
+	MaxImportBytes   int64 `toml:"max_import_bytes"`
 }
 
 // AgentConfig defines agent configuration
@@ -33,6 +47,57 @@ type AgentConfig struct {
 	Description string   `toml:"description" json:"description"`
 	Enabled     bool     `toml:"enabled" json:"enabled"`
 	Actions     []string `toml:"actions" json:"actions"`
+
+	// Retrieve holds the agent's default retrieve options; fields set in a request take precedence
+	Retrieve AgentRetrieveConfig `toml:"retrieve" json:"retrieve"`
+}
+
+// AgentRetrieveConfig holds per-agent retrieve defaults, 0 keeps the built-in default
+type AgentRetrieveConfig struct {
+	MaxTokens  int `toml:"max_tokens" json:"max_tokens"`
+	MaxFacts   int `toml:"max_facts" json:"max_facts"`     // -1 disables the bucket
+	MaxGraph   int `toml:"max_graph" json:"max_graph"`     // -1 disables the bucket
+	MaxWorking int `toml:"max_working" json:"max_working"` // -1 disables the bucket
+
+	FactThreshold    float64 `toml:"fact_threshold" json:"fact_threshold"`
+	WorkingThreshold float64 `toml:"working_threshold" json:"working_threshold"`
+	EventThreshold   float64 `toml:"event_threshold" json:"event_threshold"`
+
+	MaxHops              int `toml:"max_hops" json:"max_hops"`
+	MaxNeighborsPerEvent int `toml:"max_neighbors_per_event" json:"max_neighbors_per_event"`
+	MaxChainEvents       int `toml:"max_chain_events" json:"max_chain_events"`
+
+	DedupThreshold float64 `toml:"dedup_threshold" json:"dedup_threshold"` // -1 disables near-duplicate filtering
+
+	RecencyWeight        float64 `toml:"recency_weight" json:"recency_weight"`                   // [0, 1], 0 ranks by similarity only
+	RecencyHalfLifeHours float64 `toml:"recency_half_life_hours" json:"recency_half_life_hours"` // 0 uses the default of 168
+
+	Roles []string `toml:"roles" json:"roles"` // short-term message roles to recall, empty keeps all
+}
+
+// Validate checks agent retrieve defaults with the same rules as request options
+func (r *AgentRetrieveConfig) Validate() error {
+	return r.Options().Validate()
+}
+
+// Options converts the defaults to retrieve options
+func (r *AgentRetrieveConfig) Options() domain.RetrieveOptions {
+	return domain.RetrieveOptions{
+		MaxTokens:            r.MaxTokens,
+		MaxFacts:             r.MaxFacts,
+		MaxGraph:             r.MaxGraph,
+		MaxWorking:           r.MaxWorking,
+		FactThreshold:        r.FactThreshold,
+		WorkingThreshold:     r.WorkingThreshold,
+		EventThreshold:       r.EventThreshold,
+		MaxHops:              r.MaxHops,
+		MaxNeighborsPerEvent: r.MaxNeighborsPerEvent,
+		MaxChainEvents:       r.MaxChainEvents,
+		DedupThreshold:       r.DedupThreshold,
+		RecencyWeight:        r.RecencyWeight,
+		RecencyHalfLifeHours: r.RecencyHalfLifeHours,
+		Roles:                r.Roles,
+	}
 }
 
 // Validate checks server configuration
@@ -49,6 +114,19 @@ func (s *ServerConfig) Validate() error {
 	if s.Port <= 0 || s.Port > 65535 {
 		return fmt.Errorf("port is required and must be between 1 and 65535")
 	}
+	if s.MaxBodyBytes < 0 || s.MaxMessages < 0 || s.MaxContentLength < 0 || s.MaxImportBytes < 0 {
+		return fmt.Errorf("request limits must not be negative")
+	}
+	seen := make(map[string]bool, len(s.APIKeys))
+	for i := range s.APIKeys {
+		if err := s.APIKeys[i].Validate(); err != nil {
+			return fmt.Errorf("api_keys[%d]: %w", i, err)
+		}
+		if seen[s.APIKeys[i].Key] {
+			return fmt.Errorf("api_keys[%d]: duplicate key", i)
+		}
+		seen[s.APIKeys[i].Key] = true
+	}
 	return nil
 }
 
@@ -60,9 +138,32 @@ func (c *AgentConfig) Validate() error {
 	if len(c.Actions) == 0 {
 		return fmt.Errorf("at least one action is required")
 	}
+	for _, name := range c.Actions {
+		if !action.IsKnownAction(name) {
+			return fmt.Errorf("unknown action: %s", name)
+		}
+	}
+	if err := c.Retrieve.Validate(); err != nil {
+		return fmt.Errorf("retrieve: %w", err)
+	}
 	return nil
 }
 
+// AgentProfiles returns the action profiles of enabled agents, keyed by agent name
+func (c *Config) AgentProfiles() map[string]action.AgentProfile {
+	profiles := make(map[string]action.AgentProfile, len(c.Agents))
+	for _, agent := range c.Agents {
+		if !agent.Enabled {
+			continue
+		}
+		profiles[agent.Name] = action.AgentProfile{
+			Actions:          agent.Actions,
+			RetrieveDefaults: agent.Retrieve.Options(),
+		}
+	}
+	return profiles
+}
+
 // Validate checks all configuration fields
 func (c *Config) Validate() error {
 	if err := c.Server.Validate(); err != nil {
@@ -85,6 +186,77 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("postgres: %w", err)
 	}
 
+	if err := c.Memory.Validate(); err != nil {
+		return fmt.Errorf("memory: %w", err)
+	}
+
+	if err := c.validateEmbedders(); err != nil {
+		return fmt.Errorf("memory: %w", err)
+	}
+
+	seen := make(map[string]bool, len(c.Agents))
+	for i := range c.Agents {
+		if err := c.Agents[i].Validate(); err != nil {
+			return fmt.Errorf("agents[%d]: %w", i, err)
+		}
+		if seen[c.Agents[i].Name] {
+			return fmt.Errorf("agents[%d]: duplicate name %s", i, c.Agents[i].Name)
+		}
+		seen[c.Agents[i].Name] = true
+	}
+
+	return nil
+}
+
+// validateEmbedders checks that each configured embedder is a registered embedding model
+// whose dimension matches the index mapping (storage.embedding_dim).
+// With memory.truncate_embedding_dim set, it must equal storage.embedding_dim and embedders may be larger.
+// The built-in default embedder is only checked when it is registered.
+func (c *Config) validateEmbedders() error {
+	truncateDim := c.Memory.TruncateEmbeddingDim
+	if truncateDim > 0 && truncateDim != c.Storage.EmbeddingDim {
+		return fmt.Errorf("truncate_embedding_dim %d must equal storage.embedding_dim %d", truncateDim, c.Storage.EmbeddingDim)
+	}
+
+	if len(c.Models.Ark.Models) == 0 {
+		return nil
+	}
+
+	// Models are registered as "<provider>/<model>"
+	dims := make(map[string]int)
+	for _, m := range c.Models.Ark.Models {
+		if m.Type == genkit.ModelTypeEmbedding {
+			dims["ark/"+m.Model] = m.Dim
+		}
+	}
+	for _, m := range c.Models.Fallback.Models {
+		if m.Type == genkit.ModelTypeEmbedding {
+			dims[genkit.FallbackProvider+"/"+m.Model] = m.Dim
+		}
+	}
+
+	embedders := map[string]string{
+		"summary.embedder": c.Memory.Summary.Embedder,
+		"event.embedder":   c.Memory.Event.Embedder,
+	}
+	if c.Memory.Fallback.Embedder != "" {
+		embedders["fallback.embedder"] = c.Memory.Fallback.Embedder
+	}
+	for field, name := range embedders {
+		dim, ok := dims[name]
+		if !ok {
+			if name == action.EmbedderName {
+				continue
+			}
+			return fmt.Errorf("%s: embedding model %s is not configured", field, name)
+		}
+		if truncateDim > 0 && dim >= truncateDim {
+			continue
+		}
+		if dim != c.Storage.EmbeddingDim {
+			return fmt.Errorf("%s: %s has dim %d, but storage.embedding_dim is %d", field, name, dim, c.Storage.EmbeddingDim)
+		}
+	}
 	return nil
 }
 
@@ -105,5 +277,6 @@ func LoadConfig(filename string) (Config, error) {
 		return cfg, fmt.Errorf("validate config: %w", err)
 	}
 
+	cfg.path = filename
 	return cfg, nil
 }
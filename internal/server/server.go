@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	stdhttp "net/http"
 	"os"
@@ -25,7 +26,7 @@ type Server struct {
 	config Config
 	logger *slog.Logger
 	memory *action.Memory
-	store  *vector.OpenSearchStore
+	store  vector.Store
 }
 
 // NewServer creates a new server with the given configuration
@@ -64,13 +65,22 @@ func (s *Server) initDepend() error {
 		return errors.WithMessage(err, "failed to init models")
 	}
 
-	// Initialize OpenSearch storage singleton
+	// Initialize storage singleton for the configured backend
 	s.logger.Info("initializing storage")
 	if err := vector.Init(s.config.Storage); err != nil {
 		return errors.WithMessage(err, "failed to init storage")
 	}
 	s.store = vector.NewStore()
 
+	// Reads and writes go through an alias so index migrations can swap it without downtime
+	if opensearch, ok := s.store.(*vector.OpenSearchStore); ok {
+		if aliased, err := opensearch.EnsureAlias(ctx); err != nil {
+			s.logger.Warn("failed to ensure storage alias", "index", s.config.Storage.IndexName, "error", err)
+		} else if !aliased {
+			s.logger.Warn("storage index is not an alias, index migration is unavailable", "index", s.config.Storage.IndexName)
+		}
+	}
+
 	// Initialize PostgreSQL relation store
 	s.logger.Info("initializing relation store")
 	if err := relation.Init(s.config.Postgres); err != nil {
@@ -83,7 +93,10 @@ func (s *Server) initDepend() error {
 // initMemory initializes the memory instance
 func (s *Server) initMemory() error {
 	s.logger.Info("initializing memory")
-	s.memory = action.NewMemory()
+	if err := action.Init(s.config.Memory); err != nil {
+		return errors.WithMessage(err, "failed to init memory config")
+	}
+	s.memory = action.NewMemory().WithAgents(s.config.AgentProfiles())
 	return nil
 }
 
@@ -93,6 +106,9 @@ func (s *Server) Start() error {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	// Reload safe-to-change config on SIGHUP
+	go s.watchReload(ctx)
+
 	// Handle graceful shutdown
 	go func() {
 		sigCh := make(chan os.Signal, 1)
@@ -128,6 +144,46 @@ func (s *Server) Start() error {
 	return g.Wait()
 }
 
+// watchReload reloads the config file on every SIGHUP until ctx is done
+func (s *Server) watchReload(ctx context.Context) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			if err := s.reloadConfig(); err != nil {
+				s.logger.Error("config reload failed", "error", err)
+			}
+		}
+	}
+}
+
+// reloadConfig re-reads the config file and applies the memory settings and agent
+// profiles (actions and retrieve defaults) that are safe to change at runtime.
+// Stores, models and the server listener are left untouched.
+func (s *Server) reloadConfig() error {
+	if s.config.path == "" {
+		return errors.New("config was not loaded from a file")
+	}
+
+	cfg, err := LoadConfig(s.config.path)
+	if err != nil {
+		return err
+	}
+
+	if err := action.Reload(cfg.Memory); err != nil {
+		return errors.WithMessage(err, "failed to reload memory config")
+	}
+	s.memory.WithAgents(cfg.AgentProfiles())
+
+	s.logger.Info("config reloaded", "path", s.config.path)
+	return nil
+}
+
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown() error {
 	s.logger.Info("shutting down")
@@ -138,8 +194,8 @@ func (s *Server) Shutdown() error {
 		s.logger.Error("failed to close relation store", "error", err)
 	}
 
-	if s.store != nil {
-		_ = s.store.Close()
+	if closer, ok := s.store.(io.Closer); ok {
+		_ = closer.Close()
 	}
 
 	return nil
@@ -148,6 +204,13 @@ func (s *Server) Shutdown() error {
 func (s *Server) runHTTPServer(ctx context.Context) error {
 	serverCfg := http.DefaultServerConfig()
 	serverCfg.Port = s.config.Server.Port
+	serverCfg.APIKeys = s.config.Server.APIKeys
+	serverCfg.Limits = http.Limits{
+		MaxBodyBytes:     s.config.Server.MaxBodyBytes,
+		MaxMessages:      s.config.Server.MaxMessages,
+		MaxContentLength: s.config.Server.MaxContentLength,
+		MaxImportBytes:   s.config.Server.MaxImportBytes,
+	}
 
 	srv := http.NewServer(s.memory, serverCfg)
 
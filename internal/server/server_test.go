@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Zereker/memory/internal/action"
+	"github.com/Zereker/memory/pkg/genkit"
+	"github.com/Zereker/memory/pkg/vector"
+)
+
+const testConfig = `
+[server]
+port = 8080
+
+[log]
+path = "logs"
+rotation_time = "24h"
+max_age = "168h"
+default_pattern = "memory-%%Y-%%m-%%d.log"
+level = "info"
+format = "json"
+
+[storage]
+addresses = ["http://localhost:9200"]
+index = "memories"
+embedding_dim = 3
+
+[memory.cache]
+enabled = %t
+
+[memory.summary]
+max_length = %d
+`
+
+func writeTestConfig(t *testing.T, path string, cacheEnabled bool, maxLength int) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(testConfig, cacheEnabled, maxLength)), 0o644))
+}
+
+func TestServer_ReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, false, 100)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.NoError(t, action.Init(cfg.Memory))
+	t.Cleanup(func() { _ = action.Init(action.DefaultConfig()) })
+
+	memory := action.NewMemory()
+	s := &Server{config: cfg, logger: slog.Default(), memory: memory}
+
+	writeTestConfig(t, path, true, 50)
+	require.NoError(t, s.reloadConfig())
+
+	active := action.GetConfig()
+	assert.Equal(t, 50, active.Summary.MaxLength)
+	assert.False(t, active.Cache.Enabled, "cache is not safe to change at runtime")
+	assert.Same(t, memory, s.memory)
+
+	t.Run("embedders, workers and dual-write mode stay fixed", func(t *testing.T) {
+		before := action.GetConfig()
+		content := fmt.Sprintf(testConfig, true, 40) + `embedder = "ark/other-embedding"
+workers = 8
+
+[memory.event]
+embedder = "ark/other-embedding"
+dual_write_mode = "strict"
+max_events = 3
+`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		require.NoError(t, s.reloadConfig())
+
+		active := action.GetConfig()
+		assert.Equal(t, 40, active.Summary.MaxLength)
+		assert.Equal(t, 3, active.Event.MaxEvents)
+		assert.Equal(t, before.Summary.Embedder, active.Summary.Embedder)
+		assert.Equal(t, before.Summary.Workers, active.Summary.Workers)
+		assert.Equal(t, before.Event.Embedder, active.Event.Embedder)
+		assert.Equal(t, before.Event.DualWriteMode, active.Event.DualWriteMode)
+
+		writeTestConfig(t, path, true, 50)
+		require.NoError(t, s.reloadConfig())
+	})
+
+	t.Run("agent retrieve defaults are reloaded", func(t *testing.T) {
+		agent := func(maxHops int) string {
+			return fmt.Sprintf(testConfig, true, 50) + fmt.Sprintf(`
+[[agents]]
+name = "agent_1"
+enabled = true
+actions = ["short_term_recall", "cognitive_retrieval"]
+
+[agents.retrieve]
+max_hops = %d
+roles = ["user"]
+`, maxHops)
+		}
+
+		require.NoError(t, os.WriteFile(path, []byte(agent(1)), 0o644))
+		require.NoError(t, s.reloadConfig())
+		profile, ok := memory.Agent("agent_1")
+		require.True(t, ok)
+		assert.Equal(t, 1, profile.RetrieveDefaults.MaxHops)
+		assert.Equal(t, []string{"user"}, profile.RetrieveDefaults.Roles)
+
+		require.NoError(t, os.WriteFile(path, []byte(agent(3)), 0o644))
+		require.NoError(t, s.reloadConfig())
+		profile, _ = memory.Agent("agent_1")
+		assert.Equal(t, 3, profile.RetrieveDefaults.MaxHops)
+
+		writeTestConfig(t, path, true, 50)
+		require.NoError(t, s.reloadConfig())
+		_, ok = memory.Agent("agent_1")
+		assert.False(t, ok, "removed agents fall back to the full chain")
+	})
+
+	t.Run("invalid file keeps active config", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("[memory.summary]\nmax_length = -1\n"), 0o644))
+		assert.Error(t, s.reloadConfig())
+		assert.Equal(t, 50, action.GetConfig().Summary.MaxLength)
+	})
+}
+
+func TestConfig_ValidateEmbedders(t *testing.T) {
+	newConfig := func() Config {
+		return Config{
+			Models: genkit.Config{Ark: genkit.ArkConfig{Models: []genkit.ModelConfig{
+				{Name: "doubao-embedding-text-240715", Type: genkit.ModelTypeEmbedding, Model: "doubao-embedding-text-240715", Dim: 2560},
+				{Name: "doubao-embedding-small", Type: genkit.ModelTypeEmbedding, Model: "doubao-embedding-small", Dim: 1024},
+			}}},
+			Storage: vector.OpenSearchConfig{EmbeddingDim: 2560},
+			Memory:  action.DefaultConfig(),
+		}
+	}
+
+	t.Run("matching dimension", func(t *testing.T) {
+		cfg := newConfig()
+		assert.NoError(t, cfg.validateEmbedders())
+	})
+
+	t.Run("dimension mismatch", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Memory.Event.Embedder = "ark/doubao-embedding-small"
+		assert.ErrorContains(t, cfg.validateEmbedders(), "event.embedder")
+	})
+
+	t.Run("fallback embedder dimension", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Models.Fallback.Models = []genkit.ModelConfig{
+			{Name: "text-embedding-3-small", Type: genkit.ModelTypeEmbedding, Model: "text-embedding-3-small", Dim: 1536},
+		}
+		cfg.Memory.Fallback.Embedder = "fallback/text-embedding-3-small"
+		assert.ErrorContains(t, cfg.validateEmbedders(), "fallback.embedder")
+	})
+
+	t.Run("unknown embedder", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Memory.Summary.Embedder = "ark/missing"
+		assert.ErrorContains(t, cfg.validateEmbedders(), "not configured")
+	})
+
+	t.Run("larger embedder truncated to index dimension", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Storage.EmbeddingDim = 1024
+		cfg.Memory.TruncateEmbeddingDim = 1024
+		assert.NoError(t, cfg.validateEmbedders())
+
+		cfg.Memory.TruncateEmbeddingDim = 512
+		assert.ErrorContains(t, cfg.validateEmbedders(), "truncate_embedding_dim")
+	})
+}
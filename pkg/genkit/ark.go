@@ -67,13 +67,18 @@ func (p *ArkPlugin) Init(ctx context.Context) []api.Action {
 	// Initialize the base OpenAI compatible client first
 	p.OpenAICompatible.Init(ctx)
 
-	actions := make([]api.Action, 0, len(p.models))
+	return defineCompatActions(&p.OpenAICompatible, "Ark", p.models)
+}
+
+// defineCompatActions registers the configured models on an OpenAI compatible client
+func defineCompatActions(p *compat_oai.OpenAICompatible, label string, models []ModelConfig) []api.Action {
+	actions := make([]api.Action, 0, len(models))
 
-	for _, m := range p.models {
+	for _, m := range models {
 		switch m.Type {
 		case ModelTypeLLM:
 			model := p.DefineModel(p.Provider, m.Model, ai.ModelOptions{
-				Label: fmt.Sprintf("Ark %s", m.Name),
+				Label: fmt.Sprintf("%s %s", label, m.Name),
 				Supports: &ai.ModelSupports{
 					Multiturn:  true,
 					Tools:      true,
@@ -85,7 +90,7 @@ func (p *ArkPlugin) Init(ctx context.Context) []api.Action {
 
 		case ModelTypeEmbedding:
 			embedder := p.DefineEmbedder(p.Provider, m.Model, &ai.EmbedderOptions{
-				Label:      fmt.Sprintf("Ark %s", m.Name),
+				Label:      fmt.Sprintf("%s %s", label, m.Name),
 				Dimensions: m.Dim,
 			})
 			actions = append(actions, embedder.(api.Action))
@@ -95,3 +100,62 @@ func (p *ArkPlugin) Init(ctx context.Context) []api.Action {
 	return actions
 }
 
+// FallbackProvider is the provider prefix of the secondary vendor's models (e.g. "fallback/gpt-4o-mini")
+const FallbackProvider = "fallback"
+
+// FallbackConfig holds configuration for a secondary OpenAI compatible vendor,
+// used when the primary vendor fails
+type FallbackConfig struct {
+	APIKey  string        `toml:"api_key"`
+	BaseURL string        `toml:"base_url"`
+	Models  []ModelConfig `toml:"models"`
+}
+
+// Validate checks fallback vendor configuration
+func (c *FallbackConfig) Validate() error {
+	if c.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	if len(c.Models) == 0 {
+		return fmt.Errorf("at least one model is required")
+	}
+	for i := range c.Models {
+		if err := c.Models[i].Validate(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FallbackPlugin implements Genkit plugin for the secondary OpenAI compatible vendor
+type FallbackPlugin struct {
+	compat_oai.OpenAICompatible
+	models []ModelConfig
+}
+
+// NewFallbackPlugin creates a new fallback plugin for Genkit
+func NewFallbackPlugin(cfg FallbackConfig) *FallbackPlugin {
+	return &FallbackPlugin{
+		OpenAICompatible: compat_oai.OpenAICompatible{
+			APIKey:   cfg.APIKey,
+			BaseURL:  cfg.BaseURL,
+			Provider: FallbackProvider,
+			Opts: []option.RequestOption{
+				option.WithHeader("Content-Type", "application/json"),
+			},
+		},
+		models: cfg.Models,
+	}
+}
+
+// Name returns the plugin name
+func (p *FallbackPlugin) Name() string {
+	return FallbackProvider
+}
+
+// Init implements api.Plugin interface - registers all fallback models
+func (p *FallbackPlugin) Init(ctx context.Context) []api.Action {
+	p.OpenAICompatible.Init(ctx)
+
+	return defineCompatActions(&p.OpenAICompatible, "Fallback", p.models)
+}
@@ -3,6 +3,9 @@ package genkit
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 
 	"github.com/firebase/genkit/go/core/api"
 	"github.com/firebase/genkit/go/genkit"
@@ -51,18 +54,40 @@ func (m *ModelConfig) Validate(index int) error {
 type Config struct {
 	Ark       ArkConfig `toml:"ark"`
 	PromptDir string    `toml:"prompt_dir"`
+
+	// Fallback is an optional secondary vendor; its models are registered as "fallback/<model>"
+	Fallback FallbackConfig `toml:"fallback"`
+
+	// PromptOverrides maps a prompt name (e.g. "memory_extract") to inline dotprompt source.
+	// Overrides replace the same-named file in PromptDir, or add a new prompt.
+	PromptOverrides map[string]string `toml:"prompt_overrides"`
 }
 
 // Validate checks genkit configuration
 func (c *Config) Validate() error {
 	// PromptDir is optional - prompts can be defined in Go code
 
+	for name, source := range c.PromptOverrides {
+		if name == "" || filepath.Base(name) != name {
+			return fmt.Errorf("prompt_overrides: invalid prompt name %q", name)
+		}
+		if source == "" {
+			return fmt.Errorf("prompt_overrides.%s: source is empty", name)
+		}
+	}
+
 	if len(c.Ark.Models) > 0 {
 		if err := c.Ark.Validate(); err != nil {
 			return fmt.Errorf("ark: %w", err)
 		}
 	}
 
+	if len(c.Fallback.Models) > 0 {
+		if err := c.Fallback.Validate(); err != nil {
+			return fmt.Errorf("fallback: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -80,12 +105,11 @@ func Init(ctx context.Context, cfg Config) error {
 		plugins = append(plugins, NewArkPlugin(cfg.Ark))
 	}
 
-	g = genkit.Init(ctx,
-		genkit.WithPlugins(plugins...),
-		genkit.WithPromptDir(cfg.PromptDir),
-	)
+	if len(cfg.Fallback.Models) > 0 {
+		plugins = append(plugins, NewFallbackPlugin(cfg.Fallback))
+	}
 
-	return nil
+	return initGenkit(ctx, plugins, cfg.PromptDir, cfg.PromptOverrides)
 }
 
 // InitForTest initializes genkit with a mock plugin for testing.
@@ -93,10 +117,9 @@ func Init(ctx context.Context, cfg Config) error {
 func InitForTest(ctx context.Context, cfg MockConfig, promptDir string) *MockPlugin {
 	mockPlugin := NewMockPlugin(cfg)
 
-	g = genkit.Init(ctx,
-		genkit.WithPlugins(mockPlugin),
-		genkit.WithPromptDir(promptDir),
-	)
+	if err := initGenkit(ctx, []api.Plugin{mockPlugin}, promptDir, cfg.PromptOverrides); err != nil {
+		panic(err)
+	}
 
 	return mockPlugin
 }
@@ -109,6 +132,73 @@ func InitWithPlugins(ctx context.Context, plugins []api.Plugin, promptDir string
 	)
 }
 
+// initGenkit initializes the Genkit instance, applying prompt overrides on top of promptDir.
+func initGenkit(ctx context.Context, plugins []api.Plugin, promptDir string, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		g = genkit.Init(ctx,
+			genkit.WithPlugins(plugins...),
+			genkit.WithPromptDir(promptDir),
+		)
+		return nil
+	}
+
+	// Genkit only loads prompts from a directory and panics on duplicate registration,
+	// so overrides are merged into a temporary copy of promptDir before loading.
+	mergedDir, err := mergePromptDir(promptDir, overrides)
+	if err != nil {
+		return errors.WithMessage(err, "failed to apply prompt overrides")
+	}
+	defer os.RemoveAll(mergedDir)
+
+	g = genkit.Init(ctx,
+		genkit.WithPlugins(plugins...),
+		genkit.WithPromptDir(mergedDir),
+	)
+	return nil
+}
+
+// mergePromptDir copies promptDir into a temporary directory and writes each override as <name>.prompt.
+func mergePromptDir(promptDir string, overrides map[string]string) (string, error) {
+	mergedDir, err := os.MkdirTemp("", "memory-prompts-")
+	if err != nil {
+		return "", err
+	}
+
+	if promptDir != "" {
+		err = filepath.WalkDir(promptDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(promptDir, path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(mergedDir, rel)
+			if d.IsDir() {
+				return os.MkdirAll(target, 0o755)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, 0o644)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			os.RemoveAll(mergedDir)
+			return "", err
+		}
+	}
+
+	for name, source := range overrides {
+		if err := os.WriteFile(filepath.Join(mergedDir, name+".prompt"), []byte(source), 0o644); err != nil {
+			os.RemoveAll(mergedDir)
+			return "", err
+		}
+	}
+
+	return mergedDir, nil
+}
+
 // Genkit returns the Genkit instance
 func Genkit() *genkit.Genkit {
 	return g
@@ -0,0 +1,67 @@
+package genkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInit_PromptOverrides(t *testing.T) {
+	ctx := context.Background()
+
+	promptDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(promptDir, "greeting.prompt"), []byte(
+		"---\nmodel: mock/test-llm\n---\n原始提示词 {{name}}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(promptDir, "farewell.prompt"), []byte(
+		"---\nmodel: mock/test-llm\n---\n再见 {{name}}\n"), 0o644))
+
+	cfg := DefaultMockConfig()
+	cfg.PromptOverrides = map[string]string{
+		"greeting": "---\nmodel: mock/test-llm\n---\n覆盖提示词 {{name}}\n",
+	}
+	_ = InitForTest(ctx, cfg, promptDir)
+	g := Genkit()
+
+	// 被覆盖的 prompt 使用内联文本（mock 模型回显用户消息）
+	greeting := genkit.LookupPrompt(g, "greeting")
+	require.NotNil(t, greeting)
+	resp, err := greeting.Execute(ctx, ai.WithInput(map[string]any{"name": "小明"}))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Text(), "覆盖提示词 小明")
+	assert.NotContains(t, resp.Text(), "原始提示词")
+
+	// 未覆盖的 prompt 仍从目录加载
+	farewell := genkit.LookupPrompt(g, "farewell")
+	require.NotNil(t, farewell)
+	resp, err = farewell.Execute(ctx, ai.WithInput(map[string]any{"name": "小明"}))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Text(), "再见 小明")
+}
+
+func TestConfig_ValidatePromptOverrides(t *testing.T) {
+	cfg := Config{PromptOverrides: map[string]string{"../memory_extract": "x"}}
+	assert.Error(t, cfg.Validate())
+
+	cfg = Config{PromptOverrides: map[string]string{"memory_extract": ""}}
+	assert.Error(t, cfg.Validate())
+
+	cfg = Config{PromptOverrides: map[string]string{"memory_extract": "---\n---\nhi"}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ValidateFallback(t *testing.T) {
+	cfg := Config{Fallback: FallbackConfig{Models: []ModelConfig{{Name: "gpt-4o-mini", Type: ModelTypeLLM, Model: "gpt-4o-mini"}}}}
+	assert.ErrorContains(t, cfg.Validate(), "base_url")
+
+	cfg.Fallback.BaseURL = "https://api.openai.com/v1"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Fallback.Models = append(cfg.Fallback.Models, ModelConfig{Name: "text-embedding-3-small", Type: ModelTypeEmbedding, Model: "text-embedding-3-small"})
+	assert.ErrorContains(t, cfg.Validate(), "dim")
+}
@@ -14,6 +14,9 @@ import (
 type MockConfig struct {
 	Provider string        // Provider prefix (default: "mock"). Use "ark" to match real model names.
 	Models   []ModelConfig
+
+	// PromptOverrides maps prompt name to inline dotprompt source (see Config.PromptOverrides)
+	PromptOverrides map[string]string
 }
 
 // MockPlugin implements a test-only genkit plugin with configurable responses
@@ -1,6 +1,12 @@
 package relation
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
 
 // PostgresConfig holds PostgreSQL connection configuration.
 type PostgresConfig struct {
@@ -11,6 +17,11 @@ type PostgresConfig struct {
 	Password string `toml:"password"`
 	Database string `toml:"database"`
 	SSLMode  string `toml:"ssl_mode"`
+
+	// Pool settings. Zero values keep the pgxpool defaults.
+	MaxConns         int32  `toml:"max_conns"`
+	MaxConnLifetime  string `toml:"max_conn_lifetime"` // e.g. "1h"
+	StatementTimeout string `toml:"statement_timeout"` // e.g. "5s", applied per connection
 }
 
 // DSN returns the PostgreSQL connection string.
@@ -39,5 +50,48 @@ func (c *PostgresConfig) Validate() error {
 	if c.Database == "" {
 		return fmt.Errorf("database is required")
 	}
+	if c.MaxConns < 0 {
+		return fmt.Errorf("max_conns must be non-negative")
+	}
+	if c.MaxConnLifetime != "" {
+		if _, err := time.ParseDuration(c.MaxConnLifetime); err != nil {
+			return fmt.Errorf("max_conn_lifetime is invalid: %w", err)
+		}
+	}
+	if c.StatementTimeout != "" {
+		if _, err := time.ParseDuration(c.StatementTimeout); err != nil {
+			return fmt.Errorf("statement_timeout is invalid: %w", err)
+		}
+	}
 	return nil
 }
+
+// PoolConfig parses the DSN and applies the pool settings.
+func (c *PostgresConfig) PoolConfig() (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(c.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dsn: %w", err)
+	}
+
+	if c.MaxConns > 0 {
+		poolCfg.MaxConns = c.MaxConns
+	}
+
+	if c.MaxConnLifetime != "" {
+		lifetime, err := time.ParseDuration(c.MaxConnLifetime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse max_conn_lifetime: %w", err)
+		}
+		poolCfg.MaxConnLifetime = lifetime
+	}
+
+	if c.StatementTimeout != "" {
+		timeout, err := time.ParseDuration(c.StatementTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse statement_timeout: %w", err)
+		}
+		poolCfg.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	}
+
+	return poolCfg, nil
+}
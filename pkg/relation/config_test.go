@@ -0,0 +1,57 @@
+package relation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresConfig_PoolConfig(t *testing.T) {
+	cfg := PostgresConfig{
+		Enabled:          true,
+		Host:             "localhost",
+		Port:             5432,
+		User:             "memory",
+		Database:         "memory",
+		MaxConns:         32,
+		MaxConnLifetime:  "30m",
+		StatementTimeout: "2s",
+	}
+	require.NoError(t, cfg.Validate())
+
+	poolCfg, err := cfg.PoolConfig()
+	require.NoError(t, err)
+	assert.Equal(t, int32(32), poolCfg.MaxConns)
+	assert.Equal(t, 30*time.Minute, poolCfg.MaxConnLifetime)
+	assert.Equal(t, "2000", poolCfg.ConnConfig.RuntimeParams["statement_timeout"])
+	assert.Equal(t, "localhost", poolCfg.ConnConfig.Host)
+	assert.Equal(t, "memory", poolCfg.ConnConfig.Database)
+}
+
+func TestPostgresConfig_PoolConfigDefaults(t *testing.T) {
+	cfg := PostgresConfig{Enabled: true, Host: "localhost", Port: 5432, Database: "memory"}
+
+	poolCfg, err := cfg.PoolConfig()
+	require.NoError(t, err)
+	assert.Positive(t, poolCfg.MaxConns)
+	assert.Equal(t, time.Hour, poolCfg.MaxConnLifetime)
+	assert.NotContains(t, poolCfg.ConnConfig.RuntimeParams, "statement_timeout")
+}
+
+func TestPostgresConfig_ValidatePoolSettings(t *testing.T) {
+	base := PostgresConfig{Enabled: true, Host: "localhost", Port: 5432, Database: "memory"}
+
+	cfg := base
+	cfg.MaxConns = -1
+	assert.Error(t, cfg.Validate())
+
+	cfg = base
+	cfg.MaxConnLifetime = "forever"
+	assert.Error(t, cfg.Validate())
+
+	cfg = base
+	cfg.StatementTimeout = "5"
+	assert.Error(t, cfg.Validate())
+}
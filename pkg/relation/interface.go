@@ -19,9 +19,15 @@ type Store interface {
 	// CreateRelation creates or updates an event relation (UPSERT semantics).
 	CreateRelation(ctx context.Context, rel Relation) error
 
+	// CreateRelations creates or updates multiple relations in one operation (UPSERT semantics).
+	CreateRelations(ctx context.Context, rels []Relation) error
+
 	// DeleteByEventID deletes all relations involving the given event ID.
 	DeleteByEventID(ctx context.Context, eventID string) error
 
+	// FindByEventID returns all relations where the given event is either endpoint.
+	FindByEventID(ctx context.Context, eventID string) ([]Relation, error)
+
 	// Close releases resources held by the store.
 	Close(ctx context.Context) error
 }
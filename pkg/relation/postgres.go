@@ -27,7 +27,11 @@ func Init(cfg PostgresConfig) error {
 }
 
 // NewStore returns the PostgresStore singleton instance.
-func NewStore() *PostgresStore {
+// Returns a nil Store when PostgreSQL is disabled, so callers can nil-check the interface.
+func NewStore() Store {
+	if pgInstance == nil {
+		return nil
+	}
 	return pgInstance
 }
 
@@ -48,7 +52,12 @@ func newPostgresStore(cfg PostgresConfig) (*PostgresStore, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, cfg.DSN())
+	poolCfg, err := cfg.PoolConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pgx pool: %w", err)
 	}
@@ -101,6 +110,53 @@ DO UPDATE SET id = EXCLUDED.id, created_at = EXCLUDED.created_at
 	return nil
 }
 
+// CreateRelations inserts or updates multiple relations in a single statement.
+// Duplicates of the same (from, to, type) key within rels are collapsed, keeping the last one,
+// since one INSERT ... ON CONFLICT cannot update the same row twice.
+func (s *PostgresStore) CreateRelations(ctx context.Context, rels []Relation) error {
+	if len(rels) == 0 {
+		return nil
+	}
+
+	type relationKey struct{ from, to, relType string }
+	index := make(map[relationKey]int, len(rels))
+	unique := make([]Relation, 0, len(rels))
+	for _, rel := range rels {
+		key := relationKey{rel.FromEventID, rel.ToEventID, rel.RelationType}
+		if i, ok := index[key]; ok {
+			unique[i] = rel
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, rel)
+	}
+
+	ids := make([]string, len(unique))
+	froms := make([]string, len(unique))
+	tos := make([]string, len(unique))
+	types := make([]string, len(unique))
+	createdAts := make([]time.Time, len(unique))
+	for i, rel := range unique {
+		ids[i] = rel.ID
+		froms[i] = rel.FromEventID
+		tos[i] = rel.ToEventID
+		types[i] = rel.RelationType
+		createdAts[i] = rel.CreatedAt
+	}
+
+	query := `
+INSERT INTO event_relations (id, from_event_id, to_event_id, relation_type, created_at)
+SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::timestamptz[])
+ON CONFLICT (from_event_id, to_event_id, relation_type)
+DO UPDATE SET id = EXCLUDED.id, created_at = EXCLUDED.created_at
+`
+	_, err := s.pool.Exec(ctx, query, ids, froms, tos, types, createdAts)
+	if err != nil {
+		return fmt.Errorf("failed to create relations: %w", err)
+	}
+	return nil
+}
+
 // DeleteByEventID deletes all relations involving the given event ID.
 func (s *PostgresStore) DeleteByEventID(ctx context.Context, eventID string) error {
 	query := `DELETE FROM event_relations WHERE from_event_id = $1 OR to_event_id = $1`
@@ -111,6 +167,36 @@ func (s *PostgresStore) DeleteByEventID(ctx context.Context, eventID string) err
 	return nil
 }
 
+// FindByEventID returns all relations where the given event is either endpoint.
+func (s *PostgresStore) FindByEventID(ctx context.Context, eventID string) ([]Relation, error) {
+	query := `
+SELECT id, from_event_id, to_event_id, relation_type, created_at
+FROM event_relations
+WHERE from_event_id = $1 OR to_event_id = $1
+ORDER BY created_at
+`
+	rows, err := s.pool.Query(ctx, query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query relations for event %s: %w", eventID, err)
+	}
+	defer rows.Close()
+
+	var relations []Relation
+	for rows.Next() {
+		var rel Relation
+		if err := rows.Scan(&rel.ID, &rel.FromEventID, &rel.ToEventID, &rel.RelationType, &rel.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan relation: %w", err)
+		}
+		relations = append(relations, rel)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate relations for event %s: %w", eventID, err)
+	}
+
+	return relations, nil
+}
+
 // Close releases the connection pool.
 func (s *PostgresStore) Close(_ context.Context) error {
 	s.pool.Close()
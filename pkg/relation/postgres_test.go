@@ -0,0 +1,108 @@
+package relation
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore connects to MEMORY_TEST_POSTGRES_DSN, skipping when it is not set.
+func newTestStore(t *testing.T) *PostgresStore {
+	t.Helper()
+
+	dsn := os.Getenv("MEMORY_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("MEMORY_TEST_POSTGRES_DSN not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+
+	store := &PostgresStore{pool: pool}
+	require.NoError(t, store.ensureSchema(ctx))
+
+	t.Cleanup(func() { _ = store.Close(ctx) })
+	return store
+}
+
+func TestPostgresStore_FindByEventID(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	rels := []Relation{
+		{ID: "rel_find_1", FromEventID: "evt_find_a", ToEventID: "evt_find_b", RelationType: "causal", CreatedAt: now},
+		{ID: "rel_find_2", FromEventID: "evt_find_c", ToEventID: "evt_find_a", RelationType: "temporal", CreatedAt: now.Add(time.Second)},
+		{ID: "rel_find_3", FromEventID: "evt_find_c", ToEventID: "evt_find_d", RelationType: "temporal", CreatedAt: now},
+	}
+	for _, rel := range rels {
+		require.NoError(t, store.CreateRelation(ctx, rel))
+	}
+	t.Cleanup(func() {
+		for _, id := range []string{"evt_find_a", "evt_find_c"} {
+			_ = store.DeleteByEventID(ctx, id)
+		}
+	})
+
+	t.Run("outgoing and incoming relations", func(t *testing.T) {
+		found, err := store.FindByEventID(ctx, "evt_find_a")
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "rel_find_1", found[0].ID)
+		assert.Equal(t, "rel_find_2", found[1].ID)
+	})
+
+	t.Run("by target endpoint", func(t *testing.T) {
+		found, err := store.FindByEventID(ctx, "evt_find_d")
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "evt_find_c", found[0].FromEventID)
+		assert.Equal(t, "temporal", found[0].RelationType)
+	})
+
+	t.Run("unknown event", func(t *testing.T) {
+		found, err := store.FindByEventID(ctx, "evt_find_missing")
+		require.NoError(t, err)
+		assert.Empty(t, found)
+	})
+}
+
+func TestPostgresStore_CreateRelations(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	t.Cleanup(func() { _ = store.DeleteByEventID(ctx, "evt_bulk_a") })
+
+	require.NoError(t, store.CreateRelations(ctx, nil))
+
+	rels := []Relation{
+		{ID: "rel_bulk_1", FromEventID: "evt_bulk_a", ToEventID: "evt_bulk_b", RelationType: "causal", CreatedAt: now},
+		{ID: "rel_bulk_2", FromEventID: "evt_bulk_a", ToEventID: "evt_bulk_c", RelationType: "temporal", CreatedAt: now.Add(time.Second)},
+		// Same key as rel_bulk_1 within one batch: last one wins.
+		{ID: "rel_bulk_3", FromEventID: "evt_bulk_a", ToEventID: "evt_bulk_b", RelationType: "causal", CreatedAt: now},
+	}
+	require.NoError(t, store.CreateRelations(ctx, rels))
+
+	found, err := store.FindByEventID(ctx, "evt_bulk_a")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "rel_bulk_3", found[0].ID)
+	assert.Equal(t, "rel_bulk_2", found[1].ID)
+
+	// Upsert on conflict with existing rows.
+	require.NoError(t, store.CreateRelations(ctx, []Relation{
+		{ID: "rel_bulk_4", FromEventID: "evt_bulk_a", ToEventID: "evt_bulk_c", RelationType: "temporal", CreatedAt: now.Add(2 * time.Second)},
+	}))
+
+	found, err = store.FindByEventID(ctx, "evt_bulk_a")
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "rel_bulk_4", found[1].ID)
+}
@@ -0,0 +1,150 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// DefaultAggregateSize is the number of buckets returned when size is not positive
+const DefaultAggregateSize = 10
+
+// calendarIntervals are the date_histogram calendar intervals accepted by DateHistogram
+var calendarIntervals = map[string]bool{
+	"minute": true, "hour": true, "day": true, "week": true, "month": true, "quarter": true, "year": true,
+}
+
+// Aggregator is implemented by stores that can count documents by field values or over time.
+// A filter value that is a []string matches any of the values.
+type Aggregator interface {
+	// Aggregate counts active documents matching filters by the values of a keyword field
+	Aggregate(ctx context.Context, field string, filters map[string]any, size int) (map[string]int, error)
+
+	// DateHistogram counts active documents matching filters per calendar interval of a date field
+	DateHistogram(ctx context.Context, field, interval string, filters map[string]any) ([]HistogramBucket, error)
+}
+
+// HistogramBucket is the document count of one date_histogram interval
+type HistogramBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// IsCalendarInterval reports whether interval is a supported date_histogram calendar interval
+func IsCalendarInterval(interval string) bool {
+	return calendarIntervals[interval]
+}
+
+// aggBucket is a single bucket of a terms or date_histogram aggregation
+type aggBucket struct {
+	Key         any    `json:"key"`
+	KeyAsString string `json:"key_as_string"`
+	DocCount    int    `json:"doc_count"`
+}
+
+// aggregate runs a single named aggregation over active documents matching filters and returns its buckets
+func (s *OpenSearchStore) aggregate(ctx context.Context, agg map[string]any, filters map[string]any) ([]aggBucket, error) {
+	filterClauses := []map[string]any{{"term": map[string]any{"status": StatusActive}}}
+	for field, value := range filters {
+		if values, ok := value.([]string); ok {
+			filterClauses = append(filterClauses, map[string]any{"terms": map[string]any{field: values}})
+			continue
+		}
+		filterClauses = append(filterClauses, map[string]any{"term": map[string]any{field: value}})
+	}
+
+	query := map[string]any{
+		"query": map[string]any{"bool": map[string]any{"filter": filterClauses}},
+		"aggs":  map[string]any{"buckets": agg},
+	}
+
+	queryBody, _ := json.Marshal(query)
+	resp, err := s.client.Search(ctx, &opensearchapi.SearchReq{
+		Indices: []string{s.indexName},
+		Body:    bytes.NewReader(queryBody),
+		Params: opensearchapi.SearchParams{
+			Size: opensearchapi.ToPointer(0),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aggregate failed: %w", err)
+	}
+
+	var result struct {
+		Buckets struct {
+			Buckets []aggBucket `json:"buckets"`
+		} `json:"buckets"`
+	}
+	if len(resp.Aggregations) > 0 {
+		if err := json.Unmarshal(resp.Aggregations, &result); err != nil {
+			return nil, fmt.Errorf("decode aggregation failed: %w", err)
+		}
+	}
+
+	return result.Buckets.Buckets, nil
+}
+
+// Aggregate counts active documents matching filters by the values of a keyword field.
+// At most size buckets are returned, ordered by count by OpenSearch.
+func (s *OpenSearchStore) Aggregate(ctx context.Context, field string, filters map[string]any, size int) (map[string]int, error) {
+	if field == "" {
+		return nil, fmt.Errorf("aggregation field is required")
+	}
+	if size <= 0 {
+		size = DefaultAggregateSize
+	}
+
+	buckets, err := s.aggregate(ctx, map[string]any{
+		"terms": map[string]any{"field": field, "size": size},
+	}, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(buckets))
+	for _, b := range buckets {
+		key := b.KeyAsString
+		if key == "" {
+			key = fmt.Sprint(b.Key)
+		}
+		counts[key] = b.DocCount
+	}
+
+	return counts, nil
+}
+
+// DateHistogram counts active documents matching filters per calendar interval of a date field.
+// Buckets are in ascending order; empty intervals between the first and last document are included.
+func (s *OpenSearchStore) DateHistogram(ctx context.Context, field, interval string, filters map[string]any) ([]HistogramBucket, error) {
+	if field == "" {
+		return nil, fmt.Errorf("histogram field is required")
+	}
+	if !IsCalendarInterval(interval) {
+		return nil, fmt.Errorf("unsupported histogram interval %q", interval)
+	}
+
+	buckets, err := s.aggregate(ctx, map[string]any{
+		"date_histogram": map[string]any{"field": field, "calendar_interval": interval},
+	}, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	histogram := make([]HistogramBucket, 0, len(buckets))
+	for _, b := range buckets {
+		millis, ok := b.Key.(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected histogram key %v", b.Key)
+		}
+		histogram = append(histogram, HistogramBucket{
+			Start: time.UnixMilli(int64(millis)).UTC(),
+			Count: b.DocCount,
+		})
+	}
+
+	return histogram, nil
+}
@@ -0,0 +1,184 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAggregateStore starts a fake OpenSearch that evaluates terms and daily date_histogram aggregations over the seeded documents.
+func newAggregateStore(t *testing.T, docs []map[string]any) *OpenSearchStore {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query struct {
+				Bool struct {
+					Filter []map[string]map[string]any `json:"filter"`
+				} `json:"bool"`
+			} `json:"query"`
+			Aggs struct {
+				Buckets struct {
+					Terms *struct {
+						Field string `json:"field"`
+						Size  int    `json:"size"`
+					} `json:"terms"`
+					DateHistogram *struct {
+						Field    string `json:"field"`
+						Interval string `json:"calendar_interval"`
+					} `json:"date_histogram"`
+				} `json:"buckets"`
+			} `json:"aggs"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		var matched []map[string]any
+		for _, doc := range docs {
+			match := true
+			for _, clause := range body.Query.Bool.Filter {
+				for field, value := range clause["term"] {
+					if doc[field] != value {
+						match = false
+					}
+				}
+				for field, values := range clause["terms"] {
+					found := false
+					for _, value := range values.([]any) {
+						found = found || doc[field] == value
+					}
+					match = match && found
+				}
+			}
+			if match {
+				matched = append(matched, doc)
+			}
+		}
+
+		buckets := []map[string]any{}
+		switch agg := body.Aggs.Buckets; {
+		case agg.Terms != nil:
+			counts := map[string]int{}
+			for _, doc := range matched {
+				if key, ok := doc[agg.Terms.Field].(string); ok {
+					counts[key]++
+				}
+			}
+			for key, count := range counts {
+				buckets = append(buckets, map[string]any{"key": key, "doc_count": count})
+			}
+			sort.Slice(buckets, func(i, j int) bool { return buckets[i]["doc_count"].(int) > buckets[j]["doc_count"].(int) })
+			if len(buckets) > agg.Terms.Size {
+				buckets = buckets[:agg.Terms.Size]
+			}
+		case agg.DateHistogram != nil:
+			require.Equal(t, "day", agg.DateHistogram.Interval, "fake only buckets by day")
+			counts := map[time.Time]int{}
+			var first, last time.Time
+			for _, doc := range matched {
+				at, err := time.Parse(time.RFC3339, doc[agg.DateHistogram.Field].(string))
+				require.NoError(t, err)
+				day := at.UTC().Truncate(24 * time.Hour)
+				counts[day]++
+				if first.IsZero() || day.Before(first) {
+					first = day
+				}
+				if day.After(last) {
+					last = day
+				}
+			}
+			for day := first; !first.IsZero() && !day.After(last); day = day.Add(24 * time.Hour) {
+				buckets = append(buckets, map[string]any{"key": day.UnixMilli(), "key_as_string": day.Format(time.RFC3339), "doc_count": counts[day]})
+			}
+		}
+
+		writeJSON(w, map[string]any{
+			"hits":         map[string]any{"total": map[string]any{"value": 0}, "hits": []any{}},
+			"aggregations": map[string]any{"buckets": map[string]any{"buckets": buckets}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := OpenSearchConfig{Addresses: []string{server.URL}, IndexName: "memories", EmbeddingDim: 3}
+	require.NoError(t, cfg.Validate())
+
+	store, err := NewOpenSearchStore(cfg)
+	require.NoError(t, err)
+	return store
+}
+
+func TestOpenSearchStore_Aggregate(t *testing.T) {
+	ctx := context.Background()
+
+	store := newAggregateStore(t, []map[string]any{
+		{"user_id": "user_1", "status": StatusActive, "memory_type": "fact"},
+		{"user_id": "user_1", "status": StatusActive, "memory_type": "fact"},
+		{"user_id": "user_1", "status": StatusActive, "memory_type": "fact"},
+		{"user_id": "user_1", "status": StatusActive, "memory_type": "working"},
+		{"user_id": "user_1", "status": StatusArchived, "memory_type": "working"},
+		{"user_id": "user_2", "status": StatusActive, "memory_type": "working"},
+	})
+
+	t.Run("counts active documents per value", func(t *testing.T) {
+		counts, err := store.Aggregate(ctx, "memory_type", map[string]any{"user_id": "user_1"}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"fact": 3, "working": 1}, counts)
+	})
+
+	t.Run("size limits buckets", func(t *testing.T) {
+		counts, err := store.Aggregate(ctx, "memory_type", map[string]any{"user_id": "user_1"}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"fact": 3}, counts)
+	})
+
+	t.Run("field is required", func(t *testing.T) {
+		_, err := store.Aggregate(ctx, "", nil, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestOpenSearchStore_DateHistogram(t *testing.T) {
+	ctx := context.Background()
+
+	store := newAggregateStore(t, []map[string]any{
+		{"user_id": "user_1", "status": StatusActive, "created_at": "2026-03-01T08:00:00Z"},
+		{"user_id": "user_1", "status": StatusActive, "created_at": "2026-03-01T21:30:00Z"},
+		{"user_id": "user_1", "status": StatusActive, "created_at": "2026-03-03T10:00:00Z"},
+		{"user_id": "user_1", "status": StatusArchived, "created_at": "2026-03-03T11:00:00Z"},
+		{"user_id": "user_2", "status": StatusActive, "created_at": "2026-03-02T10:00:00Z"},
+	})
+
+	t.Run("buckets by day", func(t *testing.T) {
+		buckets, err := store.DateHistogram(ctx, "created_at", "day", map[string]any{"user_id": "user_1"})
+		require.NoError(t, err)
+
+		day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+		assert.Equal(t, []HistogramBucket{
+			{Start: day(1), Count: 2},
+			{Start: day(2), Count: 0},
+			{Start: day(3), Count: 1},
+		}, buckets)
+	})
+
+	t.Run("slice filter matches any value", func(t *testing.T) {
+		buckets, err := store.DateHistogram(ctx, "created_at", "day", map[string]any{"user_id": []string{"user_1", "user_2"}})
+		require.NoError(t, err)
+
+		counts := make([]int, 0, len(buckets))
+		for _, b := range buckets {
+			counts = append(counts, b.Count)
+		}
+		assert.Equal(t, []int{2, 1, 1}, counts)
+	})
+
+	t.Run("unsupported interval", func(t *testing.T) {
+		_, err := store.DateHistogram(ctx, "created_at", "fortnight", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+package vector
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Text analyzer languages
+const (
+	AnalyzerStandard = "standard" // standard tokenizer and lowercase, no built-in stopwords
+	AnalyzerEnglish  = "english"  // adds the built-in English stopword list
+	AnalyzerCJK      = "cjk"      // CJK bigrams for Chinese/Japanese/Korean text and the built-in CJK stopword list
+)
+
+// TextAnalyzerName is the custom analyzer applied to content and raw_content.
+// Full-text queries on those fields analyze the query text with it as well, since a field's
+// search analyzer defaults to its index analyzer.
+const TextAnalyzerName = "memory_text"
+
+// englishStopwords is the Lucene English stopword list, applied by MemoryStore
+var englishStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in", "into", "is", "it",
+	"no", "not", "of", "on", "or", "such", "that", "the", "their", "then", "there", "these",
+	"they", "this", "to", "was", "will", "with",
+}
+
+// AnalyzerConfig configures the text analyzer of the memory index.
+// Changing it only affects indices created afterwards; existing indices need a reindex.
+type AnalyzerConfig struct {
+	Language  string   `toml:"language"`  // standard (default), english or cjk
+	Stopwords []string `toml:"stopwords"` // extra stopwords removed in addition to the language list
+}
+
+// Validate checks the analyzer configuration
+func (c *AnalyzerConfig) Validate() error {
+	if c.Language == "" {
+		c.Language = AnalyzerStandard
+	}
+	switch c.Language {
+	case AnalyzerStandard, AnalyzerEnglish, AnalyzerCJK:
+	default:
+		return fmt.Errorf("invalid analyzer language: %s, must be %s, %s or %s", c.Language, AnalyzerStandard, AnalyzerEnglish, AnalyzerCJK)
+	}
+	return nil
+}
+
+// analysis returns the index analysis settings defining TextAnalyzerName
+func (c AnalyzerConfig) analysis() map[string]any {
+	filters := map[string]any{}
+	chain := []string{"lowercase"}
+
+	switch c.Language {
+	case AnalyzerEnglish:
+		filters["memory_language_stop"] = map[string]any{"type": "stop", "stopwords": "_english_"}
+		chain = append(chain, "memory_language_stop")
+	case AnalyzerCJK:
+		filters["memory_language_stop"] = map[string]any{"type": "stop", "stopwords": "_cjk_"}
+		chain = []string{"cjk_width", "lowercase", "cjk_bigram", "memory_language_stop"}
+	}
+	if len(c.Stopwords) > 0 {
+		filters["memory_custom_stop"] = map[string]any{"type": "stop", "stopwords": c.Stopwords, "ignore_case": true}
+		chain = append(chain, "memory_custom_stop")
+	}
+
+	analysis := map[string]any{
+		"analyzer": map[string]any{
+			TextAnalyzerName: map[string]any{"type": "custom", "tokenizer": "standard", "filter": chain},
+		},
+	}
+	if len(filters) > 0 {
+		analysis["filter"] = filters
+	}
+	return analysis
+}
+
+// terms splits text into lowercase terms, dropping stopwords.
+// It approximates the index analyzer for MemoryStore; CJK text is kept as whole runs.
+func (c AnalyzerConfig) terms(text string) []string {
+	stop := make(map[string]bool, len(c.Stopwords)+len(englishStopwords))
+	if c.Language == AnalyzerEnglish {
+		for _, w := range englishStopwords {
+			stop[w] = true
+		}
+	}
+	for _, w := range c.Stopwords {
+		stop[strings.ToLower(w)] = true
+	}
+
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := fields[:0]
+	for _, f := range fields {
+		if !stop[f] {
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,50 @@
+package vector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexMapping_Analyzer(t *testing.T) {
+	analyzer := AnalyzerConfig{Language: AnalyzerEnglish, Stopwords: []string{"please"}}
+	require.NoError(t, analyzer.Validate())
+
+	mapping := IndexMapping(3, analyzer)
+
+	analysis := mapping["settings"].(map[string]any)["analysis"].(map[string]any)
+	definition := analysis["analyzer"].(map[string]any)[TextAnalyzerName].(map[string]any)
+	assert.Equal(t, []string{"lowercase", "memory_language_stop", "memory_custom_stop"}, definition["filter"])
+
+	filters := analysis["filter"].(map[string]any)
+	assert.Equal(t, "_english_", filters["memory_language_stop"].(map[string]any)["stopwords"])
+	assert.Equal(t, []string{"please"}, filters["memory_custom_stop"].(map[string]any)["stopwords"])
+
+	properties := mapping["mappings"].(map[string]any)["properties"].(map[string]any)
+	for _, field := range []string{"content", "raw_content"} {
+		assert.Equal(t, TextAnalyzerName, properties[field].(map[string]any)["analyzer"], field)
+	}
+
+	t.Run("default language", func(t *testing.T) {
+		var cfg AnalyzerConfig
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, AnalyzerStandard, cfg.Language)
+
+		cfg.Language = "klingon"
+		assert.Error(t, cfg.Validate())
+	})
+}
+
+func TestMemoryStore_TextQueryStopwords(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemoryStore(2).WithAnalyzer(AnalyzerConfig{Language: AnalyzerEnglish})
+	require.NoError(t, store.Store(ctx, "coffee", map[string]any{"id": "coffee", "content": "User drinks espresso every morning"}))
+	require.NoError(t, store.Store(ctx, "weather", map[string]any{"id": "weather", "content": "The weather in the city is nice and it is warm"}))
+
+	docs, err := store.Search(ctx, SearchQuery{TextQuery: "what is it that the user drinks in the morning", ScoreThreshold: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"coffee"}, searchIDs(docs), "stopwords neither dilute nor create matches")
+}
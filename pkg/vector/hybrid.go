@@ -0,0 +1,141 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Default hybrid search weights, used when both weights are zero
+const (
+	DefaultHybridVectorWeight = 0.7
+	DefaultHybridTextWeight   = 0.3
+)
+
+// Default full-text field boosts, used when a boost is zero; the original message text ranks above the summary
+const (
+	DefaultRawContentBoost = 2.0
+	DefaultContentBoost    = 1.0
+)
+
+// HybridConfig weights the k-NN and full-text scores of a hybrid search.
+// Each result list is min-max normalized to [0, 1] before the weighted mean is taken,
+// so the weights are comparable regardless of the BM25 score scale.
+// RawContentBoost and ContentBoost weight the two fields inside the full-text query itself.
+type HybridConfig struct {
+	VectorWeight float64 `toml:"vector_weight"`
+	TextWeight   float64 `toml:"text_weight"`
+
+	RawContentBoost float64 `toml:"raw_content_boost"`
+	ContentBoost    float64 `toml:"content_boost"`
+}
+
+// Validate checks hybrid weights and field boosts
+func (c *HybridConfig) Validate() error {
+	if c.VectorWeight < 0 || c.TextWeight < 0 {
+		return fmt.Errorf("hybrid weights must be non-negative")
+	}
+	if c.RawContentBoost < 0 || c.ContentBoost < 0 {
+		return fmt.Errorf("hybrid field boosts must be non-negative")
+	}
+	return nil
+}
+
+// weights returns the configured weights, falling back to the defaults when unset
+func (c HybridConfig) weights() (vectorWeight, textWeight float64) {
+	if c.VectorWeight == 0 && c.TextWeight == 0 {
+		return DefaultHybridVectorWeight, DefaultHybridTextWeight
+	}
+	return c.VectorWeight, c.TextWeight
+}
+
+// textFields returns the multi_match fields with their boosts, falling back to the defaults when unset
+func (c HybridConfig) textFields() []string {
+	rawContentBoost, contentBoost := c.RawContentBoost, c.ContentBoost
+	if rawContentBoost == 0 {
+		rawContentBoost = DefaultRawContentBoost
+	}
+	if contentBoost == 0 {
+		contentBoost = DefaultContentBoost
+	}
+	return []string{
+		"raw_content^" + strconv.FormatFloat(rawContentBoost, 'f', -1, 64),
+		"content^" + strconv.FormatFloat(contentBoost, 'f', -1, 64),
+	}
+}
+
+// hybridSearch runs the k-NN and full-text queries separately and combines their normalized scores
+func (s *OpenSearchStore) hybridSearch(ctx context.Context, query SearchQuery, filters []map[string]any, k int) ([]map[string]any, error) {
+	vectorDocs, err := s.runSearch(ctx, buildKNNQuery(query.Embedding, filters, k))
+	if err != nil {
+		return nil, err
+	}
+	textDocs, err := s.runSearch(ctx, buildTextQuery(query.TextQuery, s.hybrid.textFields(), filters, k))
+	if err != nil {
+		return nil, err
+	}
+	return combineHybrid(vectorDocs, textDocs, s.hybrid, k), nil
+}
+
+// combineHybrid merges k-NN and full-text hits by id into the weighted mean of their min-max normalized scores.
+// A document missing from one list scores 0 for that list. At most k documents are returned, best first.
+func combineHybrid(vectorDocs, textDocs []map[string]any, cfg HybridConfig, k int) []map[string]any {
+	vectorWeight, textWeight := cfg.weights()
+
+	combined := make(map[string]map[string]any)
+	scores := make(map[string]float64)
+	var order []string
+
+	add := func(docs []map[string]any, weight float64) {
+		normalized := normalizeScores(docs)
+		for i, doc := range docs {
+			id := fmt.Sprint(doc["id"])
+			if _, ok := combined[id]; !ok {
+				combined[id] = doc
+				order = append(order, id)
+			}
+			scores[id] += weight * normalized[i]
+		}
+	}
+	add(vectorDocs, vectorWeight)
+	add(textDocs, textWeight)
+
+	results := make([]map[string]any, 0, len(order))
+	for _, id := range order {
+		doc := combined[id]
+		doc["_score"] = scores[id] / (vectorWeight + textWeight)
+		results = append(results, doc)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i]["_score"].(float64) > results[j]["_score"].(float64)
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// normalizeScores min-max normalizes the _score of each document; a single distinct score normalizes to 1
+func normalizeScores(docs []map[string]any) []float64 {
+	normalized := make([]float64, len(docs))
+	if len(docs) == 0 {
+		return normalized
+	}
+
+	lo, hi := docs[0]["_score"].(float64), docs[0]["_score"].(float64)
+	for _, doc := range docs {
+		score := doc["_score"].(float64)
+		lo, hi = min(lo, score), max(hi, score)
+	}
+
+	for i, doc := range docs {
+		if hi == lo {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (doc["_score"].(float64) - lo) / (hi - lo)
+	}
+	return normalized
+}
@@ -0,0 +1,115 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineHybrid(t *testing.T) {
+	hits := func(scores map[string]float64, order ...string) []map[string]any {
+		docs := make([]map[string]any, 0, len(order))
+		for _, id := range order {
+			docs = append(docs, map[string]any{"id": id, "_score": scores[id]})
+		}
+		return docs
+	}
+	// "semantic" wins k-NN, "keyword" wins BM25; BM25 scores are on a much larger scale
+	vectorDocs := func() []map[string]any {
+		return hits(map[string]float64{"semantic": 0.95, "both": 0.9, "keyword": 0.6}, "semantic", "both", "keyword")
+	}
+	textDocs := func() []map[string]any {
+		return hits(map[string]float64{"keyword": 12, "both": 9, "semantic": 2}, "keyword", "both", "semantic")
+	}
+
+	t.Run("vector weight favours k-NN ranking", func(t *testing.T) {
+		docs := combineHybrid(vectorDocs(), textDocs(), HybridConfig{VectorWeight: 0.9, TextWeight: 0.1}, 10)
+		assert.Equal(t, []string{"semantic", "both", "keyword"}, searchIDs(docs))
+	})
+
+	t.Run("text weight favours BM25 ranking", func(t *testing.T) {
+		docs := combineHybrid(vectorDocs(), textDocs(), HybridConfig{VectorWeight: 0.1, TextWeight: 0.9}, 10)
+		assert.Equal(t, []string{"keyword", "both", "semantic"}, searchIDs(docs))
+	})
+
+	t.Run("scores are normalized weighted means", func(t *testing.T) {
+		docs := combineHybrid(vectorDocs(), textDocs(), HybridConfig{VectorWeight: 1, TextWeight: 1}, 1)
+		require.Len(t, docs, 1)
+		assert.Equal(t, "both", docs[0]["id"])
+		assert.InDelta(t, (0.3/0.35+0.7)/2, docs[0]["_score"], 1e-9)
+	})
+
+	t.Run("unset weights use defaults", func(t *testing.T) {
+		vectorWeight, textWeight := HybridConfig{}.weights()
+		assert.Equal(t, DefaultHybridVectorWeight, vectorWeight)
+		assert.Equal(t, DefaultHybridTextWeight, textWeight)
+
+		cfg := HybridConfig{VectorWeight: -1}
+		assert.Error(t, cfg.Validate())
+	})
+}
+
+func TestMemoryStore_HybridSearch(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemoryStore(2).WithHybrid(HybridConfig{VectorWeight: 0.2, TextWeight: 0.8})
+	require.NoError(t, store.Store(ctx, "semantic", map[string]any{"id": "semantic", "content": "espresso", "embedding": []float32{1, 0}}))
+	require.NoError(t, store.Store(ctx, "keyword", map[string]any{"id": "keyword", "content": "coffee", "embedding": []float32{0, 1}}))
+
+	docs, err := store.Search(ctx, SearchQuery{Embedding: []float32{1, 0}, TextQuery: "coffee", HybridSearch: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"keyword", "semantic"}, searchIDs(docs))
+}
+
+func TestOpenSearchStore_TextFieldBoosts(t *testing.T) {
+	ctx := context.Background()
+
+	var fields []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query struct {
+				Bool struct {
+					Must struct {
+						MultiMatch struct {
+							Fields []string `json:"fields"`
+						} `json:"multi_match"`
+					} `json:"must"`
+				} `json:"bool"`
+			} `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		fields = body.Query.Bool.Must.MultiMatch.Fields
+		writeJSON(w, map[string]any{"hits": map[string]any{"total": map[string]any{"value": 0}, "hits": []any{}}})
+	}))
+	t.Cleanup(server.Close)
+
+	newStore := func(hybrid HybridConfig) *OpenSearchStore {
+		cfg := OpenSearchConfig{Addresses: []string{server.URL}, IndexName: "memories", EmbeddingDim: 3, Hybrid: hybrid}
+		require.NoError(t, cfg.Validate())
+		store, err := NewOpenSearchStore(cfg)
+		require.NoError(t, err)
+		return store
+	}
+
+	t.Run("configured boosts", func(t *testing.T) {
+		_, err := newStore(HybridConfig{RawContentBoost: 0.5, ContentBoost: 3}).Search(ctx, SearchQuery{TextQuery: "coffee", Limit: 5})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"raw_content^0.5", "content^3"}, fields)
+	})
+
+	t.Run("unset boosts use defaults", func(t *testing.T) {
+		_, err := newStore(HybridConfig{}).Search(ctx, SearchQuery{TextQuery: "coffee", Limit: 5})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"raw_content^2", "content^1"}, fields)
+	})
+
+	t.Run("negative boost rejected", func(t *testing.T) {
+		cfg := HybridConfig{ContentBoost: -1}
+		assert.Error(t, cfg.Validate())
+	})
+}
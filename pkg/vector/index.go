@@ -0,0 +1,258 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/opensearch-project/opensearch-go/v4/opensearchapi"
+)
+
+// Scanner is implemented by stores that can page through every document, archived ones included.
+// It backs bulk operations such as export and index migration.
+type Scanner interface {
+	// Scan pages through all documents (any status) matching filters, ordered by id.
+	// Pass the last id of the previous page as after; an empty page ends the scan.
+	Scan(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error)
+}
+
+// IndexManager is implemented by stores that can create indices and move aliases.
+// It backs maintenance operations such as embedding dimension migration.
+type IndexManager interface {
+	// IndexName returns the name reads and writes go through (an alias when migrations are used)
+	IndexName() string
+
+	// CreateIndex creates a concrete index with the memory mapping for the given embedding dimension
+	CreateIndex(ctx context.Context, name string, dim int) error
+
+	// AliasTargets returns the indices an alias points to, or nil when name is not an alias
+	AliasTargets(ctx context.Context, alias string) ([]string, error)
+
+	// SwapAlias atomically moves alias from oldIndex to newIndex
+	SwapAlias(ctx context.Context, alias, oldIndex, newIndex string) error
+
+	Scanner
+
+	// ForIndex returns a store writing to a concrete index with the same client and settings
+	ForIndex(name string) Store
+}
+
+// Compile-time check that OpenSearchStore implements IndexManager.
+var _ IndexManager = (*OpenSearchStore)(nil)
+
+// IndexMapping returns the settings and mappings of a memory index with the given embedding dimension
+// and text analyzer. Keep in sync with scripts/lib/infra.py.
+func IndexMapping(dim int, analyzer AnalyzerConfig) map[string]any {
+	knnVector := map[string]any{
+		"type":      "knn_vector",
+		"dimension": dim,
+		"method": map[string]any{
+			"name":       "hnsw",
+			"space_type": "cosinesimil",
+		},
+	}
+
+	return map[string]any{
+		"settings": map[string]any{
+			"index": map[string]any{
+				"knn":                      true,
+				"knn.algo_param.ef_search": 100,
+				"number_of_shards":         1,
+				"number_of_replicas":       0,
+			},
+			"analysis": analyzer.analysis(),
+		},
+		"mappings": map[string]any{
+			"dynamic": true,
+			"properties": map[string]any{
+				"embedding":       knnVector,
+				"topic_embedding": knnVector,
+
+				"id":          map[string]any{"type": "keyword"},
+				"type":        map[string]any{"type": "keyword"},
+				"agent_id":    map[string]any{"type": "keyword"},
+				"user_id":     map[string]any{"type": "keyword"},
+				"session_id":  map[string]any{"type": "keyword"},
+				"status":      map[string]any{"type": "keyword"},
+				"memory_type": map[string]any{"type": "keyword"},
+				"parent_id":   map[string]any{"type": "keyword"},
+				"sentiment":   map[string]any{"type": "keyword"},
+
+				// Event fields are matched with term filters; existing indices keep their
+				// dynamic text mapping until they are rebuilt through a migration
+				"trigger_word": map[string]any{"type": "keyword"},
+				"argument1":    map[string]any{"type": "keyword"},
+				"argument2":    map[string]any{"type": "keyword"},
+
+				"content":     map[string]any{"type": "text", "analyzer": TextAnalyzerName},
+				"raw_content": map[string]any{"type": "text", "analyzer": TextAnalyzerName},
+
+				"created_at":  map[string]any{"type": "date"},
+				"updated_at":  map[string]any{"type": "date"},
+				"occurred_at": map[string]any{"type": "date"},
+				"valid_at":    map[string]any{"type": "date"},
+				"invalid_at":  map[string]any{"type": "date"},
+				"expired_at":  map[string]any{"type": "date"},
+			},
+		},
+	}
+}
+
+// IndexName returns the configured index name
+func (s *OpenSearchStore) IndexName() string {
+	return s.indexName
+}
+
+// EmbeddingDim returns the configured embedding dimension of the index, 0 for an uninitialized store
+func (s *OpenSearchStore) EmbeddingDim() int {
+	if s == nil {
+		return 0
+	}
+	return s.embeddingDim
+}
+
+// DefaultLimit returns the number of results a search returns when the query sets no limit
+func (s *OpenSearchStore) DefaultLimit() int {
+	if s == nil {
+		return DefaultSearchLimit
+	}
+	return searchLimit(0, s.defaultLimit)
+}
+
+// CreateIndex creates a concrete index with the memory mapping
+func (s *OpenSearchStore) CreateIndex(ctx context.Context, name string, dim int) error {
+	if dim <= 0 {
+		return fmt.Errorf("embedding dimension must be positive")
+	}
+
+	body, _ := json.Marshal(IndexMapping(dim, s.analyzer))
+	_, err := s.client.Indices.Create(ctx, opensearchapi.IndicesCreateReq{
+		Index: name,
+		Body:  bytes.NewReader(body),
+	})
+	if err != nil {
+		return fmt.Errorf("create index %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// AliasTargets returns the indices the alias points to
+func (s *OpenSearchStore) AliasTargets(ctx context.Context, alias string) ([]string, error) {
+	resp, err := s.client.Indices.Alias.Get(ctx, opensearchapi.AliasGetReq{Indices: []string{"_all"}, Alias: []string{alias}})
+	if err != nil {
+		if resp != nil && resp.Inspect().Response != nil && resp.Inspect().Response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get alias %s failed: %w", alias, err)
+	}
+
+	indices := make([]string, 0, len(resp.Indices))
+	for index := range resp.Indices {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// EnsureAlias makes sure the configured index name is an alias so it can later be swapped
+// to a new index without downtime. When nothing exists yet it creates "<name>_v1" and points
+// the alias at it. An existing concrete index is left untouched and reported as not aliased.
+func (s *OpenSearchStore) EnsureAlias(ctx context.Context) (bool, error) {
+	targets, err := s.AliasTargets(ctx, s.indexName)
+	if err != nil {
+		return false, err
+	}
+	if len(targets) > 0 {
+		return true, nil
+	}
+
+	resp, err := s.client.Indices.Exists(ctx, opensearchapi.IndicesExistsReq{Indices: []string{s.indexName}})
+	if err == nil {
+		return false, nil // concrete index
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return false, fmt.Errorf("check index %s failed: %w", s.indexName, err)
+	}
+
+	index := s.indexName + "_v1"
+	if err := s.CreateIndex(ctx, index, s.embeddingDim); err != nil {
+		return false, err
+	}
+	if err := s.SwapAlias(ctx, s.indexName, "", index); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SwapAlias atomically moves alias from oldIndex to newIndex in a single _aliases request,
+// so readers and writers never see the alias missing. With an empty oldIndex the alias is only added.
+func (s *OpenSearchStore) SwapAlias(ctx context.Context, alias, oldIndex, newIndex string) error {
+	actions := []map[string]any{
+		{"add": map[string]any{"index": newIndex, "alias": alias, "is_write_index": true}},
+	}
+	if oldIndex != "" {
+		actions = append(actions, map[string]any{"remove": map[string]any{"index": oldIndex, "alias": alias}})
+	}
+
+	body, _ := json.Marshal(map[string]any{"actions": actions})
+	_, err := s.client.Aliases(ctx, opensearchapi.AliasesReq{Body: bytes.NewReader(body)})
+	if err != nil {
+		return fmt.Errorf("swap alias %s from %s to %s failed: %w", alias, oldIndex, newIndex, err)
+	}
+	return nil
+}
+
+// Scan pages through all documents matching filters with search_after on id
+func (s *OpenSearchStore) Scan(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+	if size <= 0 {
+		size = 100
+	}
+
+	filterClauses := []map[string]any{}
+	for field, value := range filters {
+		filterClauses = append(filterClauses, map[string]any{"term": map[string]any{field: value}})
+	}
+
+	query := map[string]any{
+		"size":  size,
+		"sort":  []map[string]any{{"id": map[string]any{"order": "asc"}}},
+		"query": map[string]any{"bool": map[string]any{"filter": filterClauses}},
+	}
+	if after != "" {
+		query["search_after"] = []string{after}
+	}
+
+	queryBody, _ := json.Marshal(query)
+	resp, err := s.client.Search(ctx, &opensearchapi.SearchReq{
+		Indices: []string{s.indexName},
+		Body:    bytes.NewReader(queryBody),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+
+	docs := make([]map[string]any, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		var doc map[string]any
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			continue
+		}
+		s.convertEmbeddingToFloat32(doc)
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// ForIndex returns a store bound to another index
+func (s *OpenSearchStore) ForIndex(name string) Store {
+	return &OpenSearchStore{
+		client:       s.client,
+		indexName:    name,
+		embeddingDim: s.embeddingDim,
+		refresh:      s.refresh,
+		defaultLimit: s.defaultLimit,
+		hybrid:       s.hybrid,
+		analyzer:     s.analyzer,
+	}
+}
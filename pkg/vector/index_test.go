@@ -0,0 +1,211 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAliasCluster is a minimal in-memory OpenSearch that resolves a single alias to its current index.
+type fakeAliasCluster struct {
+	mu      sync.Mutex
+	alias   string
+	target  string
+	indices map[string]map[string]map[string]any // index -> id -> doc
+	paths   []string                             // paths of document reads and writes
+}
+
+func newFakeAliasCluster(t *testing.T, alias string) (*fakeAliasCluster, *OpenSearchStore) {
+	t.Helper()
+
+	cluster := &fakeAliasCluster{alias: alias, indices: map[string]map[string]map[string]any{}}
+	server := httptest.NewServer(http.HandlerFunc(cluster.serve))
+	t.Cleanup(server.Close)
+
+	cfg := OpenSearchConfig{Addresses: []string{server.URL}, IndexName: alias, EmbeddingDim: 3}
+	require.NoError(t, cfg.Validate())
+
+	store, err := NewOpenSearchStore(cfg)
+	require.NoError(t, err)
+	return cluster, store
+}
+
+func (f *fakeAliasCluster) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 3 && parts[1] == "_alias":
+		if f.target == "" {
+			notFound(w)
+			return
+		}
+		writeJSON(w, map[string]any{f.target: map[string]any{"aliases": map[string]any{f.alias: map[string]any{}}}})
+
+	case r.URL.Path == "/_aliases":
+		var body struct {
+			Actions []map[string]map[string]any `json:"actions"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, action := range body.Actions {
+			if add, ok := action["add"]; ok {
+				f.target = add["index"].(string)
+			}
+		}
+		writeJSON(w, map[string]any{"acknowledged": true})
+
+	case len(parts) == 1 && r.Method == http.MethodHead:
+		if _, ok := f.indices[parts[0]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		f.indices[parts[0]] = map[string]map[string]any{}
+		writeJSON(w, map[string]any{"acknowledged": true, "index": parts[0]})
+
+	case len(parts) == 3 && parts[1] == "_doc":
+		f.paths = append(f.paths, r.URL.Path)
+		docs := f.resolve(parts[0])
+		if docs == nil {
+			notFound(w)
+			return
+		}
+		var doc map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&doc)
+		docs[parts[2]] = doc
+		writeJSON(w, map[string]any{"_id": parts[2], "result": "created"})
+
+	case len(parts) == 2 && parts[1] == "_search":
+		f.paths = append(f.paths, r.URL.Path)
+		docs := f.resolve(parts[0])
+		hits := []map[string]any{}
+		for id, doc := range docs {
+			hits = append(hits, map[string]any{"_id": id, "_score": 1.0, "_source": doc})
+		}
+		writeJSON(w, map[string]any{"hits": map[string]any{"total": map[string]any{"value": len(hits)}, "hits": hits}})
+
+	default:
+		notFound(w)
+	}
+}
+
+// resolve returns the documents of an index, following the alias
+func (f *fakeAliasCluster) resolve(name string) map[string]map[string]any {
+	if name == f.alias {
+		name = f.target
+	}
+	return f.indices[name]
+}
+
+func (f *fakeAliasCluster) docs(index string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.indices[index])
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	writeJSON(w, map[string]any{"error": "not found", "status": http.StatusNotFound})
+}
+
+func TestOpenSearchStore_EnsureAlias(t *testing.T) {
+	ctx := context.Background()
+	cluster, store := newFakeAliasCluster(t, "memories")
+
+	aliased, err := store.EnsureAlias(ctx)
+	require.NoError(t, err)
+	assert.True(t, aliased)
+
+	targets, err := store.AliasTargets(ctx, "memories")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"memories_v1"}, targets)
+
+	// An existing alias is kept as is
+	aliased, err = store.EnsureAlias(ctx)
+	require.NoError(t, err)
+	assert.True(t, aliased)
+	assert.Len(t, cluster.indices, 1)
+
+	t.Run("concrete index is left untouched", func(t *testing.T) {
+		cluster, store := newFakeAliasCluster(t, "memories")
+		cluster.indices["memories"] = map[string]map[string]any{}
+
+		aliased, err := store.EnsureAlias(ctx)
+		require.NoError(t, err)
+		assert.False(t, aliased)
+		assert.Len(t, cluster.indices, 1)
+	})
+}
+
+func TestOpenSearchStore_ServesDuringAliasSwap(t *testing.T) {
+	ctx := context.Background()
+	cluster, store := newFakeAliasCluster(t, "memories")
+
+	_, err := store.EnsureAlias(ctx)
+	require.NoError(t, err)
+	require.NoError(t, store.CreateIndex(ctx, "memories_v2", 3))
+
+	// Keep reading and writing while the alias is swapped
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	swapped := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			if err := store.Store(ctx, fmt.Sprintf("doc_%d", i), map[string]any{"content": "x"}); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := store.Search(ctx, SearchQuery{Filters: map[string]any{"type": "summary"}, Limit: 10}); err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case <-swapped:
+				if i > 0 {
+					return
+				}
+			default:
+			}
+		}
+	}()
+
+	require.NoError(t, store.SwapAlias(ctx, "memories", "memories_v1", "memories_v2"))
+	close(swapped)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	targets, err := store.AliasTargets(ctx, "memories")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"memories_v2"}, targets)
+
+	// Writes after the swap land in the new index
+	require.NoError(t, store.Store(ctx, "after_swap", map[string]any{"content": "y"}))
+	assert.Positive(t, cluster.docs("memories_v2"))
+
+	cluster.mu.Lock()
+	defer cluster.mu.Unlock()
+	for _, path := range cluster.paths {
+		assert.True(t, strings.HasPrefix(path, "/memories/"), path)
+	}
+}
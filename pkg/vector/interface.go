@@ -9,4 +9,28 @@ type Store interface {
 
 	// Search searches for documents based on query
 	Search(ctx context.Context, query SearchQuery) ([]map[string]any, error)
+
+	// Delete deletes a document by ID
+	Delete(ctx context.Context, id string) error
+
+	// UpdateFields updates specific fields of a document
+	UpdateFields(ctx context.Context, id string, fields map[string]any) error
+
+	// Count counts active documents matching the filters
+	Count(ctx context.Context, filters map[string]any) (int, error)
+
+	// DeleteByQuery deletes active documents matching the filters
+	DeleteByQuery(ctx context.Context, filters map[string]any) (int, error)
 }
+
+// MultiGetter is implemented by stores that can fetch several documents by ID in one round trip.
+type MultiGetter interface {
+	// MGet returns the documents with the given IDs keyed by ID; missing IDs are omitted
+	MGet(ctx context.Context, ids []string) (map[string]map[string]any, error)
+}
+
+// Compile-time checks that OpenSearchStore implements Store and MultiGetter.
+var (
+	_ Store       = (*OpenSearchStore)(nil)
+	_ MultiGetter = (*OpenSearchStore)(nil)
+)
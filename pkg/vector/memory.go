@@ -0,0 +1,477 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compile-time checks that MemoryStore implements Store, Scanner and MultiGetter.
+var (
+	_ Store       = (*MemoryStore)(nil)
+	_ Scanner     = (*MemoryStore)(nil)
+	_ MultiGetter = (*MemoryStore)(nil)
+)
+
+// MemoryStore is an in-process Store that keeps documents in a map.
+// Search mirrors the OpenSearch query semantics closely enough for local development and tests:
+// exact/terms/range/missing filters, brute-force cosine k-NN, naive term matching for text queries,
+// the same normalized hybrid combination as OpenSearchStore, and created_at ordering for filter-only
+// queries. Nothing is persisted.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	docs         map[string]map[string]any
+	embeddingDim int
+	defaultLimit int
+	hybrid       HybridConfig
+	analyzer     AnalyzerConfig
+}
+
+// NewMemoryStore creates an empty in-memory store for embeddings of the given dimension
+func NewMemoryStore(dim int) *MemoryStore {
+	return &MemoryStore{docs: make(map[string]map[string]any), embeddingDim: dim}
+}
+
+// WithDefaultLimit sets the number of results returned when a query sets no limit
+func (s *MemoryStore) WithDefaultLimit(limit int) *MemoryStore {
+	s.defaultLimit = limit
+	return s
+}
+
+// WithHybrid sets the hybrid search weights
+func (s *MemoryStore) WithHybrid(cfg HybridConfig) *MemoryStore {
+	s.hybrid = cfg
+	return s
+}
+
+// WithAnalyzer sets the stopwords applied to text queries
+func (s *MemoryStore) WithAnalyzer(cfg AnalyzerConfig) *MemoryStore {
+	s.analyzer = cfg
+	return s
+}
+
+// EmbeddingDim returns the configured embedding dimension
+func (s *MemoryStore) EmbeddingDim() int {
+	return s.embeddingDim
+}
+
+// DefaultLimit returns the number of results a search returns when the query sets no limit
+func (s *MemoryStore) DefaultLimit() int {
+	return searchLimit(0, s.defaultLimit)
+}
+
+// Store stores a copy of the document, defaulting status to active
+func (s *MemoryStore) Store(ctx context.Context, id string, doc map[string]any) error {
+	stored := make(map[string]any, len(doc)+1)
+	for k, v := range doc {
+		stored[k] = v
+	}
+	if _, ok := stored["status"]; !ok {
+		stored["status"] = StatusActive
+	}
+
+	for _, field := range []string{"embedding", "content_embedding", "topic_embedding"} {
+		if v, ok := stored[field]; ok {
+			embedding, ok := toFloat32Slice(v)
+			if !ok {
+				return fmt.Errorf("store failed: %s is not a vector", field)
+			}
+			if s.embeddingDim > 0 && len(embedding) > 0 && len(embedding) != s.embeddingDim {
+				return fmt.Errorf("store failed: %s has %d dimensions, expected %d", field, len(embedding), s.embeddingDim)
+			}
+			stored[field] = embedding
+		}
+	}
+
+	s.mu.Lock()
+	s.docs[id] = stored
+	s.mu.Unlock()
+	return nil
+}
+
+// Search searches active documents, see MemoryStore for the supported semantics
+func (s *MemoryStore) Search(ctx context.Context, query SearchQuery) ([]map[string]any, error) {
+	k := searchLimit(query.Limit, s.defaultLimit)
+
+	hasEmbedding := len(query.Embedding) > 0
+	hasTextQuery := query.TextQuery != ""
+
+	if query.HybridSearch && hasEmbedding && hasTextQuery {
+		vectorQuery, textQuery := query, query
+		vectorQuery.TextQuery, vectorQuery.ScoreThreshold = "", 0
+		textQuery.Embedding, textQuery.ScoreThreshold = nil, 0
+
+		vectorDocs, _ := s.Search(ctx, vectorQuery)
+		textDocs, _ := s.Search(ctx, textQuery)
+		return applyScoreThreshold(combineHybrid(vectorDocs, textDocs, s.hybrid, k), query.ScoreThreshold), nil
+	}
+
+	s.mu.RLock()
+	var hits []map[string]any
+	for _, doc := range s.docs {
+		if doc["status"] != StatusActive || !matchesQuery(doc, query) {
+			continue
+		}
+
+		score := 0.0
+		switch {
+		case hasEmbedding:
+			vectorScore, ok := knnScore(doc, query.Embedding)
+			if !ok {
+				continue
+			}
+			score = vectorScore
+		case hasTextQuery:
+			score = textScore(doc, s.analyzer.terms(query.TextQuery))
+			if score == 0 {
+				continue
+			}
+		}
+
+		hit := copyDoc(doc)
+		hit["_score"] = score
+		hits = append(hits, hit)
+	}
+	s.mu.RUnlock()
+
+	if hasEmbedding || hasTextQuery {
+		sort.Slice(hits, func(i, j int) bool {
+			si, sj := hits[i]["_score"].(float64), hits[j]["_score"].(float64)
+			if si != sj {
+				return si > sj
+			}
+			return fmt.Sprint(hits[i]["id"]) < fmt.Sprint(hits[j]["id"])
+		})
+	} else {
+		// Filter-only: created_at desc, id asc, matching the OpenSearch sort used for paging
+		sort.Slice(hits, func(i, j int) bool {
+			ti, _ := toTime(hits[i]["created_at"])
+			tj, _ := toTime(hits[j]["created_at"])
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			return fmt.Sprint(hits[i]["id"]) < fmt.Sprint(hits[j]["id"])
+		})
+		if query.From > 0 {
+			hits = hits[min(query.From, len(hits)):]
+		}
+	}
+
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+
+	return applyScoreThreshold(hits, query.ScoreThreshold), nil
+}
+
+// MGet returns copies of the documents with the given IDs; missing IDs are omitted
+func (s *MemoryStore) MGet(ctx context.Context, ids []string) (map[string]map[string]any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	docs := make(map[string]map[string]any, len(ids))
+	for _, id := range ids {
+		if doc, ok := s.docs[id]; ok {
+			docs[id] = copyDoc(doc)
+		}
+	}
+	return docs, nil
+}
+
+// Scan pages through documents of any status matching filters, ordered by id
+func (s *MemoryStore) Scan(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("scan size must be positive")
+	}
+
+	s.mu.RLock()
+	var docs []map[string]any
+	for id, doc := range s.docs {
+		if id > after && matchesFilters(doc, filters) {
+			docs = append(docs, copyDoc(doc))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(docs, func(i, j int) bool { return fmt.Sprint(docs[i]["id"]) < fmt.Sprint(docs[j]["id"]) })
+	if len(docs) > size {
+		docs = docs[:size]
+	}
+	return docs, nil
+}
+
+// Delete deletes a document by ID
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	delete(s.docs, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// UpdateFields sets the given fields on an existing document; a nil value clears the field
+func (s *MemoryStore) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.docs[id]
+	if !ok {
+		return fmt.Errorf("update failed: document %s not found", id)
+	}
+	for k, v := range fields {
+		doc[k] = v
+	}
+	return nil
+}
+
+// Count counts active documents matching the filters
+func (s *MemoryStore) Count(ctx context.Context, filters map[string]any) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, doc := range s.docs {
+		if doc["status"] == StatusActive && matchesFilters(doc, filters) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteByQuery deletes active documents matching the filters
+func (s *MemoryStore) DeleteByQuery(ctx context.Context, filters map[string]any) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, doc := range s.docs {
+		if doc["status"] == StatusActive && matchesFilters(doc, filters) {
+			delete(s.docs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// matchesQuery applies all filters of a search query
+func matchesQuery(doc map[string]any, query SearchQuery) bool {
+	if !matchesFilters(doc, query.Filters) {
+		return false
+	}
+
+	for field, values := range query.TermsFilters {
+		matched := false
+		for _, v := range values {
+			if termMatches(doc[field], v) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for field, spec := range query.RangeFilters {
+		if !rangeMatches(doc[field], spec) {
+			return false
+		}
+	}
+
+	for _, field := range query.MissingFields {
+		if doc[field] != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesFilters applies exact-match term filters
+func matchesFilters(doc map[string]any, filters map[string]any) bool {
+	for field, value := range filters {
+		if !termMatches(doc[field], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// termMatches mirrors a keyword term query: scalars compare by value, arrays match any element
+func termMatches(docValue, value any) bool {
+	switch v := docValue.(type) {
+	case nil:
+		return false
+	case []string:
+		for _, item := range v {
+			if item == fmt.Sprint(value) {
+				return true
+			}
+		}
+		return false
+	case []any:
+		for _, item := range v {
+			if fmt.Sprint(item) == fmt.Sprint(value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(docValue) == fmt.Sprint(value)
+	}
+}
+
+// rangeMatches compares dates (time.Time or RFC3339 strings) or numbers against gte/gt/lte/lt bounds
+func rangeMatches(docValue any, spec map[string]any) bool {
+	for op, bound := range spec {
+		cmp, ok := compareValues(docValue, bound)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "gte":
+			ok = cmp >= 0
+		case "gt":
+			ok = cmp > 0
+		case "lte":
+			ok = cmp <= 0
+		case "lt":
+			ok = cmp < 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compareValues returns -1, 0 or 1 comparing a to b as times if both parse as times, otherwise as numbers
+func compareValues(a, b any) (int, bool) {
+	if ta, ok := toTime(a); ok {
+		tb, ok := toTime(b)
+		if !ok {
+			return 0, false
+		}
+		return ta.Compare(tb), true
+	}
+
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	switch {
+	case fa < fb:
+		return -1, true
+	case fa > fb:
+		return 1, true
+	}
+	return 0, true
+}
+
+// knnScore converts cosine similarity to the OpenSearch cosinesimil score (1 + cos) / 2
+func knnScore(doc map[string]any, query []float32) (float64, bool) {
+	embedding, ok := doc["embedding"].([]float32)
+	if !ok || len(embedding) != len(query) {
+		return 0, false
+	}
+
+	var dot, normA, normB float64
+	for i := range query {
+		dot += float64(embedding[i]) * float64(query[i])
+		normA += float64(embedding[i]) * float64(embedding[i])
+		normB += float64(query[i]) * float64(query[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, false
+	}
+	return (1 + dot/(math.Sqrt(normA)*math.Sqrt(normB))) / 2, true
+}
+
+// textScore is the fraction of analyzed query terms found in content or raw_content
+func textScore(doc map[string]any, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	haystack := strings.ToLower(fmt.Sprint(doc["content"]) + " " + fmt.Sprint(doc["raw_content"]))
+	found := 0
+	for _, term := range terms {
+		if strings.Contains(haystack, term) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(terms))
+}
+
+func copyDoc(doc map[string]any) map[string]any {
+	out := make(map[string]any, len(doc)+1)
+	for k, v := range doc {
+		out[k] = v
+	}
+	return out
+}
+
+func toFloat32Slice(v any) ([]float32, bool) {
+	switch vec := v.(type) {
+	case nil:
+		return nil, true
+	case []float32:
+		return vec, true
+	case []float64:
+		out := make([]float32, len(vec))
+		for i, f := range vec {
+			out[i] = float32(f)
+		}
+		return out, true
+	case []any:
+		out := make([]float32, len(vec))
+		for i, item := range vec {
+			f, ok := toFloat(item)
+			if !ok {
+				return nil, false
+			}
+			out[i] = float32(f)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func toTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false
+		}
+		return *t, true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		return parsed, err == nil
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,154 @@
+package vector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchIDs(docs []map[string]any) []string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc["id"].(string))
+	}
+	return ids
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore(2)
+	for i, doc := range []map[string]any{
+		{"id": "a", "user_id": "user_1", "keywords": []string{"coffee"}, "content": "likes coffee", "embedding": []float32{1, 0}},
+		{"id": "b", "user_id": "user_1", "keywords": []string{"tea"}, "content": "drinks tea", "embedding": []float32{0, 1}},
+		{"id": "c", "user_id": "user_1", "content": "old coffee fact", "embedding": []float32{1, 0}, "expired_at": base},
+		{"id": "d", "user_id": "user_2", "content": "likes coffee", "embedding": []float32{1, 0}},
+		{"id": "e", "user_id": "user_1", "content": "archived coffee", "embedding": []float32{1, 0}, "status": StatusArchived},
+	} {
+		doc["created_at"] = base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339)
+		require.NoError(t, store.Store(ctx, doc["id"].(string), doc))
+	}
+
+	t.Run("knn search ranks by cosine score", func(t *testing.T) {
+		docs, err := store.Search(ctx, SearchQuery{Embedding: []float32{1, 0}, Filters: map[string]any{"user_id": "user_1"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "c", "b"}, searchIDs(docs))
+		assert.Equal(t, 1.0, docs[0]["_score"])
+		assert.Equal(t, 0.5, docs[2]["_score"])
+	})
+
+	t.Run("score threshold and missing fields", func(t *testing.T) {
+		docs, err := store.Search(ctx, SearchQuery{
+			Embedding:      []float32{1, 0},
+			Filters:        map[string]any{"user_id": "user_1"},
+			MissingFields:  []string{"expired_at"},
+			ScoreThreshold: 0.8,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, searchIDs(docs))
+	})
+
+	t.Run("terms and range filters", func(t *testing.T) {
+		docs, err := store.Search(ctx, SearchQuery{TermsFilters: map[string][]string{"keywords": {"tea", "juice"}}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b"}, searchIDs(docs))
+
+		docs, err = store.Search(ctx, SearchQuery{RangeFilters: map[string]map[string]any{
+			"created_at": {"gte": base.Add(time.Hour), "lt": base.Add(3 * time.Hour).Format(time.RFC3339)},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "b"}, searchIDs(docs))
+	})
+
+	t.Run("filter-only search pages newest first", func(t *testing.T) {
+		docs, err := store.Search(ctx, SearchQuery{Filters: map[string]any{"user_id": "user_1"}, Limit: 2, From: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a"}, searchIDs(docs))
+	})
+
+	t.Run("text query", func(t *testing.T) {
+		docs, err := store.Search(ctx, SearchQuery{TextQuery: "coffee", Filters: map[string]any{"user_id": "user_1"}})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "c"}, searchIDs(docs))
+	})
+
+	t.Run("update fields", func(t *testing.T) {
+		require.NoError(t, store.UpdateFields(ctx, "c", map[string]any{"expired_at": nil}))
+		docs, err := store.Search(ctx, SearchQuery{Embedding: []float32{1, 0}, MissingFields: []string{"expired_at"}, Filters: map[string]any{"user_id": "user_1"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "c", "b"}, searchIDs(docs))
+
+		assert.Error(t, store.UpdateFields(ctx, "missing", map[string]any{"status": StatusDeleted}))
+	})
+
+	t.Run("scan sees every status", func(t *testing.T) {
+		docs, err := store.Scan(ctx, map[string]any{"user_id": "user_1"}, "a", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "c", "e"}, searchIDs(docs))
+	})
+
+	t.Run("count and delete by query only touch active documents", func(t *testing.T) {
+		count, err := store.Count(ctx, map[string]any{"user_id": "user_1"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+
+		deleted, err := store.DeleteByQuery(ctx, map[string]any{"user_id": "user_1"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, deleted)
+
+		docs, err := store.Scan(ctx, nil, "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"d", "e"}, searchIDs(docs))
+	})
+
+	t.Run("rejects embeddings of the wrong dimension", func(t *testing.T) {
+		assert.Error(t, store.Store(ctx, "f", map[string]any{"id": "f", "embedding": []float32{1, 0, 0}}))
+	})
+}
+
+func TestInit_MemoryBackend(t *testing.T) {
+	t.Cleanup(func() { storeInstance = nil })
+
+	cfg := OpenSearchConfig{Backend: BackendMemory, EmbeddingDim: 3}
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, Init(cfg))
+	assert.IsType(t, &MemoryStore{}, NewStore())
+
+	cfg = OpenSearchConfig{Backend: "qdrant", EmbeddingDim: 3}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestSearch_DefaultLimit(t *testing.T) {
+	ctx := context.Background()
+	t.Cleanup(func() { storeInstance = nil })
+
+	cfg := OpenSearchConfig{Backend: BackendMemory, EmbeddingDim: 2, DefaultLimit: 3}
+	require.NoError(t, cfg.Validate())
+	require.NoError(t, Init(cfg))
+
+	store := NewStore()
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, store.Store(ctx, id, map[string]any{"id": id, "embedding": []float32{1, 0}}))
+	}
+
+	docs, err := store.Search(ctx, SearchQuery{Embedding: []float32{1, 0}})
+	require.NoError(t, err)
+	assert.Len(t, docs, 3, "zero limit returns the configured default")
+
+	docs, err = store.Search(ctx, SearchQuery{Embedding: []float32{1, 0}, Limit: 4})
+	require.NoError(t, err)
+	assert.Len(t, docs, 4)
+
+	t.Run("unset default", func(t *testing.T) {
+		cfg := OpenSearchConfig{Backend: BackendMemory, EmbeddingDim: 2}
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, DefaultSearchLimit, cfg.DefaultLimit)
+
+		cfg.DefaultLimit = -1
+		assert.Error(t, cfg.Validate())
+	})
+}
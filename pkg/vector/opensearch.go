@@ -19,11 +19,44 @@ const (
 	StatusDeleted  = "deleted"
 )
 
+// Refresh policies for write requests
+const (
+	// RefreshTrue forces a refresh after every write so the document is searchable
+	// immediately. Simple but expensive; meant for tests and low-volume setups.
+	RefreshTrue = "true"
+
+	// RefreshWaitFor blocks the write until the next scheduled refresh makes it visible.
+	// Keeps read-after-write without forcing extra refreshes, at the cost of write latency.
+	RefreshWaitFor = "wait_for"
+
+	// RefreshFalse returns immediately; documents become searchable after the next
+	// refresh interval (1s by default), so a retrieve right after an add may miss them.
+	RefreshFalse = "false"
+)
+
+// DefaultSearchLimit is the number of results a search returns when SearchQuery.Limit is not positive
+// and OpenSearchConfig.DefaultLimit is unset
+const DefaultSearchLimit = 10
+
+// Storage backends selectable via OpenSearchConfig.Backend
+const (
+	BackendOpenSearch = "opensearch"
+	BackendMemory     = "memory"
+)
+
 // Package-level singleton instance
-var storeInstance *OpenSearchStore
+var storeInstance Store
 
-// Init initializes the OpenSearch store singleton with config.
+// Init initializes the store singleton for the configured backend.
 func Init(cfg OpenSearchConfig) error {
+	if cfg.Backend == BackendMemory {
+		storeInstance = NewMemoryStore(cfg.EmbeddingDim).
+			WithDefaultLimit(cfg.DefaultLimit).
+			WithHybrid(cfg.Hybrid).
+			WithAnalyzer(cfg.Analyzer)
+		return nil
+	}
+
 	store, err := NewOpenSearchStore(cfg)
 	if err != nil {
 		return err
@@ -32,23 +65,58 @@ func Init(cfg OpenSearchConfig) error {
 	return nil
 }
 
-// NewStore returns the singleton OpenSearch store instance.
-func NewStore() *OpenSearchStore {
+// NewStore returns the singleton store instance, nil before Init.
+func NewStore() Store {
 	return storeInstance
 }
 
 // OpenSearchConfig holds OpenSearch configuration
 type OpenSearchConfig struct {
+	Backend      string   `toml:"backend"` // opensearch (default) or memory
 	Addresses    []string `toml:"addresses"`
 	Username     string   `toml:"username"`
 	Password     string   `toml:"password"`
 	IndexName    string   `toml:"index"`
 	EmbeddingDim int      `toml:"embedding_dim"`
 	InsecureSSL  bool     `toml:"insecure_ssl"`
+	Refresh      string   `toml:"refresh"`       // true, false or wait_for (default)
+	DefaultLimit int      `toml:"default_limit"` // results per search when the query sets no limit, default 10
+
+	// Hybrid weights the k-NN and full-text scores when SearchQuery.HybridSearch is set
+	Hybrid HybridConfig `toml:"hybrid"`
+
+	// Analyzer configures stopwords and tokenization of content and raw_content in new indices
+	Analyzer AnalyzerConfig `toml:"analyzer"`
 }
 
 // Validate checks OpenSearch configuration
 func (c *OpenSearchConfig) Validate() error {
+	if c.Backend == "" {
+		c.Backend = BackendOpenSearch
+	}
+	if c.DefaultLimit == 0 {
+		c.DefaultLimit = DefaultSearchLimit
+	}
+	if c.DefaultLimit < 0 {
+		return fmt.Errorf("default_limit must be positive")
+	}
+	if err := c.Hybrid.Validate(); err != nil {
+		return err
+	}
+	if err := c.Analyzer.Validate(); err != nil {
+		return err
+	}
+	switch c.Backend {
+	case BackendOpenSearch:
+	case BackendMemory:
+		// In-memory backend needs no connection settings
+		if c.EmbeddingDim <= 0 {
+			return fmt.Errorf("embedding_dim must be positive")
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid backend: %s, must be %s or %s", c.Backend, BackendOpenSearch, BackendMemory)
+	}
 	if len(c.Addresses) == 0 {
 		return fmt.Errorf("addresses is required")
 	}
@@ -58,6 +126,14 @@ func (c *OpenSearchConfig) Validate() error {
 	if c.EmbeddingDim <= 0 {
 		return fmt.Errorf("embedding_dim must be positive")
 	}
+	if c.Refresh == "" {
+		c.Refresh = RefreshWaitFor
+	}
+	switch c.Refresh {
+	case RefreshTrue, RefreshFalse, RefreshWaitFor:
+	default:
+		return fmt.Errorf("invalid refresh: %s, must be %s, %s or %s", c.Refresh, RefreshTrue, RefreshFalse, RefreshWaitFor)
+	}
 	return nil
 }
 
@@ -72,6 +148,9 @@ type SearchQuery struct {
 	// RangeFilters for range queries (field -> {gte/lte/gt/lt -> value})
 	RangeFilters map[string]map[string]any
 
+	// MissingFields excludes documents that have any of these fields set (null counts as missing)
+	MissingFields []string
+
 	// Embedding vector for k-NN search
 	Embedding []float32
 
@@ -87,6 +166,9 @@ type SearchQuery struct {
 
 	// Limit on results
 	Limit int
+
+	// From skips the first N results of a filter-only search, for paging through all documents
+	From int
 }
 
 // OpenSearchStore implements a generic vector store using OpenSearch k-NN
@@ -94,6 +176,10 @@ type OpenSearchStore struct {
 	client       *opensearchapi.Client
 	indexName    string
 	embeddingDim int
+	refresh      string
+	defaultLimit int
+	hybrid       HybridConfig
+	analyzer     AnalyzerConfig
 }
 
 // NewOpenSearchStore creates a new OpenSearch store
@@ -117,10 +203,19 @@ func NewOpenSearchStore(cfg OpenSearchConfig) (*OpenSearchStore, error) {
 		return nil, fmt.Errorf("failed to create OpenSearch client: %w", err)
 	}
 
+	refresh := cfg.Refresh
+	if refresh == "" {
+		refresh = RefreshWaitFor
+	}
+
 	store := &OpenSearchStore{
 		client:       client,
 		indexName:    cfg.IndexName,
 		embeddingDim: cfg.EmbeddingDim,
+		refresh:      refresh,
+		defaultLimit: cfg.DefaultLimit,
+		hybrid:       cfg.Hybrid,
+		analyzer:     cfg.Analyzer,
 	}
 
 	return store, nil
@@ -143,7 +238,7 @@ func (s *OpenSearchStore) Store(ctx context.Context, id string, doc map[string]a
 		Index:      s.indexName,
 		DocumentID: id,
 		Body:       bytes.NewReader(docBody),
-		Params:     opensearchapi.IndexParams{Refresh: "true"},
+		Params:     opensearchapi.IndexParams{Refresh: s.refresh},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to index document: %w", err)
@@ -177,6 +272,39 @@ func (s *OpenSearchStore) Get(ctx context.Context, id string) (map[string]any, e
 	return doc, nil
 }
 
+// MGet retrieves multiple documents by ID in a single _mget request.
+// Documents that do not exist are omitted from the result.
+func (s *OpenSearchStore) MGet(ctx context.Context, ids []string) (map[string]map[string]any, error) {
+	docs := make(map[string]map[string]any, len(ids))
+	if len(ids) == 0 {
+		return docs, nil
+	}
+
+	body, _ := json.Marshal(map[string]any{"ids": ids})
+	resp, err := s.client.MGet(ctx, opensearchapi.MGetReq{
+		Index: s.indexName,
+		Body:  bytes.NewReader(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mget failed: %w", err)
+	}
+
+	for _, hit := range resp.Docs {
+		if !hit.Found {
+			continue
+		}
+
+		var doc map[string]any
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal document %s: %w", hit.ID, err)
+		}
+		s.convertEmbeddingToFloat32(doc)
+		docs[hit.ID] = doc
+	}
+
+	return docs, nil
+}
+
 // Search searches for documents based on query
 func (s *OpenSearchStore) Search(ctx context.Context, query SearchQuery) ([]map[string]any, error) {
 	// Build filters
@@ -198,57 +326,66 @@ func (s *OpenSearchStore) Search(ctx context.Context, query SearchQuery) ([]map[
 		filters = append(filters, map[string]any{"range": map[string]any{field: rangeSpec}})
 	}
 
-	k := query.Limit
-	if k <= 0 {
-		k = 10
+	// Add missing-field filters
+	for _, field := range query.MissingFields {
+		filters = append(filters, map[string]any{"bool": map[string]any{
+			"must_not": map[string]any{"exists": map[string]any{"field": field}},
+		}})
 	}
 
-	var searchQuery map[string]any
+	k := searchLimit(query.Limit, s.defaultLimit)
+
 	hasEmbedding := len(query.Embedding) > 0
 	hasTextQuery := query.TextQuery != ""
 
-	// Hybrid search: combine k-NN and full-text search
+	var searchQuery map[string]any
 	if query.HybridSearch && hasEmbedding && hasTextQuery {
-		searchQuery = s.buildHybridQuery(query.Embedding, query.TextQuery, filters, k)
+		// Hybrid search: k-NN and full-text scores normalized and combined client-side
+		docs, err := s.hybridSearch(ctx, query, filters, k)
+		if err != nil {
+			return nil, err
+		}
+		return applyScoreThreshold(docs, query.ScoreThreshold), nil
 	} else if hasEmbedding {
 		// Vector-only search (k-NN)
-		searchQuery = map[string]any{
-			"size": k,
-			"query": map[string]any{
-				"bool": map[string]any{
-					"must":   map[string]any{"knn": map[string]any{"embedding": map[string]any{"vector": query.Embedding, "k": k}}},
-					"filter": filters,
-				},
-			},
-		}
+		searchQuery = buildKNNQuery(query.Embedding, filters, k)
 	} else if hasTextQuery {
 		// Text-only search (full-text)
-		searchQuery = map[string]any{
-			"size": k,
-			"query": map[string]any{
-				"bool": map[string]any{
-					"must": map[string]any{
-						"multi_match": map[string]any{
-							"query":  query.TextQuery,
-							"fields": []string{"raw_content^2", "content"}, // 原文权重更高
-							"type":   "best_fields",
-						},
-					},
-					"filter": filters,
-				},
-			},
-		}
+		searchQuery = buildTextQuery(query.TextQuery, s.hybrid.textFields(), filters, k)
 	} else {
-		// No search criteria, just filter with sorting by created_at
+		// No search criteria, just filter with sorting by created_at (id breaks ties for stable paging)
 		searchQuery = map[string]any{
 			"size": k,
-			"sort": []map[string]any{{"created_at": map[string]any{"order": "desc"}}},
+			"sort": []map[string]any{{"created_at": map[string]any{"order": "desc"}}, {"id": map[string]any{"order": "asc"}}},
 			"query": map[string]any{
 				"bool": map[string]any{"filter": filters},
 			},
 		}
+		if query.From > 0 {
+			searchQuery["from"] = query.From
+		}
+	}
+
+	docs, err := s.runSearch(ctx, searchQuery)
+	if err != nil {
+		return nil, err
 	}
+	return applyScoreThreshold(docs, query.ScoreThreshold), nil
+}
+
+// searchLimit returns limit, falling back to defaultLimit and then DefaultSearchLimit when not positive
+func searchLimit(limit, defaultLimit int) int {
+	switch {
+	case limit > 0:
+		return limit
+	case defaultLimit > 0:
+		return defaultLimit
+	}
+	return DefaultSearchLimit
+}
 
+// runSearch executes a search request and returns the hit sources with their _score
+func (s *OpenSearchStore) runSearch(ctx context.Context, searchQuery map[string]any) ([]map[string]any, error) {
 	queryBody, _ := json.Marshal(searchQuery)
 	searchResp, err := s.client.Search(ctx, &opensearchapi.SearchReq{
 		Indices: []string{s.indexName},
@@ -266,16 +403,11 @@ func (s *OpenSearchStore) Search(ctx context.Context, query SearchQuery) ([]map[
 			continue
 		}
 
-		score := float64(hit.Score)
-		if query.ScoreThreshold > 0 && score < query.ScoreThreshold {
-			continue
-		}
-
 		// Convert embedding back to []float32
 		s.convertEmbeddingToFloat32(doc)
 
 		// Add score to document
-		doc["_score"] = score
+		doc["_score"] = float64(hit.Score)
 
 		results = append(results, doc)
 	}
@@ -283,35 +415,47 @@ func (s *OpenSearchStore) Search(ctx context.Context, query SearchQuery) ([]map[
 	return results, nil
 }
 
-// buildHybridQuery builds a hybrid query combining k-NN and full-text search
-// Uses OpenSearch's bool query with should clauses to combine scores
-func (s *OpenSearchStore) buildHybridQuery(embedding []float32, textQuery string, filters []map[string]any, k int) map[string]any {
+// applyScoreThreshold drops documents scoring below threshold; a non-positive threshold keeps all
+func applyScoreThreshold(docs []map[string]any, threshold float64) []map[string]any {
+	if threshold <= 0 {
+		return docs
+	}
+	filtered := docs[:0]
+	for _, doc := range docs {
+		if doc["_score"].(float64) >= threshold {
+			filtered = append(filtered, doc)
+		}
+	}
+	return filtered
+}
+
+// buildKNNQuery builds a filtered k-NN query on the embedding field
+func buildKNNQuery(embedding []float32, filters []map[string]any, k int) map[string]any {
 	return map[string]any{
 		"size": k,
 		"query": map[string]any{
 			"bool": map[string]any{
-				"should": []map[string]any{
-					// k-NN 向量检索
-					{
-						"knn": map[string]any{
-							"embedding": map[string]any{
-								"vector": embedding,
-								"k":      k,
-							},
-						},
-					},
-					// 全文检索（搜索原文和摘要）
-					{
-						"multi_match": map[string]any{
-							"query":  textQuery,
-							"fields": []string{"raw_content^2", "content"}, // 原文权重更高
-							"type":   "best_fields",
-							"boost":  0.5, // 全文检索权重稍低于向量
-						},
+				"must":   map[string]any{"knn": map[string]any{"embedding": map[string]any{"vector": embedding, "k": k}}},
+				"filter": filters,
+			},
+		},
+	}
+}
+
+// buildTextQuery builds a filtered full-text query over the given boosted fields
+func buildTextQuery(textQuery string, fields []string, filters []map[string]any, k int) map[string]any {
+	return map[string]any{
+		"size": k,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"must": map[string]any{
+					"multi_match": map[string]any{
+						"query":  textQuery,
+						"fields": fields,
+						"type":   "best_fields",
 					},
 				},
-				"minimum_should_match": 1,
-				"filter":               filters,
+				"filter": filters,
 			},
 		},
 	}
@@ -339,7 +483,7 @@ func (s *OpenSearchStore) Delete(ctx context.Context, id string) error {
 	_, err := s.client.Document.Delete(ctx, opensearchapi.DocumentDeleteReq{
 		Index:      s.indexName,
 		DocumentID: id,
-		Params:     opensearchapi.DocumentDeleteParams{Refresh: "true"},
+		Params:     opensearchapi.DocumentDeleteParams{Refresh: s.refresh},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
@@ -364,7 +508,8 @@ func (s *OpenSearchStore) DeleteByQuery(ctx context.Context, filters map[string]
 	resp, err := s.client.Document.DeleteByQuery(ctx, opensearchapi.DocumentDeleteByQueryReq{
 		Indices: []string{s.indexName},
 		Body:    bytes.NewReader(queryBody),
-		Params:  opensearchapi.DocumentDeleteByQueryParams{Refresh: opensearchapi.ToPointer(true)},
+		// delete_by_query only accepts true/false; wait_for is treated as true
+		Params: opensearchapi.DocumentDeleteByQueryParams{Refresh: opensearchapi.ToPointer(s.refresh != RefreshFalse)},
 	})
 	if err != nil {
 		return 0, fmt.Errorf("delete by query failed: %w", err)
@@ -431,6 +576,7 @@ func (s *OpenSearchStore) UpdateFields(ctx context.Context, id string, fields ma
 		Index:      s.indexName,
 		DocumentID: id,
 		Body:       bytes.NewReader(updateBody),
+		Params:     opensearchapi.UpdateParams{Refresh: s.refresh},
 	})
 	if err != nil {
 		return fmt.Errorf("update fields failed: %w", err)
@@ -0,0 +1,131 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRecordingStore starts a fake OpenSearch that records the refresh parameter of each write.
+func newRecordingStore(t *testing.T, refresh string) (*OpenSearchStore, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var refreshes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		refreshes = append(refreshes, r.URL.Query().Get("refresh"))
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"result":"created","deleted":0}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := OpenSearchConfig{Addresses: []string{server.URL}, IndexName: "memories", EmbeddingDim: 3, Refresh: refresh}
+	require.NoError(t, cfg.Validate())
+
+	store, err := NewOpenSearchStore(cfg)
+	require.NoError(t, err)
+
+	return store, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), refreshes...)
+	}
+}
+
+func TestOpenSearchStore_RefreshPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	for _, refresh := range []string{RefreshTrue, RefreshFalse, RefreshWaitFor} {
+		t.Run(refresh, func(t *testing.T) {
+			store, recorded := newRecordingStore(t, refresh)
+
+			require.NoError(t, store.Store(ctx, "doc_1", map[string]any{"content": "x"}))
+			require.NoError(t, store.UpdateFields(ctx, "doc_1", map[string]any{"status": StatusArchived}))
+			require.NoError(t, store.Delete(ctx, "doc_1"))
+
+			assert.Equal(t, []string{refresh, refresh, refresh}, recorded())
+		})
+	}
+
+	t.Run("delete by query maps wait_for to true", func(t *testing.T) {
+		store, recorded := newRecordingStore(t, RefreshWaitFor)
+
+		_, err := store.DeleteByQuery(ctx, map[string]any{"user_id": "user_1"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"true"}, recorded())
+	})
+}
+
+func TestOpenSearchConfig_ValidateRefresh(t *testing.T) {
+	cfg := OpenSearchConfig{Addresses: []string{"http://localhost:9200"}, IndexName: "memories", EmbeddingDim: 3}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, RefreshWaitFor, cfg.Refresh)
+
+	cfg.Refresh = "sometimes"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestOpenSearchStore_MGet(t *testing.T) {
+	ctx := context.Background()
+
+	stored := map[string]map[string]any{
+		"a": {"id": "a", "content": "likes coffee", "embedding": []float32{1, 0, 0}},
+		"b": {"id": "b", "content": "lives in Shanghai"},
+	}
+
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		docs := make([]map[string]any, 0, len(body.IDs))
+		for _, id := range body.IDs {
+			if doc, ok := stored[id]; ok {
+				docs = append(docs, map[string]any{"_index": "memories", "_id": id, "found": true, "_source": doc})
+			} else {
+				docs = append(docs, map[string]any{"_index": "memories", "_id": id, "found": false})
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, map[string]any{"docs": docs})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := OpenSearchConfig{Addresses: []string{server.URL}, IndexName: "memories", EmbeddingDim: 3}
+	require.NoError(t, cfg.Validate())
+	store, err := NewOpenSearchStore(cfg)
+	require.NoError(t, err)
+
+	docs, err := store.MGet(ctx, []string{"a", "missing", "b"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/memories/_mget"}, paths, "one request for all ids")
+	require.Len(t, docs, 2)
+	assert.Equal(t, "likes coffee", docs["a"]["content"])
+	assert.Equal(t, []float32{1, 0, 0}, docs["a"]["embedding"])
+	assert.Equal(t, "lives in Shanghai", docs["b"]["content"])
+	assert.NotContains(t, docs, "missing")
+
+	t.Run("no ids skips the request", func(t *testing.T) {
+		docs, err := store.MGet(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, docs)
+		assert.Len(t, paths, 1)
+	})
+}
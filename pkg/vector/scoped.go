@@ -0,0 +1,117 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+)
+
+// Compile-time checks that ScopedStore implements Store, MultiGetter, Scanner and Aggregator.
+var (
+	_ Store       = (*ScopedStore)(nil)
+	_ MultiGetter = (*ScopedStore)(nil)
+	_ Scanner     = (*ScopedStore)(nil)
+	_ Aggregator  = (*ScopedStore)(nil)
+)
+
+// ScopedStore wraps a Store and confines every query to one agent/user pair.
+// Search, Count, DeleteByQuery, Scan and the aggregations always filter on agent_id and
+// user_id, overriding any values set by the caller; ID-based operations are passed through
+// unchanged. Optional capabilities return an error when the wrapped store lacks them.
+type ScopedStore struct {
+	store   Store
+	agentID string
+	userID  string
+}
+
+// NewScopedStore creates a store confined to agentID and userID
+func NewScopedStore(store Store, agentID, userID string) *ScopedStore {
+	return &ScopedStore{store: store, agentID: agentID, userID: userID}
+}
+
+// scope returns a copy of filters with the tenant filters applied
+func (s *ScopedStore) scope(filters map[string]any) map[string]any {
+	scoped := make(map[string]any, len(filters)+2)
+	for k, v := range filters {
+		scoped[k] = v
+	}
+	scoped["agent_id"] = s.agentID
+	scoped["user_id"] = s.userID
+	return scoped
+}
+
+// Store stores a document with the given ID
+func (s *ScopedStore) Store(ctx context.Context, id string, doc map[string]any) error {
+	return s.store.Store(ctx, id, doc)
+}
+
+// Search searches the scoped tenant's documents
+func (s *ScopedStore) Search(ctx context.Context, query SearchQuery) ([]map[string]any, error) {
+	query.Filters = s.scope(query.Filters)
+	return s.store.Search(ctx, query)
+}
+
+// Delete deletes a document by ID
+func (s *ScopedStore) Delete(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, id)
+}
+
+// UpdateFields updates specific fields of a document
+func (s *ScopedStore) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+	return s.store.UpdateFields(ctx, id, fields)
+}
+
+// Count counts the scoped tenant's active documents matching the filters
+func (s *ScopedStore) Count(ctx context.Context, filters map[string]any) (int, error) {
+	return s.store.Count(ctx, s.scope(filters))
+}
+
+// DeleteByQuery deletes the scoped tenant's active documents matching the filters
+func (s *ScopedStore) DeleteByQuery(ctx context.Context, filters map[string]any) (int, error) {
+	return s.store.DeleteByQuery(ctx, s.scope(filters))
+}
+
+// MGet fetches documents by ID, dropping any that belong to another tenant
+func (s *ScopedStore) MGet(ctx context.Context, ids []string) (map[string]map[string]any, error) {
+	getter, ok := s.store.(MultiGetter)
+	if !ok {
+		return nil, fmt.Errorf("mget is not supported by the underlying store")
+	}
+
+	docs, err := getter.MGet(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, doc := range docs {
+		if doc["agent_id"] != s.agentID || doc["user_id"] != s.userID {
+			delete(docs, id)
+		}
+	}
+	return docs, nil
+}
+
+// Scan pages through the scoped tenant's documents (any status) matching the filters
+func (s *ScopedStore) Scan(ctx context.Context, filters map[string]any, after string, size int) ([]map[string]any, error) {
+	scanner, ok := s.store.(Scanner)
+	if !ok {
+		return nil, fmt.Errorf("scan is not supported by the underlying store")
+	}
+	return scanner.Scan(ctx, s.scope(filters), after, size)
+}
+
+// Aggregate counts the scoped tenant's active documents matching the filters by field value
+func (s *ScopedStore) Aggregate(ctx context.Context, field string, filters map[string]any, size int) (map[string]int, error) {
+	aggregator, ok := s.store.(Aggregator)
+	if !ok {
+		return nil, fmt.Errorf("aggregations are not supported by the underlying store")
+	}
+	return aggregator.Aggregate(ctx, field, s.scope(filters), size)
+}
+
+// DateHistogram counts the scoped tenant's active documents matching the filters per interval
+func (s *ScopedStore) DateHistogram(ctx context.Context, field, interval string, filters map[string]any) ([]HistogramBucket, error) {
+	aggregator, ok := s.store.(Aggregator)
+	if !ok {
+		return nil, fmt.Errorf("aggregations are not supported by the underlying store")
+	}
+	return aggregator.DateHistogram(ctx, field, interval, s.scope(filters))
+}
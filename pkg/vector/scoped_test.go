@@ -0,0 +1,104 @@
+package vector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedStore(t *testing.T) {
+	ctx := context.Background()
+
+	inner := NewMemoryStore(2)
+	for id, owner := range map[string][2]string{
+		"mine":        {"agent_1", "user_1"},
+		"other_user":  {"agent_1", "user_2"},
+		"other_agent": {"agent_2", "user_1"},
+	} {
+		require.NoError(t, inner.Store(ctx, id, map[string]any{"id": id, "agent_id": owner[0], "user_id": owner[1], "embedding": []float32{1, 0}}))
+	}
+	store := NewScopedStore(inner, "agent_1", "user_1")
+
+	t.Run("unscoped search applies tenant filters", func(t *testing.T) {
+		docs, err := store.Search(ctx, SearchQuery{Embedding: []float32{1, 0}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mine"}, searchIDs(docs))
+	})
+
+	t.Run("caller filters cannot widen the scope", func(t *testing.T) {
+		filters := map[string]any{"user_id": "user_2"}
+		docs, err := store.Search(ctx, SearchQuery{Filters: filters})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mine"}, searchIDs(docs))
+		assert.Equal(t, map[string]any{"user_id": "user_2"}, filters, "caller filters are not mutated")
+	})
+
+	t.Run("mget drops other tenants' documents", func(t *testing.T) {
+		docs, err := store.MGet(ctx, []string{"mine", "other_user", "other_agent", "missing"})
+		require.NoError(t, err)
+		assert.Len(t, docs, 1)
+		assert.Contains(t, docs, "mine")
+	})
+
+	t.Run("scan is scoped", func(t *testing.T) {
+		docs, err := store.Scan(ctx, map[string]any{"agent_id": "agent_2"}, "", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mine"}, searchIDs(docs))
+	})
+
+	t.Run("aggregations are scoped", func(t *testing.T) {
+		agg := &recordingAggregator{MemoryStore: inner}
+		scoped := NewScopedStore(agg, "agent_1", "user_1")
+
+		_, err := scoped.Aggregate(ctx, "type", map[string]any{"user_id": "user_2"}, 0)
+		require.NoError(t, err)
+		_, err = scoped.DateHistogram(ctx, "created_at", "day", map[string]any{"type": "summary"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []map[string]any{
+			{"agent_id": "agent_1", "user_id": "user_1"},
+			{"agent_id": "agent_1", "user_id": "user_1", "type": "summary"},
+		}, agg.filters)
+	})
+
+	t.Run("aggregations require an aggregating store", func(t *testing.T) {
+		_, err := store.Aggregate(ctx, "type", nil, 0)
+		assert.Error(t, err)
+
+		_, err = store.DateHistogram(ctx, "created_at", "day", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("count and delete by query are scoped", func(t *testing.T) {
+		count, err := store.Count(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		deleted, err := store.DeleteByQuery(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		remaining, err := inner.Count(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, remaining)
+	})
+}
+
+// recordingAggregator records the filters passed to its aggregations
+type recordingAggregator struct {
+	*MemoryStore
+
+	filters []map[string]any
+}
+
+func (a *recordingAggregator) Aggregate(ctx context.Context, field string, filters map[string]any, size int) (map[string]int, error) {
+	a.filters = append(a.filters, filters)
+	return map[string]int{}, nil
+}
+
+func (a *recordingAggregator) DateHistogram(ctx context.Context, field, interval string, filters map[string]any) ([]HistogramBucket, error) {
+	a.filters = append(a.filters, filters)
+	return nil, nil
+}
@@ -130,9 +130,17 @@ class InfraManager:
                     "fact": {"type": "text"},
                     # Summary 字段
                     "episode_ids": {"type": "keyword"},
+                    "memory_type": {"type": "keyword"},
+                    "parent_id": {"type": "keyword"},
+                    "sentiment": {"type": "keyword"},
+                    # Event 字段：互斥触发词按 term 精确匹配主语与触发词，须为 keyword（与 pkg/vector IndexMapping 一致）
+                    "trigger_word": {"type": "keyword"},
+                    "argument1": {"type": "keyword"},
+                    "argument2": {"type": "keyword"},
                     # 时间字段
                     "created_at": {"type": "date"},
-                    "updated_at": {"type": "date"}
+                    "updated_at": {"type": "date"},
+                    "occurred_at": {"type": "date"}
                 }
             }
         }